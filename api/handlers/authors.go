@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+)
+
+// attachPostAuthors embeds author summaries in posts and their comments
+func attachPostAuthors(users service.IUserService, posts []models.PostResponseDTO) {
+	var ids []string
+	for _, post := range posts {
+		ids = append(ids, post.AuthorId)
+		ids = collectCommentAuthors(ids, post.Comments)
+	}
+
+	authors := users.GetAuthorSummaries(ids)
+
+	for i := range posts {
+		posts[i].Author = authorOf(authors, posts[i].AuthorId)
+		setCommentAuthors(authors, posts[i].Comments)
+	}
+}
+
+// attachCommentAuthors embeds author summaries in comments and their replies
+func attachCommentAuthors(users service.IUserService, comments []models.CommentResponseDTO) {
+	authors := users.GetAuthorSummaries(collectCommentAuthors(nil, comments))
+	setCommentAuthors(authors, comments)
+}
+
+func collectCommentAuthors(ids []string, comments []models.CommentResponseDTO) []string {
+	for _, comment := range comments {
+		if comment.AuthorId != "" {
+			ids = append(ids, comment.AuthorId)
+		}
+		ids = collectCommentAuthors(ids, comment.Replies)
+	}
+	return ids
+}
+
+func setCommentAuthors(authors map[string]models.AuthorSummaryDTO, comments []models.CommentResponseDTO) {
+	for i := range comments {
+		comments[i].Author = authorOf(authors, comments[i].AuthorId)
+		setCommentAuthors(authors, comments[i].Replies)
+	}
+}
+
+// attachUsers embeds user summaries in a list of items. slot returns the id
+// of the user an item is about and where the summary of that user goes.
+func attachUsers[T any](users service.IUserService, items []T, slot func(item *T) (string, **models.AuthorSummaryDTO)) {
+	ids := make([]string, 0, len(items))
+	for i := range items {
+		user_id, _ := slot(&items[i])
+		ids = append(ids, user_id)
+	}
+
+	summaries := users.GetAuthorSummaries(ids)
+
+	for i := range items {
+		user_id, summary := slot(&items[i])
+		*summary = authorOf(summaries, user_id)
+	}
+}
+
+func authorOf(authors map[string]models.AuthorSummaryDTO, user_id string) *models.AuthorSummaryDTO {
+	author, found := authors[user_id]
+	if !found {
+		return nil
+	}
+	return &author
+}
+
+// userSummaries turns a list of user ids into user summaries, leaving out
+// users that no longer exist
+func userSummaries(users service.IUserService, ids []string) []models.AuthorSummaryDTO {
+	summaries := users.GetAuthorSummaries(ids)
+
+	list := make([]models.AuthorSummaryDTO, 0, len(ids))
+	for _, user_id := range ids {
+		if summary, found := summaries[user_id]; found {
+			list = append(list, summary)
+		}
+	}
+	return list
+}
+
+// attachConversationMembers embeds member summaries, and the sender of the
+// latest message, in conversations
+func attachConversationMembers(users service.IUserService, conversations []models.ConversationResponseDTO) {
+	var ids []string
+	var messages []*models.MessageResponseDTO
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.MemberIds...)
+		if conversation.LastMessage != nil {
+			messages = append(messages, conversation.LastMessage)
+			ids = collectMessageUsers(ids, *conversation.LastMessage)
+		}
+	}
+
+	summaries := users.GetAuthorSummaries(ids)
+
+	for i, conversation := range conversations {
+		conversations[i].Members = make([]models.AuthorSummaryDTO, 0, len(conversation.MemberIds))
+		for _, member_id := range conversation.MemberIds {
+			if summary, found := summaries[member_id]; found {
+				conversations[i].Members = append(conversations[i].Members, summary)
+			}
+		}
+	}
+
+	for _, message := range messages {
+		setMessageUsers(summaries, message)
+	}
+}
+
+// attachMessageSenders embeds sender summaries in messages, and author
+// summaries in the posts they share
+func attachMessageSenders(users service.IUserService, messages []models.MessageResponseDTO) {
+	var ids []string
+	for _, message := range messages {
+		ids = collectMessageUsers(ids, message)
+	}
+
+	summaries := users.GetAuthorSummaries(ids)
+
+	for i := range messages {
+		setMessageUsers(summaries, &messages[i])
+	}
+}
+
+func collectMessageUsers(ids []string, message models.MessageResponseDTO) []string {
+	ids = append(ids, message.SenderId)
+	if message.Post != nil {
+		ids = append(ids, message.Post.AuthorId)
+	}
+	return ids
+}
+
+func setMessageUsers(summaries map[string]models.AuthorSummaryDTO, message *models.MessageResponseDTO) {
+	message.Sender = authorOf(summaries, message.SenderId)
+	if message.Post != nil {
+		message.Post.Author = authorOf(summaries, message.Post.AuthorId)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) BlockUser(c *gin.Context) {
+	h.setUserRelation(c, "blocked", true, h.service.BlockUser)
+}
+
+func (h *Handler) UnblockUser(c *gin.Context) {
+	h.setUserRelation(c, "blocked", false, h.service.UnblockUser)
+}
+
+func (h *Handler) MuteUser(c *gin.Context) {
+	h.setUserRelation(c, "muted", true, h.service.MuteUser)
+}
+
+func (h *Handler) UnmuteUser(c *gin.Context) {
+	h.setUserRelation(c, "muted", false, h.service.UnmuteUser)
+}
+
+// setUserRelation blocks, mutes or lists as a close friend the user in the
+// URL, or undoes it, responding with the new state of the relation
+func (h *Handler) setUserRelation(c *gin.Context, relation string, state bool, apply func(string, string) error) {
+	user, err := h.users.LookupUser(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := apply(middleware.UserID(c), user.Id); err != nil {
+		switch err.Error() {
+		case "cannot block yourself":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+			return
+		case "cannot mute yourself":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot mute yourself"})
+			return
+		case "cannot add yourself to close friends":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot add yourself to close friends"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": user.Id, relation: state})
+}
+
+func (h *Handler) GetBlockedUsers(c *gin.Context) {
+	user_ids, err := h.service.ListBlockedUsers(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get blocked users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": userSummaries(h.users, user_ids)})
+}
+
+func (h *Handler) GetMutedUsers(c *gin.Context) {
+	user_ids, err := h.service.ListMutedUsers(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get muted users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": userSummaries(h.users, user_ids)})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SavePost(c *gin.Context) {
+	var requestBody struct {
+		CollectionId string `json:"collection_id"`
+	}
+
+	// The body is optional; without it the post is only bookmarked
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	post_Id := c.Param("id")
+
+	if err := h.service.SavePost(post_Id, middleware.UserID(c), requestBody.CollectionId); err != nil {
+		respondCollectionError(c, err, "Error saving post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post_Id, "saved": true})
+}
+
+func (h *Handler) UnsavePost(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	if err := h.service.UnsavePost(post_Id, middleware.UserID(c)); err != nil {
+		respondCollectionError(c, err, "Error unsaving post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post_Id, "saved": false})
+}
+
+func (h *Handler) GetSavedPosts(c *gin.Context) {
+	query, ok := savedPostQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.ListSavedPosts(middleware.UserID(c), query)
+
+	if err != nil {
+		respondCollectionError(c, err, "Failed to get saved posts")
+		return
+	}
+
+	h.respondSavedPosts(c, page)
+}
+
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.service.CreateCollection(middleware.UserID(c), requestBody.Name)
+
+	if err != nil {
+		respondCollectionError(c, err, "Error creating collection")
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *Handler) GetCollections(c *gin.Context) {
+	collections, err := h.service.ListCollections(middleware.UserID(c))
+
+	if err != nil {
+		respondCollectionError(c, err, "Failed to get collections")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+func (h *Handler) ReorderCollections(c *gin.Context) {
+	var requestBody struct {
+		CollectionIds []string `json:"collection_ids"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ReorderCollections(middleware.UserID(c), requestBody.CollectionIds); err != nil {
+		respondCollectionError(c, err, "Error updating collections")
+		return
+	}
+
+	h.GetCollections(c)
+}
+
+func (h *Handler) RenameCollection(c *gin.Context) {
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.service.RenameCollection(c.Param("id"), middleware.UserID(c), requestBody.Name)
+
+	if err != nil {
+		respondCollectionError(c, err, "Error updating collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	collection_Id := c.Param("id")
+
+	if err := h.service.DeleteCollection(collection_Id, middleware.UserID(c)); err != nil {
+		respondCollectionError(c, err, "Error deleting collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collection deleted": collection_Id})
+}
+
+func (h *Handler) GetCollectionPosts(c *gin.Context) {
+	query, ok := savedPostQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.ListCollectionPosts(c.Param("id"), middleware.UserID(c), query)
+
+	if err != nil {
+		respondCollectionError(c, err, "Failed to get collection")
+		return
+	}
+
+	h.respondSavedPosts(c, page)
+}
+
+func (h *Handler) AddToCollection(c *gin.Context) {
+	var requestBody struct {
+		PostId string `json:"post_id"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection_Id := c.Param("id")
+
+	if err := h.service.AddToCollection(collection_Id, middleware.UserID(c), requestBody.PostId); err != nil {
+		respondCollectionError(c, err, "Error updating collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collection_id": collection_Id, "post_id": requestBody.PostId})
+}
+
+func (h *Handler) ReorderCollectionPosts(c *gin.Context) {
+	var requestBody struct {
+		PostIds []string `json:"post_ids"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection_Id := c.Param("id")
+
+	if err := h.service.ReorderCollectionPosts(collection_Id, middleware.UserID(c), requestBody.PostIds); err != nil {
+		respondCollectionError(c, err, "Error updating collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collection_id": collection_Id, "post_ids": requestBody.PostIds})
+}
+
+func (h *Handler) RemoveFromCollection(c *gin.Context) {
+	collection_Id := c.Param("id")
+	post_Id := c.Param("post_id")
+
+	if err := h.service.RemoveFromCollection(collection_Id, middleware.UserID(c), post_Id); err != nil {
+		respondCollectionError(c, err, "Error updating collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collection_id": collection_Id, "post_id": post_Id})
+}
+
+func (h *Handler) respondSavedPosts(c *gin.Context, page models.SavedPostPageDTO) {
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.service, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
+
+// savedPostQuery reads the page of saved posts asked for, responding with an
+// error when the parameters are invalid
+func savedPostQuery(c *gin.Context) (models.SavedPostQueryDTO, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return models.SavedPostQueryDTO{}, false
+	}
+
+	return models.SavedPostQueryDTO{Cursor: c.Query("cursor"), Limit: limit}, true
+}
+
+func respondCollectionError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "error retrieving collection":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	case "error retrieving post":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	case "invalid collection name", "invalid order", "invalid cursor":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetDrafts(c *gin.Context) {
+	drafts, err := h.service.ListDrafts(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get drafts"})
+		return
+	}
+
+	attachPostAuthors(h.users, drafts)
+
+	c.JSON(http.StatusOK, gin.H{"drafts": drafts})
+}
+
+func (h *Handler) PublishPost(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	if err := h.service.PublishPost(post_Id, middleware.UserID(c)); err != nil {
+		respondDraftError(c, err, "Error publishing post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post_Id, "published": true})
+}
+
+func (h *Handler) SchedulePost(c *gin.Context) {
+	var requestBody struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	post_Id := c.Param("id")
+
+	if err := h.service.SchedulePost(post_Id, middleware.UserID(c), requestBody.PublishAt); err != nil {
+		respondDraftError(c, err, "Error scheduling post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post_Id, "publish_at": requestBody.PublishAt})
+}
+
+func respondDraftError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "error retrieving post":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	case "unauthorized":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case "post already published", "invalid publish time":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ExploreHandler struct {
+	explore service.IExploreService
+	posts   service.IService
+	users   service.IUserService
+}
+
+func NewExploreHandler(explore service.IExploreService, posts service.IService, users service.IUserService) *ExploreHandler {
+	return &ExploreHandler{
+		explore: explore,
+		posts:   posts,
+		users:   users,
+	}
+}
+
+func (h *ExploreHandler) GetExplore(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.ExploreQueryDTO{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	page, err := h.explore.GetExplore(middleware.UserID(c), query)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
+		return
+	}
+
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.posts, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
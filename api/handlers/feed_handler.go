@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	feed  service.IFeedService
+	posts service.IService
+	users service.IUserService
+}
+
+func NewFeedHandler(feed service.IFeedService, posts service.IService, users service.IUserService) *FeedHandler {
+	return &FeedHandler{
+		feed:  feed,
+		posts: posts,
+		users: users,
+	}
+}
+
+func (h *FeedHandler) GetFeed(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.FeedQueryDTO{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	page, err := h.feed.GetFeed(middleware.UserID(c), query)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.posts, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) FollowUser(c *gin.Context) {
+	user, err := h.users.LookupUser(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	status, err := h.service.FollowUser(middleware.UserID(c), user.Id)
+
+	if err != nil {
+		if err.Error() == "cannot follow yourself" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+			return
+		}
+
+		// Blocked users are not told about the block
+		if err.Error() == "blocked" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error following user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": user.Id, "following": status == models.FollowStatusFollowing, "status": status})
+}
+
+func (h *Handler) UnfollowUser(c *gin.Context) {
+	user, err := h.users.LookupUser(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = h.service.UnfollowUser(middleware.UserID(c), user.Id)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unfollowing user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": user.Id, "following": false})
+}
+
+func (h *Handler) GetFollowers(c *gin.Context) {
+	h.listFollows(c, h.service.ListFollowers)
+}
+
+func (h *Handler) GetFollowing(c *gin.Context) {
+	h.listFollows(c, h.service.ListFollowing)
+}
+
+// listFollows responds with a page of a follower or following list
+func (h *Handler) listFollows(c *gin.Context, list func(string, string, models.FollowQueryDTO) (models.FollowPageDTO, error)) {
+	user, err := h.users.LookupUser(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := list(user.Id, middleware.UserID(c), models.FollowQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		switch err.Error() {
+		case "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		case "private account":
+			c.JSON(http.StatusForbidden, gin.H{"error": "This account is private"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+		return
+	}
+
+	attachUsers(h.users, page.Users, func(follow *models.FollowDTO) (string, **models.AuthorSummaryDTO) {
+		return follow.UserId, &follow.User
+	})
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetFollowRequests(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := h.service.ListFollowRequests(middleware.UserID(c), models.FollowQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get follow requests"})
+		return
+	}
+
+	attachUsers(h.users, page.Requests, func(request *models.FollowRequestDTO) (string, **models.AuthorSummaryDTO) {
+		return request.RequesterId, &request.Requester
+	})
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) ApproveFollowRequest(c *gin.Context) {
+	h.decideFollowRequest(c, true)
+}
+
+func (h *Handler) DenyFollowRequest(c *gin.Context) {
+	h.decideFollowRequest(c, false)
+}
+
+// decideFollowRequest approves or denies the request of the user in the URL
+// to follow the caller
+func (h *Handler) decideFollowRequest(c *gin.Context, approve bool) {
+	requester, err := h.users.LookupUser(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+		return
+	}
+
+	if approve {
+		err = h.service.ApproveFollowRequest(middleware.UserID(c), requester.Id)
+	} else {
+		err = h.service.DenyFollowRequest(middleware.UserID(c), requester.Id)
+	}
+
+	if err != nil {
+		if err.Error() == "follow request not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating follow request"})
+		return
+	}
+
+	status := models.FollowRequestDenied
+	if approve {
+		status = models.FollowRequestApproved
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": requester.Id, "status": status})
+}
+
+func (h *Handler) AddCloseFriend(c *gin.Context) {
+	h.setUserRelation(c, "close_friend", true, h.service.AddCloseFriend)
+}
+
+func (h *Handler) RemoveCloseFriend(c *gin.Context) {
+	h.setUserRelation(c, "close_friend", false, h.service.RemoveCloseFriend)
+}
+
+func (h *Handler) GetCloseFriends(c *gin.Context) {
+	user_ids, err := h.service.ListCloseFriends(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get close friends"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": userSummaries(h.users, user_ids)})
+}
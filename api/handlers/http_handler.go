@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/imaging"
 	"github.com/anandh86/instagram/models"
 	"github.com/anandh86/instagram/service"
 	"github.com/gin-gonic/gin"
@@ -13,11 +20,15 @@ import (
 
 type Handler struct {
 	service service.IService
+	users   service.IUserService
+	config  config.Config
 }
 
-func NewHandler(serv service.IService) *Handler {
+func NewHandler(serv service.IService, users service.IUserService, cfg config.Config) *Handler {
 	return &Handler{
 		service: serv,
+		users:   users,
+		config:  cfg,
 	}
 }
 
@@ -25,45 +36,48 @@ func (h *Handler) CreatePost(c *gin.Context) {
 	// Fetch the caption from the form data
 	caption := c.PostForm("caption")
 
-	// Fetch the image file from the form data
-	fileHeader, err := c.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required"})
-		return
-	}
-
-	if fileHeader == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Error processing image file"})
+	post_img, ok := h.readPostImage(c)
+	if !ok {
 		return
 	}
 
-	// Check file size (100MB = 100 * 1024 * 1024 bytes)
-	const MaxFileSize = 100 * 1024 * 1024 // 100MB
-	if fileHeader.Size > MaxFileSize {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size exceeds limit (100MB)"})
-		return
+	postRequestDTO := models.PostRequestDTO{
+		Caption:    caption,
+		AuthorId:   middleware.UserID(c),
+		Visibility: c.PostForm("visibility"),
 	}
 
-	post_img, format, imgErr := h.processImage(fileHeader)
-
-	if imgErr != nil || post_img == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Error processing image file"})
-		return
+	// Posts can be kept as drafts or scheduled for later
+	if draft := c.PostForm("draft"); draft != "" {
+		var err error
+		if postRequestDTO.Draft, err = strconv.ParseBool(draft); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
 	}
 
-	if format != "png" && format != "jpeg" && format != "jpg" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+	if publish_at := c.PostForm("publish_at"); publish_at != "" {
+		at, err := time.Parse(time.RFC3339, publish_at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
+		postRequestDTO.PublishAt = &at
 	}
 
-	postRequestDTO := models.PostRequestDTO{
-		Caption:  caption,
-		AuthorId: "1234",
+	if postRequestDTO.Location, ok = h.readPostLocation(c); !ok {
+		return
 	}
 
 	post_id, post_err := h.service.CreatePost(post_img, postRequestDTO)
 
 	if post_err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Error creating post"})
+		switch post_err.Error() {
+		case "invalid visibility", "invalid publish time", "invalid location":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error creating post"})
+		}
 		return
 	}
 
@@ -79,7 +93,7 @@ func (h *Handler) GetPostById(c *gin.Context) {
 		return
 	}
 
-	post_img, _, err := h.service.GetPostById(post_Id)
+	post_img, _, err := h.service.GetPostById(post_Id, middleware.UserID(c))
 
 	if err != nil || post_img == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error getting post"})
@@ -97,7 +111,7 @@ func (h *Handler) GetPostById(c *gin.Context) {
 
 func (h *Handler) GetAllPosts(c *gin.Context) {
 
-	postsMetaDatas, err := h.service.GetAllPosts()
+	postsMetaDatas, err := h.service.GetAllPosts(middleware.UserID(c))
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get posts"})
@@ -109,28 +123,152 @@ func (h *Handler) GetAllPosts(c *gin.Context) {
 	for _, postMeta := range postsMetaDatas {
 
 		postResponse := models.PostResponseDTO{
-			Id:       postMeta.Id,
-			Caption:  postMeta.Caption,
-			AuthorId: postMeta.Creator,
-			ImageId:  postMeta.ImageId,
-			Comments: postMeta.Comments,
+			Id:            postMeta.Id,
+			Caption:       postMeta.Caption,
+			CreatedAt:     postMeta.CreatedAt,
+			AuthorId:      postMeta.Creator,
+			ImageId:       postMeta.ImageId,
+			CommentPolicy: postMeta.CommentPolicy,
+			Visibility:    postMeta.Visibility,
+			Mentions:      postMeta.Mentions,
+			Location:      postMeta.Location,
+			Comments:      postMeta.Comments,
 		}
 
 		responses = append(responses, postResponse)
 	}
 
+	attachPostAuthors(h.users, responses)
+	attachLikes(h.service, middleware.UserID(c), responses)
+
 	c.JSON(http.StatusOK, gin.H{"posts": responses})
 
 }
 
+func (h *Handler) DeletePost(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	if post_Id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	err := h.service.DeletePost(post_Id, middleware.UserID(c))
+
+	if err != nil {
+		if err.Error() == "unauthorized" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		} else if err.Error() == "error retrieving post" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post deleted": post_Id})
+}
+
+func (h *Handler) EditPost(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	var requestBody struct {
+		Caption *string `json:"caption"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Caption == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	err := h.service.EditPost(post_Id, middleware.UserID(c), *requestBody.Caption)
+
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case "error retrieving post":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error editing post"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post updated": post_Id})
+}
+
+func (h *Handler) UpdatePostSettings(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	var requestBody struct {
+		CommentPolicy string `json:"comment_policy"`
+		Visibility    string `json:"visibility"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := models.PostSettingsDTO{
+		CommentPolicy: requestBody.CommentPolicy,
+		Visibility:    requestBody.Visibility,
+	}
+
+	err := h.service.UpdatePostSettings(post_Id, middleware.UserID(c), settings)
+
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case "error retrieving post":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post not found"})
+		case "invalid comment policy", "invalid visibility":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating post settings"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post updated": post_Id})
+}
+
+func (h *Handler) RestorePost(c *gin.Context) {
+	post_Id := c.Param("id")
+
+	if post_Id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	err := h.service.RestorePost(post_Id, middleware.UserID(c))
+
+	if err != nil {
+		h.respondRestoreError(c, err, "Post not found", "Error restoring post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post restored": post_Id})
+}
+
 func (h *Handler) CommentOnPost(c *gin.Context) {
 
 	// Fetch the postId from the URL
-	postId := c.Param("postId")
-	// TODO: user id can be fetched from JWT token
+	postId := c.Param("id")
 	var requestBody struct {
-		Comment string `json:"comment"`
-		UserId  string `json:"user_id"`
+		Comment  string `json:"comment"`
+		ParentId string `json:"parent_comment_id"`
 	}
 
 	// Bind the JSON request
@@ -139,7 +277,7 @@ func (h *Handler) CommentOnPost(c *gin.Context) {
 		return
 	}
 
-	if requestBody.Comment == "" || postId == "" || requestBody.UserId == "" {
+	if requestBody.Comment == "" || postId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
 		return
 	}
@@ -147,12 +285,22 @@ func (h *Handler) CommentOnPost(c *gin.Context) {
 	commentRequestDTO := models.CommentRequestDTO{
 		Comment:  requestBody.Comment,
 		PostId:   postId,
-		AuthorId: requestBody.UserId,
+		ParentId: requestBody.ParentId,
+		AuthorId: middleware.UserID(c),
 	}
 
 	comment_id, err := h.service.CommentOnPost(commentRequestDTO)
 
 	if err != nil {
+		switch err.Error() {
+		case "error retrieving parent comment":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found"})
+			return
+		case "comments disabled", "comments limited to followers":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Commenting is restricted on this post"})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating comment"})
 		return
 	}
@@ -162,6 +310,44 @@ func (h *Handler) CommentOnPost(c *gin.Context) {
 
 }
 
+func (h *Handler) GetPostComments(c *gin.Context) {
+	postId := c.Param("id")
+
+	limit, limitErr := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	// Optional depth limit; the configured maximum applies when omitted
+	depth, depthErr := strconv.Atoi(c.DefaultQuery("depth", "0"))
+
+	if limitErr != nil || depthErr != nil || limit < 0 || depth < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.CommentQueryDTO{
+		Order:    c.Query("order"),
+		Cursor:   c.Query("cursor"),
+		Limit:    limit,
+		MaxDepth: depth,
+	}
+
+	page, err := h.service.ListPostComments(postId, middleware.UserID(c), query)
+
+	if err != nil {
+		switch err.Error() {
+		case "error retrieving post":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post not found"})
+		case "invalid order", "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comments"})
+		}
+		return
+	}
+
+	attachCommentAuthors(h.users, page.Comments)
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *Handler) DeleteComment(c *gin.Context) {
 	comment_Id := c.Param("id")
 
@@ -170,9 +356,69 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	// TODO : This can be fetched from JWT token (when implemented)
+	err := h.service.DeleteComment(comment_Id, middleware.UserID(c))
+
+	if err != nil {
+		if err.Error() == "unauthorized" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		} else if err.Error() == "error retrieving comment" || err.Error() == "error retrieving post" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Comment not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment deleted": comment_Id})
+
+}
+
+func (h *Handler) HideComment(c *gin.Context) {
+	h.setCommentHidden(c, true)
+}
+
+func (h *Handler) UnhideComment(c *gin.Context) {
+	h.setCommentHidden(c, false)
+}
+
+// setCommentHidden lets the author of a post hide or show a comment on it
+func (h *Handler) setCommentHidden(c *gin.Context, hidden bool) {
+	comment_Id := c.Param("id")
+
+	var err error
+	if hidden {
+		err = h.service.HideComment(comment_Id, middleware.UserID(c))
+	} else {
+		err = h.service.UnhideComment(comment_Id, middleware.UserID(c))
+	}
+
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case "error retrieving comment", "error retrieving post":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Comment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating comment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment_id": comment_Id, "hidden": hidden})
+}
+
+func (h *Handler) EditComment(c *gin.Context) {
+	comment_Id := c.Param("id")
+
+	if comment_Id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
 	var requestBody struct {
-		AuthorId string `json:"author_id"`
+		Comment string `json:"comment"`
 	}
 
 	// Bind the JSON request
@@ -181,26 +427,205 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	err := h.service.DeleteComment(comment_Id, requestBody.AuthorId)
+	comment, err := h.service.EditComment(comment_Id, middleware.UserID(c), requestBody.Comment)
 
 	if err != nil {
-		if err.Error() == "unauthorized" {
+		switch err.Error() {
+		case "unauthorized":
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		} else if err.Error() == "error retrieving comment" {
+		case "error retrieving comment":
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Comment not found"})
+		case "empty comment":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		case "edit window expired":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Edit window has expired"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error editing comment"})
+		}
+		return
+	}
+
+	edited := []models.CommentResponseDTO{{
+		Id:        comment.Id,
+		ParentId:  comment.ParentId,
+		Comment:   comment.Content,
+		AuthorId:  comment.Creator,
+		CreatedAt: comment.CreatedAt,
+		EditedAt:  comment.EditedAt,
+	}}
+	attachCommentAuthors(h.users, edited)
+
+	c.JSON(http.StatusOK, gin.H{"comment": edited[0]})
+}
+
+func (h *Handler) GetCommentRevisions(c *gin.Context) {
+	comment_Id := c.Param("id")
+
+	revisions, err := h.service.GetCommentRevisions(comment_Id, middleware.UserID(c))
+
+	if err != nil {
+		if err.Error() == "unauthorized" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting comment"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"comment deleted": comment_Id})
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+func (h *Handler) RestoreComment(c *gin.Context) {
+	comment_Id := c.Param("id")
+
+	if comment_Id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	err := h.service.RestoreComment(comment_Id, middleware.UserID(c))
+
+	if err != nil {
+		h.respondRestoreError(c, err, "Comment not found", "Error restoring comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment restored": comment_Id})
+}
+
+// respondRestoreError maps the errors of a restore operation to a response
+func (h *Handler) respondRestoreError(c *gin.Context, err error, notFoundMsg, defaultMsg string) {
+	switch err.Error() {
+	case "unauthorized":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case "error retrieving post", "error retrieving comment":
+		c.JSON(http.StatusBadRequest, gin.H{"error": notFoundMsg})
+	case "not deleted":
+		c.JSON(http.StatusConflict, gin.H{"error": "Item is not in the trash"})
+	case "restore window expired":
+		c.JSON(http.StatusGone, gin.H{"error": "Restore window has expired"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": defaultMsg})
+	}
+}
+
+// readPostImage reads the image of a post or story from the request,
+// cropped and scaled to the square posts are shown in
+func (h *Handler) readPostImage(c *gin.Context) (image.Image, bool) {
+	img, ok := readImageUpload(c, h.config.MaxUploadSize)
+	if !ok {
+		return nil, false
+	}
+
+	return imaging.Square(img, h.config.PostImageSize), true
+}
+
+// readPostLocation reads the place a post is tagged with from the form
+// data, if any. The coordinates recorded by the camera in the photo are
+// only used when the author opts in with use_photo_location.
+func (h *Handler) readPostLocation(c *gin.Context) (*models.PlaceDTO, bool) {
+	name := c.PostForm("location_name")
+	latitude, longitude := c.PostForm("latitude"), c.PostForm("longitude")
+
+	usePhoto := false
+	if value := c.PostForm("use_photo_location"); value != "" {
+		var err error
+		if usePhoto, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return nil, false
+		}
+	}
+
+	if name == "" && latitude == "" && longitude == "" && !usePhoto {
+		return nil, true
+	}
+
+	location := &models.PlaceDTO{Name: name}
+
+	if usePhoto && latitude == "" && longitude == "" {
+		var found bool
+		location.Latitude, location.Longitude, found = readPhotoLocation(c, h.config.MaxUploadSize)
+
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No location in photo"})
+			return nil, false
+		}
+
+		return location, true
+	}
+
+	var latErr, lngErr error
+	location.Latitude, latErr = strconv.ParseFloat(latitude, 64)
+	location.Longitude, lngErr = strconv.ParseFloat(longitude, 64)
+
+	if usePhoto || latErr != nil || lngErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return nil, false
+	}
+
+	return location, true
+}
+
+// readPhotoLocation reads the GPS coordinates in the EXIF metadata of the
+// uploaded image
+func readPhotoLocation(c *gin.Context, maxSize int64) (lat, lng float64, ok bool) {
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return 0, 0, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return 0, 0, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return imaging.ExifLocation(data)
+}
+
+// readImageUpload decodes the image uploaded in the "image" form field,
+// responding with an error when it is missing, too large or not an image
+func readImageUpload(c *gin.Context, maxSize int64) (image.Image, bool) {
+	// Fetch the image file from the form data
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required"})
+		return nil, false
+	}
+
+	if fileHeader == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error processing image file"})
+		return nil, false
+	}
+
+	// Check file size against the configured limit
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File size exceeds limit (%dMB)", maxSize/(1024*1024))})
+		return nil, false
+	}
+
+	img, format, imgErr := processImage(fileHeader)
+
+	if imgErr != nil || img == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error processing image file"})
+		return nil, false
+	}
+
+	if format != "png" && format != "jpeg" && format != "jpg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return nil, false
+	}
 
+	return img, true
 }
 
-func (h *Handler) processImage(fileHeader *multipart.FileHeader) (image.Image, string, error) {
+func processImage(fileHeader *multipart.FileHeader) (image.Image, string, error) {
 	// Open the file
 	file, err := fileHeader.Open()
 	if err != nil {
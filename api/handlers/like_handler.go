@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) LikePost(c *gin.Context) {
+	h.setLike(c, h.service.LikePost)
+}
+
+func (h *Handler) UnlikePost(c *gin.Context) {
+	h.setLike(c, h.service.UnlikePost)
+}
+
+// setLike likes or unlikes the post in the URL, responding with its likes
+func (h *Handler) setLike(c *gin.Context, apply func(string, string) (models.LikeSummaryDTO, error)) {
+	post_Id := c.Param("id")
+
+	summary, err := apply(post_Id, middleware.UserID(c))
+
+	if err != nil {
+		if err.Error() == "error retrieving post" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating like"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post_Id, "like_count": summary.LikeCount, "liked_by_me": summary.LikedByMe})
+}
+
+func (h *Handler) GetPostLikes(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := h.service.ListPostLikes(c.Param("id"), middleware.UserID(c), models.LikeQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		switch err.Error() {
+		case "error retrieving post":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		case "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get likes"})
+		}
+		return
+	}
+
+	attachUsers(h.users, page.Likes, func(like *models.LikeDTO) (string, **models.AuthorSummaryDTO) {
+		return like.UserId, &like.User
+	})
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) ReactToComment(c *gin.Context) {
+	var requestBody struct {
+		Reaction string `json:"reaction"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setReaction(c, func(comment_id, user_id string) (models.CommentReactionsDTO, error) {
+		return h.service.ReactToComment(comment_id, user_id, requestBody.Reaction)
+	})
+}
+
+func (h *Handler) RemoveCommentReaction(c *gin.Context) {
+	h.setReaction(c, h.service.RemoveCommentReaction)
+}
+
+// setReaction reacts to the comment in the URL or takes the reaction back,
+// responding with the reactions on the comment
+func (h *Handler) setReaction(c *gin.Context, apply func(string, string) (models.CommentReactionsDTO, error)) {
+	comment_Id := c.Param("id")
+
+	reactions, err := apply(comment_Id, middleware.UserID(c))
+
+	if err != nil {
+		switch err.Error() {
+		case "error retrieving comment":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		case "invalid reaction":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again", "reactions": models.CommentReactions})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating reaction"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment_id": comment_Id, "reactions": reactions.Reactions, "my_reaction": reactions.MyReaction})
+}
+
+// attachLikes fills in the like count of posts, whether the viewer likes
+// them, and the reactions on their comment previews. Posts are still served
+// if these cannot be read.
+func attachLikes(posts service.IService, viewer_id string, responses []models.PostResponseDTO) {
+	ids := make([]string, 0, len(responses))
+	var comment_ids []string
+	for _, post := range responses {
+		ids = append(ids, post.Id)
+		for _, comment := range post.Comments {
+			comment_ids = append(comment_ids, comment.Id)
+		}
+	}
+
+	summaries, err := posts.GetLikeSummaries(ids, viewer_id)
+	if err != nil {
+		return
+	}
+
+	reactions, err := posts.GetCommentReactions(comment_ids, viewer_id)
+	if err != nil {
+		return
+	}
+
+	for i := range responses {
+		summary := summaries[responses[i].Id]
+		responses[i].LikeCount = summary.LikeCount
+		responses[i].LikedByMe = summary.LikedByMe
+
+		for j := range responses[i].Comments {
+			comment := &responses[i].Comments[j]
+			comment.Reactions = reactions[comment.Id].Reactions
+			comment.MyReaction = reactions[comment.Id].MyReaction
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateConversation(c *gin.Context) {
+	var requestBody models.ConversationRequestDTO
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.service.CreateConversation(middleware.UserID(c), requestBody.MemberIds)
+
+	if err != nil {
+		respondMessageError(c, err, "Error creating conversation")
+		return
+	}
+
+	conversations := []models.ConversationResponseDTO{conversation}
+	attachConversationMembers(h.users, conversations)
+
+	c.JSON(http.StatusCreated, conversations[0])
+}
+
+func (h *Handler) GetConversations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := h.service.ListConversations(middleware.UserID(c), models.ConversationQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		respondMessageError(c, err, "Failed to get conversations")
+		return
+	}
+
+	attachConversationMembers(h.users, page.Conversations)
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) SendMessage(c *gin.Context) {
+	var requestBody models.MessageRequestDTO
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.service.SendMessage(c.Param("id"), middleware.UserID(c), requestBody)
+
+	if err != nil {
+		respondMessageError(c, err, "Error sending message")
+		return
+	}
+
+	messages := []models.MessageResponseDTO{message}
+	attachMessageSenders(h.users, messages)
+
+	c.JSON(http.StatusCreated, messages[0])
+}
+
+func (h *Handler) GetMessages(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := h.service.ListMessages(c.Param("id"), middleware.UserID(c), models.MessageQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		respondMessageError(c, err, "Failed to get messages")
+		return
+	}
+
+	attachMessageSenders(h.users, page.Messages)
+	attachUsers(h.users, page.ReadReceipts, func(receipt *models.ReadReceiptResponseDTO) (string, **models.AuthorSummaryDTO) {
+		return receipt.UserId, &receipt.User
+	})
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) MarkConversationRead(c *gin.Context) {
+	var requestBody struct {
+		MessageId string `json:"message_id"`
+	}
+
+	// The message is optional: without it the whole conversation is read
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	conversation_Id := c.Param("id")
+
+	if err := h.service.MarkConversationRead(conversation_Id, middleware.UserID(c), requestBody.MessageId); err != nil {
+		respondMessageError(c, err, "Error marking conversation read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversation_id": conversation_Id, "read": true})
+}
+
+func respondMessageError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "error retrieving conversation":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case "error retrieving message":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case "error retrieving post":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	case "blocked":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot message this user"})
+	case "invalid members", "invalid message", "invalid cursor":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notifications service.INotificationService
+	users         service.IUserService
+}
+
+func NewNotificationHandler(notifications service.INotificationService, users service.IUserService) *NotificationHandler {
+	return &NotificationHandler{
+		notifications: notifications,
+		users:         users,
+	}
+}
+
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.NotificationQueryDTO{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	page, err := h.notifications.ListNotifications(middleware.UserID(c), query)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+		return
+	}
+
+	attachUsers(h.users, page.Notifications, func(notification *models.NotificationDTO) (string, **models.AuthorSummaryDTO) {
+		return notification.ActorId, &notification.Actor
+	})
+
+	c.JSON(http.StatusOK, page)
+}
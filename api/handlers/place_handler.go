@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PlaceHandler struct {
+	places service.IPlaceService
+	posts  service.IService
+	users  service.IUserService
+}
+
+func NewPlaceHandler(places service.IPlaceService, posts service.IService, users service.IUserService) *PlaceHandler {
+	return &PlaceHandler{
+		places: places,
+		posts:  posts,
+		users:  users,
+	}
+}
+
+// Nearby serves the post listings that ask for posts near a point with the
+// near query parameter, and hands the others to next
+func (h *PlaceHandler) Nearby(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("near") == "" {
+			next(c)
+			return
+		}
+		h.GetPostsNear(c)
+	}
+}
+
+func (h *PlaceHandler) GetPostsNear(c *gin.Context) {
+	latitude, longitude, found := strings.Cut(c.Query("near"), ",")
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	radius, radiusErr := strconv.ParseFloat(c.DefaultQuery("radius_km", "0"), 64)
+	limit, limitErr := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if !found || latErr != nil || lngErr != nil || radiusErr != nil || limitErr != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.NearbyQueryDTO{
+		Latitude:  lat,
+		Longitude: lng,
+		RadiusKm:  radius,
+		Cursor:    c.Query("cursor"),
+		Limit:     limit,
+	}
+
+	page, err := h.places.GetPostsNear(middleware.UserID(c), query)
+
+	if err != nil {
+		respondPlaceError(c, err)
+		return
+	}
+
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.posts, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *PlaceHandler) GetPlacePosts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.PlaceQueryDTO{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	page, err := h.places.GetPlacePosts(c.Param("id"), middleware.UserID(c), query)
+
+	if err != nil {
+		respondPlaceError(c, err)
+		return
+	}
+
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.posts, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
+
+func respondPlaceError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "error retrieving place":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+	case "invalid location", "invalid radius", "invalid cursor":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	search service.ISearchService
+	posts  service.IService
+	users  service.IUserService
+}
+
+func NewSearchHandler(search service.ISearchService, posts service.IService, users service.IUserService) *SearchHandler {
+	return &SearchHandler{
+		search: search,
+		posts:  posts,
+		users:  users,
+	}
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.SearchQueryDTO{
+		Query:  c.Query("q"),
+		Type:   c.Query("type"),
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	viewer_id := middleware.UserID(c)
+
+	page, err := h.search.Search(viewer_id, query)
+
+	if err != nil {
+		switch err.Error() {
+		case "invalid query", "invalid search type", "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+		}
+		return
+	}
+
+	switch page.Type {
+	case models.SearchTypePosts:
+		attachPostAuthors(h.users, page.Posts)
+		attachLikes(h.posts, viewer_id, page.Posts)
+	case models.SearchTypeUsers:
+		page.Users = userSummaries(h.users, page.UserIds)
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	users    service.IUserService
+	sessions service.ISessionService
+}
+
+func NewSessionHandler(users service.IUserService, sessions service.ISessionService) *SessionHandler {
+	return &SessionHandler{
+		users:    users,
+		sessions: sessions,
+	}
+}
+
+func (h *SessionHandler) Login(c *gin.Context) {
+	var requestBody models.UserRequestDTO
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.Authenticate(requestBody.Username, requestBody.Password)
+
+	if err != nil {
+		if err.Error() == "invalid credentials" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging in"})
+		return
+	}
+
+	tokens, err := h.sessions.CreateSession(user.Id, c.Request.UserAgent())
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":          toUserResponse(user),
+		"session_id":    tokens.SessionId,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_at":    tokens.ExpiresAt,
+	})
+}
+
+func (h *SessionHandler) Refresh(c *gin.Context) {
+	var requestBody struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(requestBody.RefreshToken)
+
+	if err != nil {
+		switch err.Error() {
+		case "token reused":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used; the session has been revoked"})
+		case "invalid token":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error refreshing session"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessions.ListSessions(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+
+	responses := []models.SessionResponseDTO{}
+
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponseDTO{
+			Id:         session.Id,
+			Device:     session.Device,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.Id == middleware.SessionID(c),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": responses})
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	session_Id := c.Param("id")
+
+	err := h.sessions.RevokeSession(middleware.UserID(c), session_Id)
+
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case "error retrieving session":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Session not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revoking session"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session revoked": session_Id})
+}
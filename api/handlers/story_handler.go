@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateStory(c *gin.Context) {
+	story_img, ok := h.readPostImage(c)
+	if !ok {
+		return
+	}
+
+	story_id, err := h.service.CreateStory(story_img, middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating story"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"story_id": story_id})
+}
+
+func (h *Handler) GetStory(c *gin.Context) {
+	story_img, _, err := h.service.ViewStory(c.Param("id"), middleware.UserID(c))
+
+	if err != nil {
+		respondStoryError(c, err, "Error getting story")
+		return
+	}
+
+	c.Header("Content-Type", "image/png")
+
+	if err := png.Encode(c.Writer, story_img); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode image"})
+	}
+}
+
+func (h *Handler) GetStoryViewers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	page, err := h.service.ListStoryViewers(c.Param("id"), middleware.UserID(c), models.StoryViewQueryDTO{Cursor: c.Query("cursor"), Limit: limit})
+
+	if err != nil {
+		respondStoryError(c, err, "Failed to get viewers")
+		return
+	}
+
+	attachUsers(h.users, page.Views, func(view *models.StoryViewDTO) (string, **models.AuthorSummaryDTO) {
+		return view.UserId, &view.User
+	})
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) DeleteStory(c *gin.Context) {
+	story_Id := c.Param("id")
+
+	if err := h.service.DeleteStory(story_Id, middleware.UserID(c)); err != nil {
+		respondStoryError(c, err, "Error deleting story")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"story_id": story_Id, "deleted": true})
+}
+
+func (h *Handler) GetStoriesTray(c *gin.Context) {
+	tray, err := h.service.GetStoriesTray(middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get stories"})
+		return
+	}
+
+	attachUsers(h.users, tray.Tray, func(entry *models.StoryTrayEntryDTO) (string, **models.AuthorSummaryDTO) {
+		return entry.AuthorId, &entry.Author
+	})
+
+	c.JSON(http.StatusOK, tray)
+}
+
+func respondStoryError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "error retrieving story":
+		c.JSON(http.StatusNotFound, gin.H{"error": "Story not found"})
+	case "unauthorized":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case "invalid cursor":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
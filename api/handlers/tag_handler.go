@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tags  service.ITagService
+	posts service.IService
+	users service.IUserService
+}
+
+func NewTagHandler(tags service.ITagService, posts service.IService, users service.IUserService) *TagHandler {
+	return &TagHandler{
+		tags:  tags,
+		posts: posts,
+		users: users,
+	}
+}
+
+func (h *TagHandler) GetTaggedPosts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	query := models.TagQueryDTO{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	page, err := h.tags.GetTaggedPosts(c.Param("tag"), middleware.UserID(c), query)
+
+	if err != nil {
+		switch err.Error() {
+		case "invalid tag", "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
+		}
+		return
+	}
+
+	attachPostAuthors(h.users, page.Posts)
+	attachLikes(h.posts, middleware.UserID(c), page.Posts)
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/imaging"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/service"
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	service service.IUserService
+	config  config.Config
+}
+
+func NewUserHandler(serv service.IUserService, cfg config.Config) *UserHandler {
+	return &UserHandler{
+		service: serv,
+		config:  cfg,
+	}
+}
+
+func (h *UserHandler) Register(c *gin.Context) {
+	var requestBody models.UserRequestDTO
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.Register(requestBody)
+
+	if err != nil {
+		switch err.Error() {
+		case "invalid username":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be 3-30 letters, digits, dots or underscores"})
+		case "invalid password":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be 8-72 characters long"})
+		case "username taken":
+			c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"user": toUserResponse(user)})
+}
+
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Param("id"), middleware.UserID(c))
+
+	if err != nil {
+		if err.Error() == "error retrieving user" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var requestBody models.ProfileRequestDTO
+
+	// Bind the JSON request
+	if err := c.BindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := h.service.UpdateProfile(middleware.UserID(c), requestBody)
+
+	if err != nil {
+		switch err.Error() {
+		case "invalid display name":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Display name must be at most 50 characters long"})
+		case "invalid bio":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bio must be at most 150 characters long"})
+		case "invalid website":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Website must be an http or https link"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating profile"})
+		}
+		return
+	}
+
+	h.respondOwnProfile(c)
+}
+
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	avatar, ok := readImageUpload(c, h.config.MaxUploadSize)
+	if !ok {
+		return
+	}
+
+	_, err := h.service.SetAvatar(middleware.UserID(c), imaging.Renditions(avatar, h.config.AvatarSizes))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving avatar"})
+		return
+	}
+
+	h.respondOwnProfile(c)
+}
+
+func (h *UserHandler) GetAvatar(c *gin.Context) {
+	// Optional width in pixels; the largest rendition is served when omitted
+	size, err := strconv.Atoi(c.DefaultQuery("size", "0"))
+
+	if err != nil || size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check input parameters again"})
+		return
+	}
+
+	if size == 0 {
+		size = math.MaxInt
+	}
+
+	avatar, err := h.service.GetAvatar(c.Param("id"), size)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	c.Header("Content-Type", "image/png")
+	encodeErr := png.Encode(c.Writer, avatar)
+
+	if encodeErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to encode image"})
+		return
+	}
+}
+
+// respondOwnProfile responds with the profile of the caller after a change
+func (h *UserHandler) respondOwnProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(middleware.UserID(c), middleware.UserID(c))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func toUserResponse(user models.User) models.UserResponseDTO {
+	return models.UserResponseDTO{
+		Id:        user.Id,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/anandh86/instagram/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys under which the authenticated caller's identity is stored
+const (
+	userIdKey    = "user_id"
+	sessionIdKey = "session_id"
+)
+
+// SessionChecker tells whether the login session behind an access token is
+// still active, so that logging a device out takes effect immediately
+type SessionChecker interface {
+	IsSessionActive(session_id string) bool
+}
+
+// RequireAuth rejects requests without a valid bearer access token and puts
+// the caller's identity into the context
+func RequireAuth(keys *auth.KeySet, sessions SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := bearerToken(c)
+		if !found {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		claims, err := keys.Verify(token)
+		if err != nil || !sessions.IsSessionActive(claims.SessionId) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		c.Set(userIdKey, claims.Subject)
+		c.Set(sessionIdKey, claims.SessionId)
+		c.Next()
+	}
+}
+
+// OptionalAuth identifies the caller when a bearer token is present, and lets
+// anonymous requests through. An invalid token is still rejected, so clients
+// learn that they have to log in again.
+func OptionalAuth(keys *auth.KeySet, sessions SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, found := bearerToken(c); !found {
+			c.Next()
+			return
+		}
+
+		RequireAuth(keys, sessions)(c)
+	}
+}
+
+// UserID returns the ID of the authenticated caller, or "" for anonymous requests
+func UserID(c *gin.Context) string {
+	return c.GetString(userIdKey)
+}
+
+// SessionID returns the login session of the authenticated caller
+func SessionID(c *gin.Context) string {
+	return c.GetString(sessionIdKey)
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
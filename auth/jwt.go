@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signing algorithms supported for access tokens
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// Claims are the registered JWT claims carried by an access token
+type Claims struct {
+	Subject   string `json:"sub"`
+	SessionId string `json:"sid,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// key is a signing or verification key identified by its "kid"
+type key struct {
+	alg        string
+	secret     []byte
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+// KeySet holds the keys used to sign and verify access tokens. Tokens name
+// the key they were signed with in their "kid" header, so keys can be
+// rotated by adding a new signing key while keeping the old one around for
+// verification until the tokens it signed have expired.
+type KeySet struct {
+	mu         sync.RWMutex
+	keys       map[string]key
+	signingKid string
+	now        func() time.Time
+}
+
+// NewKeySet creates an empty key set
+func NewKeySet() *KeySet {
+	return &KeySet{
+		keys: make(map[string]key),
+		now:  time.Now,
+	}
+}
+
+// AddHMACKey adds an HS256 key, usable for signing and verification
+func (ks *KeySet) AddHMACKey(kid string, secret []byte) error {
+	if len(secret) < 32 {
+		return errors.New("hmac secret must be at least 32 bytes")
+	}
+	return ks.add(kid, key{alg: HS256, secret: secret})
+}
+
+// AddRSAKey adds an RS256 private key, usable for signing and verification
+func (ks *KeySet) AddRSAKey(kid string, privateKey *rsa.PrivateKey) error {
+	return ks.add(kid, key{alg: RS256, privateKey: privateKey, publicKey: &privateKey.PublicKey})
+}
+
+// AddRSAPublicKey adds an RS256 public key, usable for verification only
+func (ks *KeySet) AddRSAPublicKey(kid string, publicKey *rsa.PublicKey) error {
+	return ks.add(kid, key{alg: RS256, publicKey: publicKey})
+}
+
+// RemoveKey retires a key; tokens signed with it no longer verify
+func (ks *KeySet) RemoveKey(kid string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	delete(ks.keys, kid)
+	if ks.signingKid == kid {
+		ks.signingKid = ""
+	}
+}
+
+// SetSigningKey selects the key new tokens are signed with
+func (ks *KeySet) SetSigningKey(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	k, exists := ks.keys[kid]
+	if !exists {
+		return errors.New("unknown key")
+	}
+	if k.alg == RS256 && k.privateKey == nil {
+		return errors.New("key cannot sign")
+	}
+
+	ks.signingKid = kid
+	return nil
+}
+
+// Issue signs an access token for subject, on behalf of a login session, that
+// is valid for ttl
+func (ks *KeySet) Issue(subject, sessionId string, ttl time.Duration) (token string, claims Claims, err error) {
+	now := ks.now()
+	claims = Claims{
+		Subject:   subject,
+		SessionId: sessionId,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	token, err = ks.Sign(claims)
+	return token, claims, err
+}
+
+// Sign encodes and signs claims with the current signing key
+func (ks *KeySet) Sign(claims Claims) (string, error) {
+	ks.mu.RLock()
+	kid := ks.signingKid
+	k, exists := ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !exists {
+		return "", errors.New("no signing key")
+	}
+
+	headerJSON, _ := json.Marshal(header{Alg: k.alg, Typ: "JWT", Kid: kid})
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+
+	signature, err := k.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify checks the signature and expiry of a token and returns its claims
+func (ks *KeySet) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	ks.mu.RLock()
+	k, exists := ks.keys[h.Kid]
+	ks.mu.RUnlock()
+
+	if !exists {
+		return Claims{}, errors.New("unknown key")
+	}
+
+	// The algorithm is fixed by the key, never by the token, so a token
+	// can't downgrade an RSA key to HMAC or to "none"
+	if h.Alg != k.alg {
+		return Claims{}, errors.New("invalid signature")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return Claims{}, errors.New("invalid signature")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	if ks.now().Unix() >= claims.ExpiresAt {
+		return Claims{}, errors.New("token expired")
+	}
+
+	return claims, nil
+}
+
+// GenerateSecret returns a random secret suitable for AddHMACKey
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (ks *KeySet) add(kid string, k key) error {
+	if kid == "" {
+		return errors.New("key id is required")
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys[kid] = k
+	return nil
+}
+
+func (k key) sign(input []byte) ([]byte, error) {
+	switch k.alg {
+	case HS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case RS256:
+		if k.privateKey == nil {
+			return nil, errors.New("key cannot sign")
+		}
+		digest := sha256.Sum256(input)
+		return rsa.SignPKCS1v15(rand.Reader, k.privateKey, crypto.SHA256, digest[:])
+	}
+	return nil, errors.New("unsupported algorithm")
+}
+
+func (k key) verify(input, signature []byte) bool {
+	switch k.alg {
+	case HS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(input)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case RS256:
+		digest := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(k.publicKey, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
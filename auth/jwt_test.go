@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIssueAndVerify_HS256(t *testing.T) {
+	ks := NewKeySet()
+	assert.NoError(t, ks.AddHMACKey("k1", testSecret))
+	assert.NoError(t, ks.SetSigningKey("k1"))
+
+	token, _, err := ks.Issue("user123", "session1", time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := ks.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", claims.Subject)
+	assert.Equal(t, "session1", claims.SessionId)
+}
+
+func TestIssueAndVerify_RS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	signer := NewKeySet()
+	assert.NoError(t, signer.AddRSAKey("rsa1", privateKey))
+	assert.NoError(t, signer.SetSigningKey("rsa1"))
+
+	token, _, err := signer.Issue("user123", "session1", time.Minute)
+	assert.NoError(t, err)
+
+	// A verifier only needs the public key
+	verifier := NewKeySet()
+	assert.NoError(t, verifier.AddRSAPublicKey("rsa1", &privateKey.PublicKey))
+	assert.EqualError(t, verifier.SetSigningKey("rsa1"), "key cannot sign")
+
+	claims, err := verifier.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", claims.Subject)
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	ks := NewKeySet()
+	assert.NoError(t, ks.AddHMACKey("old", testSecret))
+	assert.NoError(t, ks.SetSigningKey("old"))
+
+	oldToken, _, _ := ks.Issue("user123", "session1", time.Minute)
+
+	assert.NoError(t, ks.AddHMACKey("new", []byte("fedcba9876543210fedcba9876543210")))
+	assert.NoError(t, ks.SetSigningKey("new"))
+
+	newToken, _, _ := ks.Issue("user123", "session1", time.Minute)
+
+	_, err := ks.Verify(oldToken)
+	assert.NoError(t, err)
+	_, err = ks.Verify(newToken)
+	assert.NoError(t, err)
+
+	ks.RemoveKey("old")
+
+	_, err = ks.Verify(oldToken)
+	assert.EqualError(t, err, "unknown key")
+	_, err = ks.Verify(newToken)
+	assert.NoError(t, err)
+}
+
+func TestVerify_Expired(t *testing.T) {
+	ks := NewKeySet()
+	_ = ks.AddHMACKey("k1", testSecret)
+	_ = ks.SetSigningKey("k1")
+
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	ks.now = func() time.Time { return now }
+
+	token, _, _ := ks.Issue("user123", "session1", time.Minute)
+
+	now = now.Add(2 * time.Minute)
+
+	_, err := ks.Verify(token)
+	assert.EqualError(t, err, "token expired")
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	ks := NewKeySet()
+	_ = ks.AddHMACKey("k1", testSecret)
+	_ = ks.SetSigningKey("k1")
+
+	token, _, _ := ks.Issue("user123", "session1", time.Minute)
+	parts := strings.Split(token, ".")
+
+	forgedClaims := encodeSegment([]byte(`{"sub":"admin","iat":0,"exp":9999999999}`))
+	_, err := ks.Verify(parts[0] + "." + forgedClaims + "." + parts[2])
+	assert.EqualError(t, err, "invalid signature")
+
+	// The algorithm can't be switched away from the key's own
+	noneHeader := encodeSegment([]byte(`{"alg":"none","typ":"JWT","kid":"k1"}`))
+	_, err = ks.Verify(noneHeader + "." + parts[1] + ".")
+	assert.EqualError(t, err, "invalid signature")
+
+	_, err = ks.Verify("not-a-token")
+	assert.EqualError(t, err, "malformed token")
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/anandh86/instagram/config"
+)
+
+// ephemeralKid names the key generated when none is configured
+const ephemeralKid = "ephemeral"
+
+// LoadKeySet builds the key set described by the configuration. Without any
+// configured key, a random HMAC key is generated; tokens signed with it stop
+// verifying when the process restarts.
+func LoadKeySet(cfg config.Config) (*KeySet, error) {
+	ks := NewKeySet()
+
+	for kid, secret := range cfg.JWTHMACKeys {
+		if err := ks.AddHMACKey(kid, []byte(secret)); err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+	}
+
+	for kid, path := range cfg.JWTRSAKeyFiles {
+		privateKey, err := readRSAPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		if err := ks.AddRSAKey(kid, privateKey); err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+	}
+
+	if len(cfg.JWTHMACKeys) == 0 && len(cfg.JWTRSAKeyFiles) == 0 {
+		log.Printf("no JWT signing key configured; using an ephemeral key")
+
+		secret, err := GenerateSecret()
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.AddHMACKey(ephemeralKid, secret); err != nil {
+			return nil, err
+		}
+		return ks, ks.SetSigningKey(ephemeralKid)
+	}
+
+	if err := ks.SetSigningKey(cfg.JWTSigningKeyId); err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", cfg.JWTSigningKeyId, err)
+	}
+
+	return ks, nil
+}
+
+// readRSAPrivateKey reads a PEM encoded PKCS#1 or PKCS#8 RSA private key
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	if privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return privateKey, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return privateKey, nil
+}
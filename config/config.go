@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config holds the tunable settings of the application
+type Config struct {
+	// Number of latest comments embedded in each post of the post listing
+	PreviewComments int
+
+	// Largest image upload accepted, in bytes
+	MaxUploadSize int64
+
+	// Width and height post images are cropped and scaled to, in pixels
+	PostImageSize int
+
+	// Widths of the square renditions kept for each avatar, in pixels
+	AvatarSizes []int
+
+	// How long a deleted post or comment stays in the trash. Authors can
+	// restore items within this window; afterwards they are purged for good.
+	TrashRetention time.Duration
+
+	// How often the background purger sweeps the trash
+	TrashPurgeInterval time.Duration
+
+	// How deep nested comment replies are returned. Replies below this
+	// depth are only reflected in their parent's reply count.
+	CommentThreadMaxDepth int
+
+	// How often the scheduler looks for scheduled posts that are due
+	PostSchedulerInterval time.Duration
+
+	// How long a story stays up before it expires
+	StoryLifetime time.Duration
+
+	// How often the background sweeper deletes expired stories
+	StorySweepInterval time.Duration
+
+	// How long after posting a comment its author can still edit it.
+	// Zero allows edits at any time.
+	CommentEditWindow time.Duration
+
+	// Accounts with more followers than this are not copied into the home
+	// feeds of their followers when they post; their posts are merged into
+	// each feed as it is read instead
+	FeedFanoutThreshold int
+
+	// Number of posts kept in the precomputed home feed of each user
+	FeedTimelineLength int
+
+	// How long after being created public posts can show up on the explore
+	// page
+	ExploreWindow time.Duration
+
+	// Points a post earns on the explore page for each like, comment and
+	// save it gets from other users. Points are worth half as much every
+	// ExploreHalfLife after they were earned.
+	ExploreLikeWeight    float64
+	ExploreCommentWeight float64
+	ExploreSaveWeight    float64
+	ExploreHalfLife      time.Duration
+
+	// Radius of the nearby posts search when the client does not ask for
+	// one, and the largest radius a client can ask for, in kilometers
+	NearbyRadiusKm    float64
+	MaxNearbyRadiusKm float64
+
+	// Posts tagged with the same place name within this distance, in
+	// kilometers, are filed under the same place
+	PlaceMergeRadiusKm float64
+
+	// Most members a group conversation can have, its creator included
+	MaxGroupSize int
+
+	// Users allowed to audit the edit history of comments
+	Moderators []string
+
+	// Work factor used when hashing passwords
+	BcryptCost int
+
+	// File where user accounts are persisted. Accounts are kept in memory
+	// only when empty.
+	UserStorePath string
+
+	// Keys for access tokens by key id ("kid"): HS256 secrets and files
+	// holding PEM encoded RS256 private keys. A retired key can be kept here
+	// so the tokens it signed verify until they expire.
+	JWTHMACKeys    map[string]string
+	JWTRSAKeyFiles map[string]string
+
+	// Key id of the key new access tokens are signed with
+	JWTSigningKeyId string
+
+	// How long an access token is valid
+	AccessTokenTTL time.Duration
+
+	// How long a session stays logged in without being refreshed
+	RefreshTokenTTL time.Duration
+
+	// File where login sessions are persisted. Sessions are kept in memory
+	// only when empty.
+	SessionStorePath string
+
+	// File where conversations and messages are persisted. They are kept in
+	// memory only when empty.
+	MessageStorePath string
+}
+
+// Default returns the configuration used when nothing else is specified
+func Default() Config {
+	return Config{
+		PreviewComments:       2,
+		MaxUploadSize:         100 * 1024 * 1024, // 100MB
+		PostImageSize:         600,
+		AvatarSizes:           []int{320, 150, 64},
+		TrashRetention:        30 * 24 * time.Hour,
+		TrashPurgeInterval:    time.Hour,
+		PostSchedulerInterval: 15 * time.Second,
+		StoryLifetime:         24 * time.Hour,
+		StorySweepInterval:    time.Minute,
+		CommentThreadMaxDepth: 5,
+		CommentEditWindow:     15 * time.Minute,
+		FeedFanoutThreshold:   10000,
+		FeedTimelineLength:    800,
+		ExploreWindow:         7 * 24 * time.Hour,
+		ExploreLikeWeight:     1,
+		ExploreCommentWeight:  3,
+		ExploreSaveWeight:     5,
+		ExploreHalfLife:       12 * time.Hour,
+		NearbyRadiusKm:        5,
+		MaxNearbyRadiusKm:     100,
+		PlaceMergeRadiusKm:    0.2,
+		MaxGroupSize:          32,
+		BcryptCost:            bcrypt.DefaultCost,
+		AccessTokenTTL:        15 * time.Minute,
+		RefreshTokenTTL:       30 * 24 * time.Hour,
+	}
+}
+
+// FromEnv returns the default configuration, overridden by the environment
+func FromEnv() Config {
+	cfg := Default()
+
+	if path := os.Getenv("USER_STORE_PATH"); path != "" {
+		cfg.UserStorePath = path
+	}
+
+	if path := os.Getenv("SESSION_STORE_PATH"); path != "" {
+		cfg.SessionStorePath = path
+	}
+
+	if path := os.Getenv("MESSAGE_STORE_PATH"); path != "" {
+		cfg.MessageStorePath = path
+	}
+
+	// Key lists are written as "kid:value,kid:value"
+	cfg.JWTHMACKeys = parseKeyList(os.Getenv("JWT_HMAC_KEYS"))
+	cfg.JWTRSAKeyFiles = parseKeyList(os.Getenv("JWT_RSA_KEY_FILES"))
+	cfg.JWTSigningKeyId = os.Getenv("JWT_SIGNING_KEY_ID")
+
+	// User ids are written as "id,id"
+	cfg.Moderators = parseList(os.Getenv("MODERATORS"))
+
+	return cfg
+}
+
+func parseList(list string) []string {
+	var values []string
+
+	for _, entry := range strings.Split(list, ",") {
+		if value := strings.TrimSpace(entry); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func parseKeyList(list string) map[string]string {
+	keys := make(map[string]string)
+
+	for _, entry := range strings.Split(list, ",") {
+		kid, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if found && kid != "" {
+			keys[kid] = value
+		}
+	}
+
+	return keys
+}
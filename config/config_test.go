@@ -0,0 +1,16 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv_Moderators(t *testing.T) {
+	t.Setenv("MODERATORS", " mod1, ,mod2 ")
+
+	assert.Equal(t, []string{"mod1", "mod2"}, FromEnv().Moderators)
+
+	t.Setenv("MODERATORS", "")
+	assert.Empty(t, FromEnv().Moderators)
+}
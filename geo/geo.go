@@ -0,0 +1,38 @@
+// Package geo handles positions on Earth: distances between them, and
+// geohashes to find the positions near a point quickly.
+package geo
+
+import "math"
+
+// Mean radius of the Earth, in kilometers
+const earthRadiusKm = 6371.0088
+
+// Point is a position in degrees of latitude and longitude
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Valid tells whether a point has a latitude within [-90, 90] and a
+// longitude within [-180, 180]
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance is the great-circle distance between two points, in kilometers
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,25 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	paris := Point{Lat: 48.8566, Lng: 2.3522}
+	london := Point{Lat: 51.5074, Lng: -0.1278}
+
+	assert.InDelta(t, 343.5, Distance(paris, london), 1)
+	assert.InDelta(t, Distance(paris, london), Distance(london, paris), 1e-9)
+	assert.Zero(t, Distance(paris, paris))
+
+	// Across the antimeridian
+	assert.InDelta(t, 111.2, Distance(Point{Lat: 0, Lng: 179.5}, Point{Lat: 0, Lng: -179.5}), 0.1)
+}
+
+func TestPoint_Valid(t *testing.T) {
+	assert.True(t, Point{Lat: 90, Lng: -180}.Valid())
+	assert.False(t, Point{Lat: 90.5, Lng: 0}.Valid())
+	assert.False(t, Point{Lat: 0, Lng: 181}.Valid())
+}
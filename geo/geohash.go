@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// MaxPrecision is the length of the geohashes Encode is asked for at most.
+// Cells of 9 characters are about 5 meters wide.
+const MaxPrecision = 9
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Largest number of cells Cover returns
+const maxCoverCells = 16
+
+// Encode returns the geohash of a point with the given number of characters.
+// A geohash names a cell of a grid over the Earth; each character splits
+// the cell of the previous ones into 32, so points whose geohashes share a
+// prefix lie in the cell that prefix names.
+func Encode(p Point, precision int) string {
+	latBits, lngBits := bits(precision)
+	return cellHash(cellIndex(p.Lat, -90, 90, latBits), cellIndex(p.Lng, -180, 180, lngBits), precision)
+}
+
+// Cover returns the geohashes of cells that together contain every point
+// within radiusKm of center. It picks the smallest cells for which a few of
+// them are enough, so the cells may hold points well outside the radius;
+// check the distance of what they contain.
+func Cover(center Point, radiusKm float64) []string {
+	// Bounding box of the circle, in degrees
+	dLat := degrees(radiusKm / earthRadiusKm)
+	minLat := max(center.Lat-dLat, -90)
+	maxLat := min(center.Lat+dLat, 90)
+
+	// Meridians get closer towards the poles, so the box is widest at the
+	// latitude furthest from the equator
+	dLng := 180.0
+	if cos := math.Cos(radians(max(math.Abs(minLat), math.Abs(maxLat)))); cos > 0 {
+		dLng = min(dLat/cos, 180)
+	}
+
+	for precision := MaxPrecision; precision > 1; precision-- {
+		if cells, ok := cover(center.Lng, minLat, maxLat, dLng, precision); ok {
+			return cells
+		}
+	}
+
+	// One character cells are so large that there are few enough of them
+	// to cover anything
+	cells, _ := cover(center.Lng, minLat, maxLat, dLng, 1)
+	return cells
+}
+
+// cover lists the cells of the given precision overlapping a box, unless
+// there are more than maxCoverCells of them
+func cover(lng, minLat, maxLat, dLng float64, precision int) ([]string, bool) {
+	latBits, lngBits := bits(precision)
+	lngCells := 1 << lngBits
+
+	lat0 := cellIndex(minLat, -90, 90, latBits)
+	lat1 := cellIndex(maxLat, -90, 90, latBits)
+
+	// Longitudes wrap around at the antimeridian
+	lng0, width := 0, lngCells
+	if 2*dLng+360/float64(lngCells) < 360 {
+		lng0 = cellIndex(normalizeLng(lng-dLng), -180, 180, lngBits)
+		lng1 := cellIndex(normalizeLng(lng+dLng), -180, 180, lngBits)
+		width = (lng1-lng0+lngCells)%lngCells + 1
+	}
+
+	if precision > 1 && (lat1-lat0+1)*width > maxCoverCells {
+		return nil, false
+	}
+
+	cells := make([]string, 0, (lat1-lat0+1)*width)
+	for lat := lat0; lat <= lat1; lat++ {
+		for i := 0; i < width; i++ {
+			cells = append(cells, cellHash(lat, (lng0+i)%lngCells, precision))
+		}
+	}
+	return cells, true
+}
+
+// bits splits the bits of a geohash between latitude and longitude.
+// Longitude takes the first bit, and every other one after it.
+func bits(precision int) (latBits, lngBits int) {
+	total := 5 * precision
+	return total / 2, (total + 1) / 2
+}
+
+// cellIndex is the index of the cell holding a coordinate among the 2^bits
+// equal cells between lo and hi
+func cellIndex(value, lo, hi float64, bits int) int {
+	cells := 1 << bits
+	index := int(math.Floor((value - lo) / (hi - lo) * float64(cells)))
+	return min(max(index, 0), cells-1)
+}
+
+// cellHash interleaves the bits of the cell indexes into a geohash
+func cellHash(latIndex, lngIndex, precision int) string {
+	latBits, lngBits := bits(precision)
+
+	var hash strings.Builder
+	char := 0
+	for i := 0; i < 5*precision; i++ {
+		char <<= 1
+		if i%2 == 0 {
+			lngBits--
+			char |= (lngIndex >> lngBits) & 1
+		} else {
+			latBits--
+			char |= (latIndex >> latBits) & 1
+		}
+
+		if i%5 == 4 {
+			hash.WriteByte(base32[char])
+			char = 0
+		}
+	}
+	return hash.String()
+}
+
+func normalizeLng(lng float64) float64 {
+	return math.Mod(math.Mod(lng+180, 360)+360, 360) - 180
+}
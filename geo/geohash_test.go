@@ -0,0 +1,75 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "u4pruydqqvj", Encode(Point{Lat: 57.64911, Lng: 10.40744}, 11))
+	assert.Equal(t, "u4pru", Encode(Point{Lat: 57.64911, Lng: 10.40744}, 5))
+
+	// The edges of the map belong to the outermost cells
+	assert.Equal(t, "zzzzz", Encode(Point{Lat: 90, Lng: 180}, 5))
+	assert.Equal(t, "00000", Encode(Point{Lat: -90, Lng: -180}, 5))
+}
+
+func TestCover(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	centers := []Point{
+		{Lat: 48.8566, Lng: 2.3522},
+		{Lat: -33.8688, Lng: 151.2093},
+		{Lat: 0, Lng: 179.99},
+		{Lat: 10, Lng: -179.99},
+		{Lat: 89.9, Lng: 45},
+		{Lat: -89.5, Lng: -120},
+	}
+
+	for _, center := range centers {
+		for _, radius := range []float64{0.05, 1, 25, 400, 5000} {
+			cells := Cover(center, radius)
+			assert.NotEmpty(t, cells)
+			assert.LessOrEqual(t, len(cells), 32)
+
+			// Every point within the radius falls in one of the cells
+			for i := 0; i < 200; i++ {
+				p := offset(center, radius*random.Float64(), 2*math.Pi*random.Float64())
+
+				hash := Encode(p, len(cells[0]))
+				assert.True(t, covered(cells, hash), "%v within %vkm of %v is not covered", p, radius, center)
+			}
+		}
+	}
+}
+
+func TestCover_PicksSmallCellsForSmallRadiuses(t *testing.T) {
+	center := Point{Lat: 48.8566, Lng: 2.3522}
+
+	assert.Greater(t, len(Cover(center, 0.1)[0]), len(Cover(center, 10)[0]))
+	assert.LessOrEqual(t, len(Cover(center, 0.1)), maxCoverCells)
+}
+
+func covered(cells []string, hash string) bool {
+	for _, cell := range cells {
+		if strings.HasPrefix(hash, cell) {
+			return true
+		}
+	}
+	return false
+}
+
+// offset moves a point by a distance in kilometers along a bearing
+func offset(p Point, distanceKm, bearing float64) Point {
+	lat1, lng1 := radians(p.Lat), radians(p.Lng)
+	d := distanceKm / earthRadiusKm
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(bearing))
+	lng2 := lng1 + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+
+	return Point{Lat: degrees(lat2), Lng: normalizeLng(degrees(lng2))}
+}
@@ -0,0 +1,148 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// EXIF tags read by ExifLocation
+const (
+	tagGPSInfo      = 0x8825
+	tagLatitudeRef  = 0x0001
+	tagLatitude     = 0x0002
+	tagLongitudeRef = 0x0003
+	tagLongitude    = 0x0004
+)
+
+// ExifLocation reads the GPS coordinates a camera recorded in the EXIF
+// metadata of a JPEG file, in degrees. ok is false when the file has none.
+func ExifLocation(data []byte) (lat, lng float64, ok bool) {
+	tiff := exifSegment(data)
+	if tiff == nil {
+		return 0, 0, false
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(tiff, []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(tiff, []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return 0, 0, false
+	}
+
+	ifd0 := readIFD(tiff, order, order.Uint32(tiff[4:8]))
+	gps, found := ifd0[tagGPSInfo]
+	if !found {
+		return 0, 0, false
+	}
+
+	fields := readIFD(tiff, order, order.Uint32(gps.value))
+
+	lat, latOk := fields.degrees(tiff, order, tagLatitude, tagLatitudeRef, 'S')
+	lng, lngOk := fields.degrees(tiff, order, tagLongitude, tagLongitudeRef, 'W')
+	if !latOk || !lngOk || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+
+	return lat, lng, true
+}
+
+// exifSegment finds the TIFF structure holding the EXIF metadata among the
+// segments that come before the image data of a JPEG file
+func exifSegment(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil
+	}
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil
+		}
+
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+
+		// Start of the image data; metadata only comes before it
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) && len(segment) >= 14 {
+			return segment[6:]
+		}
+
+		pos += 2 + length
+	}
+
+	return nil
+}
+
+// ifdEntry is a field of an image file directory. value holds the 4 bytes
+// of its value, or of the offset of its value when it does not fit.
+type ifdEntry struct {
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+type ifd map[uint16]ifdEntry
+
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) ifd {
+	fields := ifd{}
+
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return fields
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + 12*i
+		if start+12 > len(tiff) {
+			break
+		}
+
+		entry := tiff[start : start+12]
+		fields[order.Uint16(entry[0:2])] = ifdEntry{
+			kind:  order.Uint16(entry[2:4]),
+			count: order.Uint32(entry[4:8]),
+			value: entry[8:12],
+		}
+	}
+
+	return fields
+}
+
+// degrees reads a coordinate stored as degrees, minutes and seconds, negated
+// when its reference is the given hemisphere
+func (fields ifd) degrees(tiff []byte, order binary.ByteOrder, tag, refTag uint16, negative byte) (float64, bool) {
+	const rational = 5
+
+	entry, found := fields[tag]
+	if !found || entry.kind != rational || entry.count != 3 {
+		return 0, false
+	}
+
+	offset := int(order.Uint32(entry.value))
+	if offset < 0 || offset+24 > len(tiff) {
+		return 0, false
+	}
+
+	value := 0.0
+	for i, scale := range []float64{1, 60, 3600} {
+		numerator := order.Uint32(tiff[offset+8*i:])
+		denominator := order.Uint32(tiff[offset+8*i+4:])
+		if denominator == 0 {
+			return 0, false
+		}
+		value += float64(numerator) / float64(denominator) / scale
+	}
+
+	if ref, found := fields[refTag]; found && ref.value[0] == negative {
+		value = -value
+	}
+
+	return value, true
+}
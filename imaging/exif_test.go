@@ -0,0 +1,111 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExifLocation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		// 48° 51' 23.76" N, 2° 21' 7.92" E
+		data := jpegWithExif(t, exifWithGPS(order, 'N', [3][2]uint32{{48, 1}, {51, 1}, {2376, 100}}, 'E', [3][2]uint32{{2, 1}, {21, 1}, {792, 100}}))
+
+		lat, lng, ok := ExifLocation(data)
+		assert.True(t, ok)
+		assert.InDelta(t, 48.8566, lat, 1e-6)
+		assert.InDelta(t, 2.3522, lng, 1e-6)
+	}
+
+	// Southern and western hemispheres
+	data := jpegWithExif(t, exifWithGPS(binary.BigEndian, 'S', [3][2]uint32{{33, 1}, {52, 1}, {0, 1}}, 'W', [3][2]uint32{{70, 1}, {30, 1}, {0, 1}}))
+
+	lat, lng, ok := ExifLocation(data)
+	assert.True(t, ok)
+	assert.InDelta(t, -33.8666667, lat, 1e-6)
+	assert.InDelta(t, -70.5, lng, 1e-6)
+}
+
+func TestExifLocation_Missing(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	var plain bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&plain, img, nil))
+	_, _, ok := ExifLocation(plain.Bytes())
+	assert.False(t, ok)
+
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, img))
+	_, _, ok = ExifLocation(pngData.Bytes())
+	assert.False(t, ok)
+
+	// Truncated or corrupt metadata is ignored
+	data := jpegWithExif(t, exifWithGPS(binary.BigEndian, 'N', [3][2]uint32{{48, 1}, {51, 0}, {0, 1}}, 'E', [3][2]uint32{{2, 1}, {0, 1}, {0, 1}}))
+	_, _, ok = ExifLocation(data)
+	assert.False(t, ok)
+
+	for cut := 0; cut < 120; cut++ {
+		assert.NotPanics(t, func() { ExifLocation(data[:cut]) })
+	}
+}
+
+// jpegWithExif encodes a small JPEG with an APP1 segment holding tiff
+func jpegWithExif(t *testing.T, tiff []byte) []byte {
+	var encoded bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	var data bytes.Buffer
+	data.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&data, binary.BigEndian, uint16(len(segment)+2))
+	data.Write(segment)
+	data.Write(encoded.Bytes()[2:])
+	return data.Bytes()
+}
+
+// exifWithGPS lays out a TIFF structure whose first directory points to a
+// GPS directory with the given coordinates, as degrees, minutes and seconds
+func exifWithGPS(order binary.ByteOrder, latRef byte, lat [3][2]uint32, lngRef byte, lng [3][2]uint32) []byte {
+	var b bytes.Buffer
+	write := func(values ...any) {
+		for _, v := range values {
+			binary.Write(&b, order, v)
+		}
+	}
+
+	if order == binary.BigEndian {
+		b.WriteString("MM\x00*")
+	} else {
+		b.WriteString("II*\x00")
+	}
+	write(uint32(8))
+
+	// IFD0 at 8: one entry pointing to the GPS IFD at 26
+	write(uint16(1))
+	write(uint16(tagGPSInfo), uint16(4), uint32(1), uint32(26))
+	write(uint32(0))
+
+	// GPS IFD at 26: four entries, with the rationals after it at 80 and 104
+	write(uint16(4))
+	write(uint16(tagLatitudeRef), uint16(2), uint32(2))
+	b.Write([]byte{latRef, 0, 0, 0})
+	write(uint16(tagLatitude), uint16(5), uint32(3), uint32(80))
+	write(uint16(tagLongitudeRef), uint16(2), uint32(2))
+	b.Write([]byte{lngRef, 0, 0, 0})
+	write(uint16(tagLongitude), uint16(5), uint32(3), uint32(104))
+	write(uint32(0))
+
+	for _, part := range lat {
+		write(part)
+	}
+	for _, part := range lng {
+		write(part)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// CropSquare cuts the largest centered square out of an image
+func CropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	rect := image.Rect(x0, y0, x0+side, y0+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), src, rect.Min, draw.Src)
+	return dst
+}
+
+// Resize scales an image to width x height. Each destination pixel is the
+// area-weighted average of the source pixels it covers, which keeps
+// downscaled images free of aliasing.
+func Resize(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	scaleX := float64(b.Dx()) / float64(width)
+	scaleY := float64(b.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		sy0 := float64(y) * scaleY
+		sy1 := sy0 + scaleY
+
+		for x := 0; x < width; x++ {
+			sx0 := float64(x) * scaleX
+			sx1 := sx0 + scaleX
+
+			var r, g, bl, a, total float64
+
+			for sy := int(sy0); float64(sy) < sy1 && sy < b.Dy(); sy++ {
+				wy := overlap(float64(sy), sy0, sy1)
+
+				for sx := int(sx0); float64(sx) < sx1 && sx < b.Dx(); sx++ {
+					w := wy * overlap(float64(sx), sx0, sx1)
+
+					cr, cg, cb, ca := src.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r += float64(cr) * w
+					g += float64(cg) * w
+					bl += float64(cb) * w
+					a += float64(ca) * w
+					total += w
+				}
+			}
+
+			if total > 0 {
+				dst.Set(x, y, color.RGBA64{
+					R: uint16(r / total),
+					G: uint16(g / total),
+					B: uint16(bl / total),
+					A: uint16(a / total),
+				})
+			}
+		}
+	}
+
+	return dst
+}
+
+// Square crops an image to a centered square and scales it to size x size
+func Square(src image.Image, size int) image.Image {
+	return Resize(CropSquare(src), size, size)
+}
+
+// Renditions produces square copies of an image in each of the given sizes
+func Renditions(src image.Image, sizes []int) map[int]image.Image {
+	square := CropSquare(src)
+
+	renditions := make(map[int]image.Image, len(sizes))
+	for _, size := range sizes {
+		renditions[size] = Resize(square, size, size)
+	}
+	return renditions
+}
+
+// overlap returns how much of the unit pixel starting at p lies in [lo, hi)
+func overlap(p, lo, hi float64) float64 {
+	return max(0, min(p+1, hi)-max(p, lo))
+}
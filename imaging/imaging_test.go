@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCropSquare(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	// Mark the center column so we can check what survives the crop
+	for y := 0; y < 100; y++ {
+		src.Set(150, y, color.RGBA{255, 0, 0, 255})
+	}
+
+	cropped := CropSquare(src)
+
+	assert.Equal(t, image.Rect(0, 0, 100, 100), cropped.Bounds())
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, cropped.At(50, 10))
+}
+
+func TestResize_AveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	// Left half black, right half white
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if x >= 2 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	dst := Resize(src, 1, 1)
+
+	r, g, b, a := dst.At(0, 0).RGBA()
+	assert.InDelta(t, 0x7fff, r, 0x100)
+	assert.InDelta(t, 0x7fff, g, 0x100)
+	assert.InDelta(t, 0x7fff, b, 0x100)
+	assert.Equal(t, uint32(0xffff), a)
+}
+
+func TestRenditions(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 600))
+
+	renditions := Renditions(src, []int{320, 64})
+
+	assert.Len(t, renditions, 2)
+	assert.Equal(t, image.Rect(0, 0, 320, 320), renditions[320].Bounds())
+	assert.Equal(t, image.Rect(0, 0, 64, 64), renditions[64].Bounds())
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"log"
+
 	"github.com/anandh86/instagram/api/handlers"
+	"github.com/anandh86/instagram/api/middleware"
+	"github.com/anandh86/instagram/auth"
+	"github.com/anandh86/instagram/config"
 	"github.com/anandh86/instagram/repository"
 	"github.com/anandh86/instagram/service"
 	"github.com/gin-gonic/gin"
@@ -11,26 +17,257 @@ func main() {
 
 	r := gin.Default()
 
+	cfg := config.FromEnv()
+
+	var userRepo repository.IUserRepository = repository.NewInMemoryUserRepo()
+	if cfg.UserStorePath != "" {
+		fileUserRepo, err := repository.NewFileUserRepo(cfg.UserStorePath)
+		if err != nil {
+			log.Fatalf("opening user store: %v", err)
+		}
+		userRepo = fileUserRepo
+	}
+
+	var messageRepo repository.IMessageRepository = repository.NewInMemoryMessageRepo()
+	if cfg.MessageStorePath != "" {
+		fileMessageRepo, err := repository.NewFileMessageRepo(cfg.MessageStorePath)
+		if err != nil {
+			log.Fatalf("opening message store: %v", err)
+		}
+		messageRepo = fileMessageRepo
+	}
+
 	repo := repository.NewInMemoryRepo()
-	serv := service.NewService(repo)
-	handler := handlers.NewHandler(serv)
+	serv := service.NewService(repo, service.WithConfig(cfg), service.WithUserRepository(userRepo), service.WithMessageRepository(messageRepo))
+
+	// Keep the home feed of each user up to date as posts are created and
+	// users follow each other
+	timelineRepo := repository.NewInMemoryTimelineRepo(cfg.FeedTimelineLength)
+	feedServ := service.NewFeedService(repo, timelineRepo, cfg)
+	serv.Subscribe(feedServ.HandleEvent)
+	go feedServ.RunFanOut(context.Background())
+
+	// Keep the hashtag index up to date as captions and comments change
+	tagRepo := repository.NewInMemoryTagRepo()
+	tagServ := service.NewTagService(serv, tagRepo)
+	serv.Subscribe(tagServ.HandleEvent)
+
+	// Rank recent public posts for the explore page as they are engaged with
+	exploreServ := service.NewExploreService(serv)
+	serv.Subscribe(exploreServ.HandleEvent)
+
+	// Put the posts tagged with a place on the map
+	placeServ := service.NewPlaceService(serv)
+	serv.Subscribe(placeServ.HandleEvent)
+
+	// Let users know when they are mentioned
+	notificationServ := service.NewNotificationService(repo, repository.NewInMemoryNotificationRepo())
+	serv.Subscribe(notificationServ.HandleEvent)
+
+	userServ := service.NewUserService(userRepo, repo, cfg)
+
+	// Keep the search index up to date as posts, comments and profiles
+	// change, starting from the users already stored
+	searchServ := service.NewSearchService(serv, userRepo, tagRepo)
+	serv.Subscribe(searchServ.HandleEvent)
+	userServ.Subscribe(searchServ.HandleEvent)
+	if err := searchServ.Rebuild(); err != nil {
+		log.Fatalf("building search index: %v", err)
+	}
+
+	userHandler := handlers.NewUserHandler(userServ, cfg)
+	handler := handlers.NewHandler(serv, userServ, cfg)
+	feedHandler := handlers.NewFeedHandler(feedServ, serv, userServ)
+	tagHandler := handlers.NewTagHandler(tagServ, serv, userServ)
+	notificationHandler := handlers.NewNotificationHandler(notificationServ, userServ)
+	searchHandler := handlers.NewSearchHandler(searchServ, serv, userServ)
+	exploreHandler := handlers.NewExploreHandler(exploreServ, serv, userServ)
+	placeHandler := handlers.NewPlaceHandler(placeServ, serv, userServ)
+
+	var sessionRepo repository.ISessionRepository = repository.NewInMemorySessionRepo()
+	if cfg.SessionStorePath != "" {
+		fileSessionRepo, err := repository.NewFileSessionRepo(cfg.SessionStorePath)
+		if err != nil {
+			log.Fatalf("opening session store: %v", err)
+		}
+		sessionRepo = fileSessionRepo
+	}
+
+	keys, err := auth.LoadKeySet(cfg)
+	if err != nil {
+		log.Fatalf("loading JWT keys: %v", err)
+	}
+	sessionServ := service.NewSessionService(sessionRepo, keys, cfg)
+	sessionHandler := handlers.NewSessionHandler(userServ, sessionServ)
+
+	// Routes in this group act on behalf of the caller identified by the
+	// bearer access token
+	authed := r.Group("/", middleware.RequireAuth(keys, sessionServ))
+	// Routes in this group also serve anonymous callers, but tailor the
+	// response to the caller when an access token is given
+	viewer := r.Group("/", middleware.OptionalAuth(keys, sessionServ))
+
+	// Permanently remove trashed posts and comments once their restore
+	// window is over
+	go serv.RunTrashPurger(context.Background())
+	go serv.RunStorySweeper(context.Background())
+	go serv.RunPostScheduler(context.Background())
 
 	// User stories and their corresponding APIs
 
+	// As a user, I should be able to sign up with a username and password
+	r.POST("/api/users", userHandler.Register)
+	// As a user, I should be able to set up my profile with a display name,
+	// bio, website and avatar, and view the profile of any user by id or
+	// username
+	authed.PATCH("/api/users/me", userHandler.UpdateProfile)
+	authed.PUT("/api/users/me/avatar", userHandler.UploadAvatar)
+	viewer.GET("/api/users/:id", userHandler.GetProfile)
+	r.GET("/api/users/:id/avatar", userHandler.GetAvatar)
+	// As a user, I should be able to follow and unfollow other users, and
+	// browse who follows a user and whom they follow
+	authed.POST("/api/users/:id/follow", handler.FollowUser)
+	authed.DELETE("/api/users/:id/follow", handler.UnfollowUser)
+	viewer.GET("/api/users/:id/followers", handler.GetFollowers)
+	viewer.GET("/api/users/:id/following", handler.GetFollowing)
+	// As a user, I should be able to make my account private and decide who
+	// gets to follow me
+	authed.GET("/api/follow-requests", handler.GetFollowRequests)
+	authed.POST("/api/follow-requests/:id/approve", handler.ApproveFollowRequest)
+	authed.POST("/api/follow-requests/:id/deny", handler.DenyFollowRequest)
+	// As a user, I should be able to block users from seeing, commenting on
+	// or following my posts, and quietly mute users I don't want to hear from
+	authed.POST("/api/users/:id/block", handler.BlockUser)
+	authed.DELETE("/api/users/:id/block", handler.UnblockUser)
+	authed.GET("/api/blocks", handler.GetBlockedUsers)
+	authed.POST("/api/users/:id/mute", handler.MuteUser)
+	authed.DELETE("/api/users/:id/mute", handler.UnmuteUser)
+	authed.GET("/api/mutes", handler.GetMutedUsers)
+	// As a user, I should be able to log in with my username and password,
+	// and stay logged in by refreshing my session
+	r.POST("/api/sessions", sessionHandler.Login)
+	r.POST("/api/sessions/refresh", sessionHandler.Refresh)
+	// As a user, I should be able to see the devices I'm logged in on and
+	// log any of them out remotely
+	authed.GET("/api/sessions", sessionHandler.ListSessions)
+	authed.DELETE("/api/sessions/:id", sessionHandler.RevokeSession)
+
 	// As a user, I should be able to create posts with images (1 post - 1 image)
 	// As a user, I should be able to set a text caption when I create a post
-	r.POST("/api/posts", handler.CreatePost)
+	// As a user, I should be able to share a post with everyone, my followers,
+	// my close friends or only myself
+	authed.POST("/api/posts", handler.CreatePost)
+	authed.GET("/api/close-friends", handler.GetCloseFriends)
+	authed.PUT("/api/close-friends/:id", handler.AddCloseFriend)
+	authed.DELETE("/api/close-friends/:id", handler.RemoveCloseFriend)
+
+	// As a user, I should only see the posts, images and comments of private
+	// accounts that approved me as a follower, and of posts shared with me
+	viewer.GET("/api/posts/:id", handler.GetPostById)
+
+	// As a user, I should be able to delete my post and restore it from the
+	// trash within the restore window
+	authed.DELETE("/api/posts/:id", handler.DeletePost)
+	authed.POST("/api/posts/:id/restore", handler.RestorePost)
 
-	r.GET("/api/posts/:id", handler.GetPostById)
+	// As a user, I should be able to edit the caption of my post
+	authed.PATCH("/api/posts/:id", handler.EditPost)
+
+	// As a user, I should be able to turn comments off on my post or limit
+	// them to my followers, and change who can see it
+	authed.PATCH("/api/posts/:id/settings", handler.UpdatePostSettings)
 
 	// As a user, I should be able to get the list of all posts along with the
 	// last 2 comments on each post
-	r.GET("/api/posts", handler.GetAllPosts)
+	// As a user, I should be able to tag my post with a place, and browse
+	// the posts near a point or at a place
+	viewer.GET("/api/posts", placeHandler.Nearby(handler.GetAllPosts))
+	viewer.GET("/api/places/:id/posts", placeHandler.GetPlacePosts)
+
+	// As a user, I should be able to see the posts of the accounts I follow,
+	// newest first
+	authed.GET("/api/feed", feedHandler.GetFeed)
+
+	// As a user, I should be able to tag my posts with #hashtags in the
+	// caption or my own comments, and browse the posts with a hashtag
+	viewer.GET("/api/tags/:tag/posts", tagHandler.GetTaggedPosts)
+
+	// As a user, I should be able to @mention other users in my captions and
+	// comments, and get notified when someone mentions me
+	authed.GET("/api/notifications", notificationHandler.GetNotifications)
+
+	// As a user, I should be able to search posts by the words of their
+	// caption and comments, users by their name, and hashtags as I type
+	viewer.GET("/api/search", searchHandler.Search)
+
+	// As a user, I should be able to discover recent public posts that are
+	// getting the most likes, comments and saves
+	viewer.GET("/api/explore", exploreHandler.GetExplore)
+
+	// As a user, I should be able to share stories that my followers can see
+	// for a day, see who viewed them, and browse the stories of the accounts
+	// I follow, unseen ones first
+	authed.POST("/api/stories", handler.CreateStory)
+	authed.GET("/api/stories", handler.GetStoriesTray)
+	authed.GET("/api/stories/:id", handler.GetStory)
+	authed.GET("/api/stories/:id/viewers", handler.GetStoryViewers)
+	authed.DELETE("/api/stories/:id", handler.DeleteStory)
+
+	// As a user, I should be able to keep posts as drafts only I can see, and
+	// publish them now or schedule them to go live later
+	authed.GET("/api/drafts", handler.GetDrafts)
+	authed.POST("/api/posts/:id/publish", handler.PublishPost)
+	authed.PUT("/api/posts/:id/schedule", handler.SchedulePost)
+
+	// As a user, I should be able to message one or a few other users, share
+	// posts with them, and see who has read what
+	authed.POST("/api/conversations", handler.CreateConversation)
+	authed.GET("/api/conversations", handler.GetConversations)
+	authed.GET("/api/conversations/:id/messages", handler.GetMessages)
+	authed.POST("/api/conversations/:id/messages", handler.SendMessage)
+	authed.POST("/api/conversations/:id/read", handler.MarkConversationRead)
+
+	// As a user, I should be able to like a post and see who liked it
+	authed.POST("/api/posts/:id/likes", handler.LikePost)
+	authed.DELETE("/api/posts/:id/likes", handler.UnlikePost)
+	viewer.GET("/api/posts/:id/likes", handler.GetPostLikes)
+
+	// As a user, I should be able to save posts to come back to later, and
+	// sort them into private collections that I can name and arrange
+	authed.POST("/api/posts/:id/save", handler.SavePost)
+	authed.DELETE("/api/posts/:id/save", handler.UnsavePost)
+	authed.GET("/api/saved", handler.GetSavedPosts)
+	authed.POST("/api/collections", handler.CreateCollection)
+	authed.GET("/api/collections", handler.GetCollections)
+	authed.PUT("/api/collections", handler.ReorderCollections)
+	authed.PATCH("/api/collections/:id", handler.RenameCollection)
+	authed.DELETE("/api/collections/:id", handler.DeleteCollection)
+	authed.GET("/api/collections/:id/posts", handler.GetCollectionPosts)
+	authed.POST("/api/collections/:id/posts", handler.AddToCollection)
+	authed.PUT("/api/collections/:id/posts", handler.ReorderCollectionPosts)
+	authed.DELETE("/api/collections/:id/posts/:post_id", handler.RemoveFromCollection)
 
 	// As a user, I should be able to comment on a post
-	r.POST("/api/posts/:postId/comments", handler.CommentOnPost)
-	// As a user, I should be able to delete a comment (created by me) from a post
-	r.DELETE("/api/comments/:id", handler.DeleteComment)
+	authed.POST("/api/posts/:id/comments", handler.CommentOnPost)
+	// As a user, I should be able to reply to a comment and page through the
+	// conversation on a post as nested threads, oldest or newest first
+	viewer.GET("/api/posts/:id/comments", handler.GetPostComments)
+	// As a user, I should be able to delete a comment (created by me, or on my
+	// post) from a post
+	authed.DELETE("/api/comments/:id", handler.DeleteComment)
+	// As a user, I should be able to hide abusive comments on my post
+	authed.POST("/api/comments/:id/hide", handler.HideComment)
+	authed.DELETE("/api/comments/:id/hide", handler.UnhideComment)
+	// As a user, I should be able to fix a typo in my comment shortly after
+	// posting it; moderators can audit the previous versions
+	authed.PATCH("/api/comments/:id", handler.EditComment)
+	authed.GET("/api/comments/:id/revisions", handler.GetCommentRevisions)
+	// As a user, I should be able to restore a comment I deleted by mistake
+	authed.POST("/api/comments/:id/restore", handler.RestoreComment)
+	// As a user, I should be able to react to a comment with an emoji, and
+	// read the conversation on a post with the top comments first
+	authed.PUT("/api/comments/:id/reaction", handler.ReactToComment)
+	authed.DELETE("/api/comments/:id/reaction", handler.RemoveCommentReaction)
 
 	r.Run(":8080")
 }
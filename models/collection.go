@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SavedPostDTO is a post a user bookmarked
+type SavedPostDTO struct {
+	PostId  string    `json:"post_id"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// CollectionDTO is a named, private list of saved posts in the order its
+// owner chose
+type CollectionDTO struct {
+	Id        string    `json:"id"`
+	OwnerId   string    `json:"-"`
+	Name      string    `json:"name"`
+	Position  int       `json:"-"`
+	PostIds   []string  `json:"-"`
+	PostCount int       `json:"post_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SavedPostQueryDTO selects a page of saved posts or of a collection
+type SavedPostQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type SavedPostPageDTO struct {
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// ExploreQueryDTO selects a page of the explore page
+type ExploreQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type ExplorePageDTO struct {
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
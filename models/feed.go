@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TimelineEntryDTO places a post in the home feed of a user
+type TimelineEntryDTO struct {
+	PostId    string    `json:"post_id"`
+	AuthorId  string    `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FeedQueryDTO selects a page of a home feed
+type FeedQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type FeedPageDTO struct {
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// LikeDTO is a like on a post, as listed in the likers of the post
+type LikeDTO struct {
+	UserId    string            `json:"-"`
+	User      *AuthorSummaryDTO `json:"user,omitempty"`
+	CreatedAt time.Time         `json:"liked_at"`
+}
+
+// LikeQueryDTO selects a page of the likers of a post
+type LikeQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type LikePageDTO struct {
+	Likes      []LikeDTO `json:"likes"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// LikeSummaryDTO is how many users like a post and whether the viewer is
+// one of them
+type LikeSummaryDTO struct {
+	LikeCount int  `json:"like_count"`
+	LikedByMe bool `json:"liked_by_me"`
+}
+
+// Reactions users can leave on comments
+var CommentReactions = []string{"👍", "❤️", "😂", "😮", "😢", "😡"}
+
+// CommentReactionsDTO is how many users left each reaction on a comment, and
+// the reaction of the viewer if any
+type CommentReactionsDTO struct {
+	Reactions  map[string]int `json:"reactions"`
+	MyReaction string         `json:"my_reaction,omitempty"`
+}
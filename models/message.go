@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// ConversationDTO is a direct conversation between two users, or a group
+// conversation between a few
+type ConversationDTO struct {
+	Id        string    `json:"id"`
+	CreatorId string    `json:"creator_id"`
+	MemberIds []string  `json:"member_ids"`
+	Group     bool      `json:"group"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// When the last message was sent, or when the conversation was created
+	// until then
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+// MessageDTO is a message in a conversation: some text, a shared post, or
+// both
+type MessageDTO struct {
+	Id             string    `json:"id"`
+	ConversationId string    `json:"conversation_id"`
+	SenderId       string    `json:"sender_id"`
+	Text           string    `json:"text,omitempty"`
+	PostId         string    `json:"post_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ReadReceiptDTO marks the last message of a conversation a member has read
+type ReadReceiptDTO struct {
+	ConversationId string    `json:"conversation_id"`
+	UserId         string    `json:"user_id"`
+	MessageId      string    `json:"message_id"`
+	MessageAt      time.Time `json:"message_at"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+type ConversationRequestDTO struct {
+	MemberIds []string `json:"member_ids"`
+}
+
+type MessageRequestDTO struct {
+	Text   string `json:"text"`
+	PostId string `json:"post_id"`
+}
+
+type MessageResponseDTO struct {
+	Id        string            `json:"id"`
+	SenderId  string            `json:"-"`
+	Sender    *AuthorSummaryDTO `json:"sender,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	PostId    string            `json:"post_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	// The shared post, when the reader can see it
+	Post *PostResponseDTO `json:"post,omitempty"`
+}
+
+// ConversationResponseDTO is a conversation as listed in the inbox, with
+// its latest message and how many messages the reader hasn't read yet
+type ConversationResponseDTO struct {
+	Id            string              `json:"id"`
+	MemberIds     []string            `json:"-"`
+	Members       []AuthorSummaryDTO  `json:"members"`
+	Group         bool                `json:"group"`
+	LastMessage   *MessageResponseDTO `json:"last_message,omitempty"`
+	LastMessageAt time.Time           `json:"last_message_at"`
+	UnreadCount   int                 `json:"unread_count"`
+}
+
+// ConversationQueryDTO selects a page of the inbox
+type ConversationQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type ConversationPageDTO struct {
+	Conversations []ConversationResponseDTO `json:"conversations"`
+	NextCursor    string                    `json:"next_cursor,omitempty"`
+}
+
+// ReadReceiptResponseDTO tells how far a member has read a conversation
+type ReadReceiptResponseDTO struct {
+	UserId    string            `json:"-"`
+	User      *AuthorSummaryDTO `json:"user,omitempty"`
+	MessageId string            `json:"message_id"`
+	ReadAt    time.Time         `json:"read_at"`
+}
+
+// MessageQueryDTO selects a page of the messages of a conversation
+type MessageQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type MessagePageDTO struct {
+	Messages     []MessageResponseDTO     `json:"messages"`
+	ReadReceipts []ReadReceiptResponseDTO `json:"read_receipts"`
+	NextCursor   string                   `json:"next_cursor,omitempty"`
+}
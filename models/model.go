@@ -2,49 +2,165 @@ package models
 
 import "time"
 
+// Who can comment on a post
+const (
+	CommentPolicyEveryone  = "everyone"
+	CommentPolicyFollowers = "followers"
+	CommentPolicyOff       = "off"
+)
+
+// Who can see a post
+const (
+	VisibilityPublic       = "public"
+	VisibilityFollowers    = "followers"
+	VisibilityCloseFriends = "close_friends"
+	VisibilityOnlyMe       = "only_me"
+)
+
+// Whether a post is live yet. Drafts and scheduled posts are only seen by
+// their author until they are published; posts without a status are
+// published.
+const (
+	PostStatusPublished = "published"
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+)
+
 type PostMetaDTO struct {
-	Id        string               `json:"id"`
-	Caption   string               `json:"caption"`
-	CreatedAt time.Time            `json:"created_at"`
-	ImageId   string               `json:"image_id"`
-	Creator   string               `json:"creator_id"`
-	Comments  []CommentResponseDTO `json:"comments"`
+	Id            string               `json:"id"`
+	Caption       string               `json:"caption"`
+	CreatedAt     time.Time            `json:"created_at"`
+	ImageId       string               `json:"image_id"`
+	Creator       string               `json:"creator_id"`
+	CommentPolicy string               `json:"comment_policy"`
+	Visibility    string               `json:"visibility"`
+	Mentions      []MentionDTO         `json:"mentions,omitempty"`
+	Comments      []CommentResponseDTO `json:"comments"`
+	Location      *PlaceDTO            `json:"location,omitempty"`
+	Status        string               `json:"status,omitempty"`
+	PublishAt     *time.Time           `json:"publish_at,omitempty"`
+	DeletedAt     *time.Time           `json:"deleted_at,omitempty"`
+
+	// Set from when a post is published until its followers, mentions and
+	// indexes have been told about it
+	Unannounced bool `json:"unannounced,omitempty"`
+}
+
+// Published tells whether a post is live, rather than a draft or waiting
+// for its publish time; the trash is not considered
+func (p PostMetaDTO) Published() bool {
+	return p.Status != PostStatusDraft && p.Status != PostStatusScheduled
 }
 
 type PostRequestDTO struct {
-	Id        string    `json:"id"`
-	Caption   string    `json:"caption"`
-	CreatedAt time.Time `json:"created_at"`
-	ImageId   string    `json:"image_id"`
-	AuthorId  string    `json:"creator_id"`
-	Comments  []string  `json:"comments"`
+	Id         string    `json:"id"`
+	Caption    string    `json:"caption"`
+	CreatedAt  time.Time `json:"created_at"`
+	ImageId    string    `json:"image_id"`
+	AuthorId   string    `json:"creator_id"`
+	Visibility string    `json:"visibility"`
+	Comments   []string  `json:"comments"`
+
+	// Where the post was taken, if the author tagged a place
+	Location *PlaceDTO `json:"location"`
+
+	// Save the post as a draft, or schedule it for later, instead of
+	// publishing it right away
+	Draft     bool       `json:"draft"`
+	PublishAt *time.Time `json:"publish_at"`
 }
 
 type PostResponseDTO struct {
-	Id       string               `json:"id"`
-	Caption  string               `json:"caption"`
-	AuthorId string               `json:"creator_id"`
-	ImageId  string               `json:"image_id"`
-	Comments []CommentResponseDTO `json:"comments"`
+	Id            string               `json:"id"`
+	Caption       string               `json:"caption"`
+	CreatedAt     time.Time            `json:"created_at"`
+	AuthorId      string               `json:"-"`
+	Author        *AuthorSummaryDTO    `json:"author,omitempty"`
+	ImageId       string               `json:"image_id"`
+	CommentPolicy string               `json:"comment_policy"`
+	Visibility    string               `json:"visibility"`
+	LikeCount     int                  `json:"like_count"`
+	LikedByMe     bool                 `json:"liked_by_me"`
+	Mentions      []MentionDTO         `json:"mentions,omitempty"`
+	Location      *PlaceDTO            `json:"location,omitempty"`
+	Status        string               `json:"status,omitempty"`
+	PublishAt     *time.Time           `json:"publish_at,omitempty"`
+	Comments      []CommentResponseDTO `json:"comments"`
+}
+
+// PostSettingsDTO holds the settings a post author can change after posting.
+// Empty settings are left as they are.
+type PostSettingsDTO struct {
+	CommentPolicy string `json:"comment_policy"`
+	Visibility    string `json:"visibility"`
+}
+
+// MentionDTO links an @username in a caption or comment to the mentioned
+// user. Start and End are offsets in characters (Unicode code points), with
+// the '@' included: the mention spans [start, end).
+type MentionDTO struct {
+	UserId   string `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
 }
 
 type CommentDTO struct {
-	Id        string    `json:"id"`
-	PostId    string    `json:"post_id"`
-	Content   string    `json:"comment"`
-	CreatedAt time.Time `json:"created_at"`
-	Creator   string    `json:"creator_id"`
+	Id        string       `json:"id"`
+	PostId    string       `json:"post_id"`
+	ParentId  string       `json:"parent_comment_id,omitempty"`
+	Content   string       `json:"comment"`
+	CreatedAt time.Time    `json:"created_at"`
+	Creator   string       `json:"creator_id"`
+	Mentions  []MentionDTO `json:"mentions,omitempty"`
+	EditedAt  *time.Time   `json:"edited_at,omitempty"`
+	HiddenAt  *time.Time   `json:"hidden_at,omitempty"`
+	DeletedAt *time.Time   `json:"deleted_at,omitempty"`
+	DeletedBy string       `json:"deleted_by,omitempty"`
+}
+
+// CommentRevisionDTO is a previous version of an edited comment
+type CommentRevisionDTO struct {
+	CommentId  string    `json:"comment_id"`
+	Content    string    `json:"comment"`
+	WrittenAt  time.Time `json:"written_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
 }
 
 type CommentRequestDTO struct {
-	PostId   string `json:"post_id"`
-	Comment  string `json:"comment"`
-	AuthorId string `json:"creator_id"`
+	PostId   string       `json:"post_id"`
+	ParentId string       `json:"parent_comment_id"`
+	Comment  string       `json:"comment"`
+	AuthorId string       `json:"creator_id"`
+	Mentions []MentionDTO `json:"-"`
 }
 
 type CommentResponseDTO struct {
-	Id        string    `json:"id"`
-	Comment   string    `json:"comment"`
-	AuthorId  string    `json:"creator_id"`
-	CreatedAt time.Time `json:"created_at"`
+	Id         string               `json:"id"`
+	ParentId   string               `json:"parent_comment_id,omitempty"`
+	Comment    string               `json:"comment"`
+	Mentions   []MentionDTO         `json:"mentions,omitempty"`
+	AuthorId   string               `json:"-"`
+	Author     *AuthorSummaryDTO    `json:"author,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	EditedAt   *time.Time           `json:"edited_at,omitempty"`
+	Deleted    bool                 `json:"deleted,omitempty"`
+	Hidden     bool                 `json:"hidden,omitempty"`
+	Reactions  map[string]int       `json:"reactions,omitempty"`
+	MyReaction string               `json:"my_reaction,omitempty"`
+	ReplyCount int                  `json:"reply_count"`
+	Replies    []CommentResponseDTO `json:"replies,omitempty"`
+}
+
+// CommentQueryDTO selects a page of the comment threads on a post
+type CommentQueryDTO struct {
+	Order    string // "oldest" (default), "newest" or "top"
+	Cursor   string
+	Limit    int
+	MaxDepth int
+}
+
+type CommentPageDTO struct {
+	Comments   []CommentResponseDTO `json:"comments"`
+	NextCursor string               `json:"next_cursor,omitempty"`
 }
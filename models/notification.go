@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Kinds of notifications
+const (
+	// The actor mentioned the user in a post, or in a comment when
+	// comment_id is set
+	NotificationMention = "mention"
+)
+
+// NotificationDTO tells a user about something another user did that
+// concerns them
+type NotificationDTO struct {
+	Id        string            `json:"id"`
+	UserId    string            `json:"-"`
+	Type      string            `json:"type"`
+	ActorId   string            `json:"-"`
+	Actor     *AuthorSummaryDTO `json:"actor,omitempty"`
+	PostId    string            `json:"post_id,omitempty"`
+	CommentId string            `json:"comment_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// NotificationQueryDTO selects a page of the notifications of a user
+type NotificationQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type NotificationPageDTO struct {
+	Notifications []NotificationDTO `json:"notifications"`
+	NextCursor    string            `json:"next_cursor,omitempty"`
+}
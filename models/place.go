@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// PlaceDTO is a named location posts can be tagged with. Posts tagged with
+// the same name close to each other share a place.
+type PlaceDTO struct {
+	Id        string  `json:"id,omitempty"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// LocatedPostDTO places a post on the map
+type LocatedPostDTO struct {
+	PostId    string    `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+	PlaceId   string    `json:"place_id"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+}
+
+// NearbyQueryDTO selects a page of the posts tagged within a distance of a
+// point. A zero RadiusKm uses the default radius.
+type NearbyQueryDTO struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Cursor    string
+	Limit     int
+}
+
+type NearbyPageDTO struct {
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// PlaceQueryDTO selects a page of the posts tagged with a place
+type PlaceQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type PlacePageDTO struct {
+	Place      PlaceDTO          `json:"place"`
+	PostCount  int               `json:"post_count"`
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
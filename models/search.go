@@ -0,0 +1,27 @@
+package models
+
+// What a search looks for
+const (
+	SearchTypePosts = "posts"
+	SearchTypeUsers = "users"
+	SearchTypeTags  = "tags"
+)
+
+// SearchQueryDTO selects a page of search results
+type SearchQueryDTO struct {
+	Query  string
+	Type   string // "posts" (default), "users" or "tags"
+	Cursor string
+	Limit  int
+}
+
+// SearchPageDTO holds a page of the results of a search, best match first.
+// Only the list matching the type of the search is set.
+type SearchPageDTO struct {
+	Type       string             `json:"type"`
+	Posts      []PostResponseDTO  `json:"posts,omitempty"`
+	UserIds    []string           `json:"-"`
+	Users      []AuthorSummaryDTO `json:"users,omitempty"`
+	Tags       []TagCountDTO      `json:"tags,omitempty"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
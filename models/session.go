@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Session is a logged in device. Each refresh rotates its refresh token; the
+// tokens it replaced are remembered so that replaying one can be detected.
+type Session struct {
+	Id                string     `json:"id"`
+	UserId            string     `json:"user_id"`
+	Device            string     `json:"device"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	RefreshTokenHash  string     `json:"refresh_token_hash"`
+	UsedRefreshHashes []string   `json:"used_refresh_hashes"`
+}
+
+type SessionResponseDTO struct {
+	Id         string    `json:"id"`
+	Device     string    `json:"device"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+// TokenPairDTO is handed out on login and on every refresh
+type TokenPairDTO struct {
+	SessionId    string    `json:"session_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
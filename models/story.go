@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// StoryDTO is a story: an image shared with followers until it expires
+type StoryDTO struct {
+	Id        string    `json:"id"`
+	AuthorId  string    `json:"-"`
+	ImageId   string    `json:"image_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StoryResponseDTO is a story as listed in the stories tray, along with
+// whether the viewer has seen it. Authors also get the number of viewers
+// of their own stories.
+type StoryResponseDTO struct {
+	Id        string    `json:"id"`
+	ImageId   string    `json:"image_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Seen      bool      `json:"seen"`
+	ViewCount *int      `json:"view_count,omitempty"`
+}
+
+// StoryViewDTO is a view of a story, as listed in the viewers of the story
+type StoryViewDTO struct {
+	UserId   string            `json:"-"`
+	User     *AuthorSummaryDTO `json:"user,omitempty"`
+	ViewedAt time.Time         `json:"viewed_at"`
+}
+
+// StoryViewQueryDTO selects a page of the viewers of a story
+type StoryViewQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type StoryViewPageDTO struct {
+	Views      []StoryViewDTO `json:"views"`
+	ViewCount  int            `json:"view_count"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// StoryTrayEntryDTO holds the live stories of a user, oldest first, as shown
+// in the stories tray
+type StoryTrayEntryDTO struct {
+	AuthorId  string             `json:"-"`
+	Author    *AuthorSummaryDTO  `json:"author,omitempty"`
+	Stories   []StoryResponseDTO `json:"stories"`
+	HasUnseen bool               `json:"has_unseen"`
+	LatestAt  time.Time          `json:"latest_at"`
+}
+
+type StoryTrayDTO struct {
+	Tray []StoryTrayEntryDTO `json:"tray"`
+}
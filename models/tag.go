@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TaggedPostDTO places a post in the listing of a hashtag
+type TaggedPostDTO struct {
+	PostId    string    `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagCountDTO is a hashtag and how many posts carry it
+type TagCountDTO struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"`
+}
+
+// TagQueryDTO selects a page of the posts with a hashtag
+type TagQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type TagPageDTO struct {
+	Tag        string            `json:"tag"`
+	PostCount  int               `json:"post_count"`
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// User is a registered account
+type User struct {
+	Id           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Bio          string    `json:"bio,omitempty"`
+	Website      string    `json:"website,omitempty"`
+
+	// Only approved followers can see the posts of a private account
+	Private bool `json:"private,omitempty"`
+
+	// Image ids of the avatar renditions, by their width in pixels
+	Avatar map[int]string `json:"avatar,omitempty"`
+}
+
+type UserRequestDTO struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type UserResponseDTO struct {
+	Id        string    `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProfileRequestDTO holds the profile fields a user can change; fields left
+// out of the request keep their current value
+type ProfileRequestDTO struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	Website     *string `json:"website"`
+	Private     *bool   `json:"private"`
+}
+
+// ProfileDTO is the public profile of a user
+type ProfileDTO struct {
+	Id          string    `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Website     string    `json:"website"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	Private     bool      `json:"private"`
+	PostCount   int       `json:"post_count"`
+	Followers   int       `json:"follower_count"`
+	Following   int       `json:"following_count"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// How the user relates to the viewer, when the viewer is logged in
+	Relationship *RelationshipDTO `json:"relationship,omitempty"`
+}
+
+// RelationshipDTO tells how a user and the viewer follow each other
+type RelationshipDTO struct {
+	Following  bool `json:"following"`
+	FollowedBy bool `json:"followed_by"`
+
+	// The viewer asked to follow the user and awaits approval
+	Requested bool `json:"requested"`
+}
+
+// Outcome of asking to follow a user
+const (
+	FollowStatusFollowing = "following"
+	FollowStatusRequested = "requested"
+)
+
+// States of a request to follow a private account
+const (
+	FollowRequestPending  = "pending"
+	FollowRequestApproved = "approved"
+	FollowRequestDenied   = "denied"
+)
+
+// FollowRequestDTO is a request to follow a private account
+type FollowRequestDTO struct {
+	RequesterId string            `json:"-"`
+	Requester   *AuthorSummaryDTO `json:"requester,omitempty"`
+	TargetId    string            `json:"-"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"requested_at"`
+	DecidedAt   *time.Time        `json:"decided_at,omitempty"`
+}
+
+type FollowRequestPageDTO struct {
+	Requests   []FollowRequestDTO `json:"requests"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// FollowDTO is one entry of a follower or following list
+type FollowDTO struct {
+	UserId    string            `json:"-"`
+	User      *AuthorSummaryDTO `json:"user,omitempty"`
+	CreatedAt time.Time         `json:"followed_at"`
+}
+
+// FollowQueryDTO selects a page of a follower or following list
+type FollowQueryDTO struct {
+	Cursor string
+	Limit  int
+}
+
+type FollowPageDTO struct {
+	Users      []FollowDTO `json:"users"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// AuthorSummaryDTO is the compact view of a user embedded in posts and comments
+type AuthorSummaryDTO struct {
+	Id          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
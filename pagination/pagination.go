@@ -0,0 +1,136 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Page size used when the client does not ask for one
+	DefaultLimit = 20
+
+	// Largest page size a client can ask for
+	MaxLimit = 100
+)
+
+// Cursor marks the position of the last item of a page. Items are ordered by
+// rank, then time, with ties broken by ID, so a cursor stays valid while
+// items are added or removed around it. Chronological listings leave the
+// rank at zero; rankings such as top comments can move items between pages
+// when their rank changes.
+type Cursor struct {
+	Rank int
+	Time time.Time
+	Id   string
+}
+
+// Encode turns the cursor into an opaque string for clients
+func (c Cursor) Encode() string {
+	key := strconv.FormatInt(c.Time.UnixNano(), 10)
+	if c.Rank != 0 {
+		key += ":" + strconv.Itoa(c.Rank)
+	}
+
+	raw := key + "|" + c.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a cursor produced by Encode
+func Decode(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	key, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	nanos, rank, ranked := strings.Cut(key, ":")
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	cursor := Cursor{Time: time.Unix(0, unixNano), Id: id}
+
+	if ranked {
+		if cursor.Rank, err = strconv.Atoi(rank); err != nil {
+			return Cursor{}, errors.New("invalid cursor")
+		}
+	}
+
+	return cursor, nil
+}
+
+// ClampLimit applies the default and maximum page sizes
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// Sort orders items by their cursor key, highest ranked and newest first when
+// desc is set
+func Sort[T any](items []T, desc bool, key func(T) Cursor) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return before(key(items[i]), key(items[j]), desc)
+	})
+}
+
+// Paginate returns the page of items that follows the cursor, along with the
+// cursor of the next page ("" on the last page). Items must already be sorted
+// with Sort using the same direction; an empty cursor starts from the top.
+func Paginate[T any](items []T, cursor string, limit int, desc bool, key func(T) Cursor) (page []T, next string, err error) {
+	limit = ClampLimit(limit)
+
+	start := 0
+	if cursor != "" {
+		after, err := Decode(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		start = sort.Search(len(items), func(i int) bool {
+			return before(after, key(items[i]), desc)
+		})
+	}
+
+	end := start + limit
+	if end >= len(items) {
+		return items[start:], "", nil
+	}
+
+	page = items[start:end]
+	return page, key(page[len(page)-1]).Encode(), nil
+}
+
+// before reports whether a comes before b in the given direction
+func before(a, b Cursor, desc bool) bool {
+	if a.Rank != b.Rank {
+		if desc {
+			return a.Rank > b.Rank
+		}
+		return a.Rank < b.Rank
+	}
+	if !a.Time.Equal(b.Time) {
+		if desc {
+			return a.Time.After(b.Time)
+		}
+		return a.Time.Before(b.Time)
+	}
+	if desc {
+		return a.Id > b.Id
+	}
+	return a.Id < b.Id
+}
@@ -0,0 +1,107 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	id string
+	at time.Time
+}
+
+func itemKey(i item) Cursor {
+	return Cursor{Time: i.at, Id: i.id}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{Time: time.Unix(0, 1723000000123456789), Id: "abc|def"}
+
+	decoded, err := Decode(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.True(t, cursor.Time.Equal(decoded.Time))
+	assert.Equal(t, cursor.Id, decoded.Id)
+
+	_, err = Decode("not a cursor")
+	assert.EqualError(t, err, "invalid cursor")
+
+	ranked := Cursor{Rank: 42, Time: time.Unix(0, 1723000000123456789), Id: "abc"}
+
+	decoded, err = Decode(ranked.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, decoded.Rank)
+	assert.True(t, ranked.Time.Equal(decoded.Time))
+	assert.Equal(t, ranked.Id, decoded.Id)
+}
+
+func TestPaginate_Ranked(t *testing.T) {
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	ranks := map[string]int{"a": 3, "b": 0, "c": 3, "d": 1}
+	items := []item{
+		{id: "a", at: base},
+		{id: "b", at: base.Add(time.Minute)},
+		{id: "c", at: base.Add(2 * time.Minute)},
+		{id: "d", at: base.Add(3 * time.Minute)},
+	}
+	key := func(i item) Cursor {
+		return Cursor{Rank: ranks[i.id], Time: i.at, Id: i.id}
+	}
+
+	Sort(items, true, key)
+
+	page, next, err := Paginate(items, "", 3, true, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "d"}, []string{page[0].id, page[1].id, page[2].id})
+
+	page, next, err = Paginate(items, next, 3, true, key)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "b", page[0].id)
+	assert.Empty(t, next)
+}
+
+func TestPaginate(t *testing.T) {
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	items := []item{
+		{id: "a", at: base},
+		{id: "c", at: base.Add(time.Minute)},
+		{id: "b", at: base.Add(time.Minute)},
+		{id: "d", at: base.Add(2 * time.Minute)},
+	}
+
+	Sort(items, true, itemKey)
+
+	page, next, err := Paginate(items, "", 2, true, itemKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []item{items[0], items[1]}, page)
+	assert.Equal(t, "d", page[0].id)
+	assert.Equal(t, "c", page[1].id)
+	assert.NotEmpty(t, next)
+
+	page, next, err = Paginate(items, next, 2, true, itemKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", page[0].id)
+	assert.Equal(t, "a", page[1].id)
+	assert.Empty(t, next)
+}
+
+func TestPaginate_CursorItemRemoved(t *testing.T) {
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	items := []item{
+		{id: "a", at: base},
+		{id: "b", at: base.Add(time.Minute)},
+		{id: "c", at: base.Add(2 * time.Minute)},
+	}
+
+	_, next, _ := Paginate(items, "", 1, false, itemKey)
+
+	// The item the cursor points at is gone; the next page still resumes
+	// right after its position
+	page, _, err := Paginate(items[1:], next, 1, false, itemKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", page[0].id)
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/anandh86/instagram/pagination"
+)
+
+// edge links a user to another one in an adjacency list
+type edge struct {
+	userID    string
+	createdAt time.Time
+	removed   bool
+}
+
+func (e edge) cursor() pagination.Cursor {
+	return pagination.Cursor{Time: e.createdAt, Id: e.userID}
+}
+
+// adjacency is one side of the follow graph for a single user: the users it
+// links to, ordered by when the link was made, with an index for constant
+// time lookups. Removed edges are only marked, and compacted away once they
+// make up half of the list, so that following and unfollowing stay cheap
+// even for users with millions of followers.
+type adjacency struct {
+	edges   []edge
+	index   map[string]int
+	removed int
+}
+
+func newAdjacency() *adjacency {
+	return &adjacency{index: make(map[string]int)}
+}
+
+// len is the number of live edges
+func (a *adjacency) len() int {
+	return len(a.index)
+}
+
+func (a *adjacency) has(userID string) bool {
+	_, exists := a.index[userID]
+	return exists
+}
+
+// add links userID, keeping the edges ordered. Returns false if the link
+// already existed.
+func (a *adjacency) add(userID string, at time.Time) bool {
+	if a.has(userID) {
+		return false
+	}
+
+	e := edge{userID: userID, createdAt: at}
+
+	// Links are almost always made in time order, so this is nearly always
+	// an append
+	pos := sort.Search(len(a.edges), func(i int) bool {
+		return edgeBefore(e, a.edges[i])
+	})
+
+	a.edges = append(a.edges, edge{})
+	copy(a.edges[pos+1:], a.edges[pos:])
+	a.edges[pos] = e
+
+	if pos == len(a.edges)-1 {
+		a.index[userID] = pos
+	} else {
+		a.reindex(pos)
+	}
+	return true
+}
+
+// remove unlinks userID. Returns false if there was no link.
+func (a *adjacency) remove(userID string) bool {
+	pos, exists := a.index[userID]
+	if !exists {
+		return false
+	}
+
+	a.edges[pos].removed = true
+	delete(a.index, userID)
+	a.removed++
+
+	if a.removed > len(a.edges)/2 {
+		a.compact()
+	}
+	return true
+}
+
+// page lists up to limit live edges, newest first, starting after the
+// cursor. Returns the cursor of the next page, "" on the last page.
+func (a *adjacency) page(cursor string, limit int) ([]edge, string, error) {
+	limit = pagination.ClampLimit(limit)
+
+	// Walk backwards from the newest edge, or from just before the cursor
+	end := len(a.edges)
+	if cursor != "" {
+		after, err := pagination.Decode(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		end = sort.Search(len(a.edges), func(i int) bool {
+			return !edgeBefore(a.edges[i], edge{userID: after.Id, createdAt: after.Time})
+		})
+	}
+
+	page := make([]edge, 0, limit)
+	i := end - 1
+	for ; i >= 0 && len(page) < limit; i-- {
+		if !a.edges[i].removed {
+			page = append(page, a.edges[i])
+		}
+	}
+
+	// Skip trailing removed edges to tell whether anything is left
+	for i >= 0 && a.edges[i].removed {
+		i--
+	}
+	if i < 0 || len(page) == 0 {
+		return page, "", nil
+	}
+	return page, page[len(page)-1].cursor().Encode(), nil
+}
+
+// compact drops the removed edges
+func (a *adjacency) compact() {
+	live := a.edges[:0]
+	for _, e := range a.edges {
+		if !e.removed {
+			live = append(live, e)
+		}
+	}
+
+	// Clear the tail so dropped edges can be collected
+	clear(a.edges[len(live):])
+	a.edges = live
+	a.removed = 0
+	a.reindex(0)
+}
+
+// reindex refreshes the positions of the edges from pos onwards
+func (a *adjacency) reindex(pos int) {
+	for i := pos; i < len(a.edges); i++ {
+		if !a.edges[i].removed {
+			a.index[a.edges[i].userID] = i
+		}
+	}
+}
+
+// edgeBefore orders edges by creation time, with ties broken by user id
+func edgeBefore(a, b edge) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.Before(b.createdAt)
+	}
+	return a.userID < b.userID
+}
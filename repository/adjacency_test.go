@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjacency_KeepsOrderAcrossCompaction(t *testing.T) {
+	adj := newAdjacency()
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		adj.add(fmt.Sprintf("user%d", i), start.Add(time.Duration(i)*time.Second))
+	}
+	// Removing most edges triggers a compaction
+	for i := 0; i < 8; i++ {
+		adj.remove(fmt.Sprintf("user%d", i))
+	}
+
+	assert.Equal(t, 2, adj.len())
+	assert.Less(t, len(adj.edges), 10)
+	assert.True(t, adj.has("user9"))
+	assert.False(t, adj.has("user0"))
+
+	// An edge made out of order lands in its place
+	adj.add("late", start.Add(-time.Second))
+
+	page, next, err := adj.page("", 10)
+
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Equal(t, []string{"user9", "user8", "late"}, []string{page[0].userID, page[1].userID, page[2].userID})
+	assert.Equal(t, "user8", adj.edges[adj.index["user8"]].userID)
+}
+
+func TestAdjacency_CursorSurvivesRemoval(t *testing.T) {
+	adj := newAdjacency()
+	start := time.Now()
+
+	for i := 0; i < 4; i++ {
+		adj.add(fmt.Sprintf("user%d", i), start.Add(time.Duration(i)*time.Second))
+	}
+
+	page, next, _ := adj.page("", 2)
+	assert.Equal(t, "user2", page[1].userID)
+
+	// The last edge of the page goes away before the next page is read
+	adj.remove("user2")
+
+	page, next, _ = adj.page(next, 2)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "user1", page[0].userID)
+	assert.Equal(t, "user0", page[1].userID)
+	assert.Empty(t, next)
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// InMemoryCollectionRepo is an in-memory implementation of
+// ICollectionRepository
+type InMemoryCollectionRepo struct {
+	mu sync.RWMutex
+
+	// Bookmarks by user, then post id
+	saved map[string]map[string]time.Time
+
+	collections map[string]models.CollectionDTO
+
+	// Collection ids of each user, in the order they arranged them
+	order map[string][]string
+}
+
+// NewInMemoryCollectionRepo creates a new instance of InMemoryCollectionRepo
+func NewInMemoryCollectionRepo() *InMemoryCollectionRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ ICollectionRepository = (*InMemoryCollectionRepo)(nil)
+
+	return &InMemoryCollectionRepo{
+		saved:       make(map[string]map[string]time.Time),
+		collections: make(map[string]models.CollectionDTO),
+		order:       make(map[string][]string),
+	}
+}
+
+// SavePost bookmarks a post, keeping the time it was first saved
+func (repo *InMemoryCollectionRepo) SavePost(userID string, postID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	saved := relationOf(repo.saved, userID)
+	if _, exists := saved[postID]; !exists {
+		saved[postID] = time.Now()
+	}
+	return nil
+}
+
+// UnsavePost removes a bookmark and takes the post out of every collection
+// of the user
+func (repo *InMemoryCollectionRepo) UnsavePost(userID string, postID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.saved[userID], postID)
+
+	for _, collectionID := range repo.order[userID] {
+		collection := repo.collections[collectionID]
+		if i := slices.Index(collection.PostIds, postID); i >= 0 {
+			collection.PostIds = slices.Delete(slices.Clone(collection.PostIds), i, i+1)
+			collection.UpdatedAt = time.Now()
+			repo.collections[collectionID] = collection
+		}
+	}
+	return nil
+}
+
+// GetSavedPosts lists the bookmarks of a user, most recent first
+func (repo *InMemoryCollectionRepo) GetSavedPosts(userID string) ([]models.SavedPostDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	saved := make([]models.SavedPostDTO, 0, len(repo.saved[userID]))
+	for postID, savedAt := range repo.saved[userID] {
+		saved = append(saved, models.SavedPostDTO{PostId: postID, SavedAt: savedAt})
+	}
+
+	sort.Slice(saved, func(i, j int) bool {
+		if !saved[i].SavedAt.Equal(saved[j].SavedAt) {
+			return saved[i].SavedAt.After(saved[j].SavedAt)
+		}
+		return saved[i].PostId > saved[j].PostId
+	})
+	return saved, nil
+}
+
+// CreateCollection saves a new collection after the others of its owner
+func (repo *InMemoryCollectionRepo) CreateCollection(collection models.CollectionDTO) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	collection.Id = uuid.New().String()
+	collection.PostIds = slices.Clone(collection.PostIds)
+
+	repo.collections[collection.Id] = collection
+	repo.order[collection.OwnerId] = append(repo.order[collection.OwnerId], collection.Id)
+
+	return collection.Id, nil
+}
+
+// GetCollection retrieves a collection by its id
+func (repo *InMemoryCollectionRepo) GetCollection(collectionID string) (models.CollectionDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	collection, exists := repo.collections[collectionID]
+	if !exists {
+		return models.CollectionDTO{}, errors.New("collection not found")
+	}
+
+	return repo.collectionView(collection), nil
+}
+
+// GetCollections lists the collections of a user in their order
+func (repo *InMemoryCollectionRepo) GetCollections(userID string) ([]models.CollectionDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	collections := make([]models.CollectionDTO, 0, len(repo.order[userID]))
+	for _, collectionID := range repo.order[userID] {
+		collections = append(collections, repo.collectionView(repo.collections[collectionID]))
+	}
+	return collections, nil
+}
+
+// UpdateCollection replaces the name and posts of a collection
+func (repo *InMemoryCollectionRepo) UpdateCollection(collection models.CollectionDTO) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	existing, exists := repo.collections[collection.Id]
+	if !exists {
+		return errors.New("collection not found")
+	}
+
+	existing.Name = collection.Name
+	existing.PostIds = slices.Clone(collection.PostIds)
+	existing.UpdatedAt = collection.UpdatedAt
+	repo.collections[collection.Id] = existing
+	return nil
+}
+
+// ReorderCollections arranges the collections of a user. The ids must be
+// exactly the collections of the user.
+func (repo *InMemoryCollectionRepo) ReorderCollections(userID string, collectionIDs []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	current := slices.Clone(repo.order[userID])
+	ordered := slices.Clone(collectionIDs)
+	slices.Sort(current)
+	slices.Sort(ordered)
+
+	if !slices.Equal(current, ordered) {
+		return errors.New("invalid order")
+	}
+
+	repo.order[userID] = slices.Clone(collectionIDs)
+	return nil
+}
+
+// DeleteCollection removes a collection, leaving its posts saved
+func (repo *InMemoryCollectionRepo) DeleteCollection(collectionID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	collection, exists := repo.collections[collectionID]
+	if !exists {
+		return errors.New("collection not found")
+	}
+
+	delete(repo.collections, collectionID)
+	repo.order[collection.OwnerId] = slices.DeleteFunc(repo.order[collection.OwnerId], func(id string) bool {
+		return id == collectionID
+	})
+	return nil
+}
+
+// collectionView copies a stored collection, filling in the derived fields
+func (repo *InMemoryCollectionRepo) collectionView(collection models.CollectionDTO) models.CollectionDTO {
+	collection.PostIds = slices.Clone(collection.PostIds)
+	collection.PostCount = len(collection.PostIds)
+	collection.Position = slices.Index(repo.order[collection.OwnerId], collection.Id)
+	return collection
+}
@@ -0,0 +1,32 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type ICollectionRepository interface {
+	// Bookmark a Post for a User; saving it again has no effect
+	SavePost(user_id string, post_id string) error
+
+	// Remove a bookmark, along with the Post from every Collection of the User
+	UnsavePost(user_id string, post_id string) error
+
+	// Get the bookmarks of a User, most recently saved first
+	GetSavedPosts(user_id string) ([]models.SavedPostDTO, error)
+
+	// Save a new Collection, placed after the other Collections of its owner
+	CreateCollection(collection models.CollectionDTO) (collection_id string, err error)
+
+	// Retrieve a Collection
+	GetCollection(collection_id string) (models.CollectionDTO, error)
+
+	// Get the Collections of a User, in the order they arranged them
+	GetCollections(user_id string) ([]models.CollectionDTO, error)
+
+	// Replace the name and Posts of a Collection
+	UpdateCollection(collection models.CollectionDTO) error
+
+	// Arrange the Collections of a User in the given order
+	ReorderCollections(user_id string, collection_ids []string) error
+
+	// Delete a Collection; its Posts stay saved
+	DeleteCollection(collection_id string) error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavePost(t *testing.T) {
+	repo := NewInMemoryCollectionRepo()
+
+	assert.NoError(t, repo.SavePost("user1", "post1"))
+	assert.NoError(t, repo.SavePost("user1", "post2"))
+	assert.NoError(t, repo.SavePost("user1", "post1"))
+
+	saved, err := repo.GetSavedPosts("user1")
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+	assert.Equal(t, "post2", saved[0].PostId)
+
+	saved, _ = repo.GetSavedPosts("user2")
+	assert.Empty(t, saved)
+}
+
+func TestCollections(t *testing.T) {
+	repo := NewInMemoryCollectionRepo()
+
+	first, err := repo.CreateCollection(models.CollectionDTO{OwnerId: "user1", Name: "Recipes", PostIds: []string{"post1", "post2"}})
+	assert.NoError(t, err)
+	second, _ := repo.CreateCollection(models.CollectionDTO{OwnerId: "user1", Name: "Travel"})
+
+	collection, err := repo.GetCollection(first)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, collection.PostCount)
+	assert.Equal(t, 0, collection.Position)
+
+	collection.Name = "Dinner"
+	collection.PostIds = []string{"post2", "post1"}
+	assert.NoError(t, repo.UpdateCollection(collection))
+
+	collection, _ = repo.GetCollection(first)
+	assert.Equal(t, "Dinner", collection.Name)
+	assert.Equal(t, []string{"post2", "post1"}, collection.PostIds)
+
+	// Unsaving a post takes it out of the collections too
+	_ = repo.SavePost("user1", "post1")
+	assert.NoError(t, repo.UnsavePost("user1", "post1"))
+
+	collection, _ = repo.GetCollection(first)
+	assert.Equal(t, []string{"post2"}, collection.PostIds)
+
+	assert.EqualError(t, repo.ReorderCollections("user1", []string{second}), "invalid order")
+	assert.NoError(t, repo.ReorderCollections("user1", []string{second, first}))
+
+	collections, err := repo.GetCollections("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, second, collections[0].Id)
+	assert.Equal(t, 1, collections[1].Position)
+
+	assert.NoError(t, repo.DeleteCollection(second))
+	assert.EqualError(t, repo.DeleteCollection(second), "collection not found")
+
+	_, err = repo.GetCollection(second)
+	assert.EqualError(t, err, "collection not found")
+
+	collections, _ = repo.GetCollections("user1")
+	assert.Len(t, collections, 1)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// jsonFile keeps a snapshot of a store on disk as a JSON document
+type jsonFile struct {
+	path string
+}
+
+// load reads the snapshot into v. A missing file leaves v untouched.
+func (f jsonFile) load(v any) error {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// save writes v to a temporary file and renames it over the snapshot, so a
+// crash never leaves a half-written file behind
+func (f jsonFile) save(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// jsonLog keeps the history of a store on disk as an append-only log of
+// JSON records, one per line, so a write costs the size of the change rather
+// than the size of the store
+type jsonLog struct {
+	path string
+}
+
+// load calls read with every record in the log, oldest first. A missing file
+// is an empty log, and a last record cut short by a crash is dropped.
+func (l jsonLog) load(read func(record []byte) error) error {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end < len(data) {
+		if err := os.Truncate(l.path, int64(end)); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range bytes.Split(data[:end], []byte{'\n'}) {
+		if len(record) == 0 {
+			continue
+		}
+		if err := read(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append adds v to the end of the log. A failed write is cut back off, so
+// the log only ever holds whole records.
+func (l jsonLog) append(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Truncate(info.Size())
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Truncate(info.Size())
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
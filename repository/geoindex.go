@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/anandh86/instagram/geo"
+)
+
+// geoEntry files an item under the geohash of its position
+type geoEntry struct {
+	hash string
+	id   string
+}
+
+// geoIndex finds the items near a point. Entries are kept sorted by the
+// geohash of their position, so the items in a cell of any size are a run
+// of entries found with a binary search on the geohash of the cell.
+type geoIndex struct {
+	entries []geoEntry
+	points  map[string]geo.Point
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{points: make(map[string]geo.Point)}
+}
+
+// set moves an item to a position, adding it if it is new
+func (ix *geoIndex) set(id string, p geo.Point) {
+	ix.remove(id)
+
+	e := geoEntry{hash: geo.Encode(p, geo.MaxPrecision), id: id}
+	pos, _ := slices.BinarySearchFunc(ix.entries, e, compareGeoEntries)
+	ix.entries = slices.Insert(ix.entries, pos, e)
+	ix.points[id] = p
+}
+
+// remove drops an item. Returns false if it was not indexed.
+func (ix *geoIndex) remove(id string) bool {
+	p, exists := ix.points[id]
+	if !exists {
+		return false
+	}
+
+	e := geoEntry{hash: geo.Encode(p, geo.MaxPrecision), id: id}
+	if pos, found := slices.BinarySearchFunc(ix.entries, e, compareGeoEntries); found {
+		ix.entries = slices.Delete(ix.entries, pos, pos+1)
+	}
+	delete(ix.points, id)
+	return true
+}
+
+// near calls fn with each item within radiusKm of center, along with its
+// distance in kilometers, in no particular order
+func (ix *geoIndex) near(center geo.Point, radiusKm float64, fn func(id string, distanceKm float64)) {
+	for _, cell := range geo.Cover(center, radiusKm) {
+		start := sort.Search(len(ix.entries), func(i int) bool {
+			return ix.entries[i].hash >= cell
+		})
+
+		for i := start; i < len(ix.entries) && strings.HasPrefix(ix.entries[i].hash, cell); i++ {
+			id := ix.entries[i].id
+			if distance := geo.Distance(center, ix.points[id]); distance <= radiusKm {
+				fn(id, distance)
+			}
+		}
+	}
+}
+
+func compareGeoEntries(a, b geoEntry) int {
+	if c := strings.Compare(a.hash, b.hash); c != 0 {
+		return c
+	}
+	return strings.Compare(a.id, b.id)
+}
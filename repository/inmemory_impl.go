@@ -4,18 +4,37 @@ import (
 	"errors"
 	"image"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
 	"github.com/google/uuid"
 )
 
 // InMemoryRepo is an in-memory implementation of IRepository
 type InMemoryRepo struct {
+	mu              sync.RWMutex
 	images          map[string]image.Image
 	posts           map[string]models.PostMetaDTO
 	comments        map[string]models.CommentDTO
 	postCommentsMap map[string][]string
+	revisions       map[string][]models.CommentRevisionDTO
+
+	// Both directions of the follow graph, by user id
+	following map[string]*adjacency
+	followers map[string]*adjacency
+
+	// Requests to follow private users, by target then requester id
+	followRequests map[string]map[string]models.FollowRequestDTO
+
+	// Blocks in both directions and mutes, by user id
+	blocks    map[string]map[string]time.Time
+	blockedBy map[string]map[string]time.Time
+	mutes     map[string]map[string]time.Time
+
+	// Close friends lists, by the id of the user keeping the list
+	closeFriends map[string]map[string]time.Time
 }
 
 // NewInMemoryRepo creates a new instance of InMemoryRepo
@@ -29,6 +48,14 @@ func NewInMemoryRepo() *InMemoryRepo {
 		posts:           make(map[string]models.PostMetaDTO),
 		comments:        make(map[string]models.CommentDTO),
 		postCommentsMap: make(map[string][]string),
+		revisions:       make(map[string][]models.CommentRevisionDTO),
+		following:       make(map[string]*adjacency),
+		followers:       make(map[string]*adjacency),
+		followRequests:  make(map[string]map[string]models.FollowRequestDTO),
+		blocks:          make(map[string]map[string]time.Time),
+		blockedBy:       make(map[string]map[string]time.Time),
+		mutes:           make(map[string]map[string]time.Time),
+		closeFriends:    make(map[string]map[string]time.Time),
 	}
 }
 
@@ -37,6 +64,9 @@ func NewInMemoryRepo() *InMemoryRepo {
 ------------------------------------------------------------------------*/
 // SaveImage saves an image to the in-memory database
 func (repo *InMemoryRepo) SaveImage(img image.Image) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	imgID := uuid.New().String()
 	repo.images[imgID] = img
 	return imgID, nil
@@ -44,6 +74,9 @@ func (repo *InMemoryRepo) SaveImage(img image.Image) (string, error) {
 
 // GetImageByID retrieves an image by its ID
 func (repo *InMemoryRepo) GetImageByID(imgID string) (image.Image, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	img, exists := repo.images[imgID]
 	if !exists {
 		return nil, errors.New("image not found")
@@ -51,11 +84,26 @@ func (repo *InMemoryRepo) GetImageByID(imgID string) (image.Image, error) {
 	return img, nil
 }
 
+// DeleteImageByID removes an image
+func (repo *InMemoryRepo) DeleteImageByID(imgID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.images[imgID]; !exists {
+		return errors.New("image not found")
+	}
+	delete(repo.images, imgID)
+	return nil
+}
+
 /*------------------------------------------------------------------------
 *                             Post
 ------------------------------------------------------------------------*/
 // SavePostMeta saves a post's metadata to the in-memory database
 func (repo *InMemoryRepo) SavePostMeta(postMeta models.PostMetaDTO) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	postID := uuid.New().String()
 	postMeta.Id = postID
 	repo.posts[postID] = postMeta
@@ -64,6 +112,9 @@ func (repo *InMemoryRepo) SavePostMeta(postMeta models.PostMetaDTO) (string, err
 
 // GetPostMetaByID retrieves a post's metadata by its ID
 func (repo *InMemoryRepo) GetPostMetaByID(postID string) (models.PostMetaDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	postMeta, exists := repo.posts[postID]
 	if !exists {
 		return models.PostMetaDTO{}, errors.New("post metadata not found")
@@ -71,25 +122,271 @@ func (repo *InMemoryRepo) GetPostMetaByID(postID string) (models.PostMetaDTO, er
 	return postMeta, nil
 }
 
+// GetAllPostMetas retrieves the metadata of all published posts that are not
+// in the trash
 func (repo *InMemoryRepo) GetAllPostMetas() ([]models.PostMetaDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	posts := make([]models.PostMetaDTO, 0, len(repo.posts))
 	for _, post := range repo.posts {
+		if !isLive(post) {
+			continue
+		}
 		posts = append(posts, post)
 	}
 	return posts, nil
 }
 
+// CountPostsByCreator counts the published posts of a user that are not in
+// the trash
+func (repo *InMemoryRepo) CountPostsByCreator(creatorID string) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	count := 0
+	for _, post := range repo.posts {
+		if post.Creator == creatorID && isLive(post) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetPostMetasByCreator reads a page of the published posts of a user that
+// are not in the trash, newest first
+func (repo *InMemoryRepo) GetPostMetasByCreator(creatorID string, cursor string, limit int) ([]models.PostMetaDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var posts []models.PostMetaDTO
+	for _, post := range repo.posts {
+		if post.Creator == creatorID && isLive(post) {
+			posts = append(posts, post)
+		}
+	}
+
+	pagination.Sort(posts, true, postCursor)
+	return pagination.Paginate(posts, cursor, limit, true, postCursor)
+}
+
+// GetUnpublishedPostMetas retrieves the drafts and scheduled posts of a user
+// that are not in the trash, newest first
+func (repo *InMemoryRepo) GetUnpublishedPostMetas(creatorID string) ([]models.PostMetaDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	posts := []models.PostMetaDTO{}
+	for _, post := range repo.posts {
+		if post.Creator == creatorID && post.DeletedAt == nil && !post.Published() {
+			posts = append(posts, post)
+		}
+	}
+
+	pagination.Sort(posts, true, postCursor)
+	return posts, nil
+}
+
+// GetDueScheduledPosts retrieves the scheduled posts that are not in the
+// trash and due by the given time
+func (repo *InMemoryRepo) GetDueScheduledPosts(at time.Time) ([]models.PostMetaDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var posts []models.PostMetaDTO
+	for _, post := range repo.posts {
+		if post.Status == models.PostStatusScheduled && post.DeletedAt == nil && !post.PublishAt.After(at) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// isLive tells whether a post is published and not in the trash
+func isLive(post models.PostMetaDTO) bool {
+	return post.Published() && post.DeletedAt == nil
+}
+
+// postCursor is the pagination key of a post
+func postCursor(post models.PostMetaDTO) pagination.Cursor {
+	return pagination.Cursor{Time: post.CreatedAt, Id: post.Id}
+}
+
+// SetPostCaption changes the caption of a post and its mentions
+func (repo *InMemoryRepo) SetPostCaption(postID, caption string, mentions []models.MentionDTO) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, err := repo.editablePost(postID)
+	if err != nil {
+		return err
+	}
+
+	postMeta.Caption = caption
+	postMeta.Mentions = mentions
+	repo.posts[postID] = postMeta
+	return nil
+}
+
+// SetPostSettings changes the comment policy and visibility of a post
+func (repo *InMemoryRepo) SetPostSettings(postID, commentPolicy, visibility string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, err := repo.editablePost(postID)
+	if err != nil {
+		return err
+	}
+
+	if commentPolicy != "" {
+		postMeta.CommentPolicy = commentPolicy
+	}
+	if visibility != "" {
+		postMeta.Visibility = visibility
+	}
+	repo.posts[postID] = postMeta
+	return nil
+}
+
+// editablePost returns a post that is not in the trash. The caller must
+// hold the write lock.
+func (repo *InMemoryRepo) editablePost(postID string) (models.PostMetaDTO, error) {
+	postMeta, exists := repo.posts[postID]
+	if !exists {
+		return models.PostMetaDTO{}, errors.New("post metadata not found")
+	}
+	if postMeta.DeletedAt != nil {
+		return models.PostMetaDTO{}, errors.New("post in trash")
+	}
+	return postMeta, nil
+}
+
+// SchedulePost sets the publish time of a draft or scheduled post, or makes
+// it a draft again when publishAt is nil
+func (repo *InMemoryRepo) SchedulePost(postID string, publishAt *time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, exists := repo.posts[postID]
+	if !exists {
+		return errors.New("post metadata not found")
+	}
+	if postMeta.Published() {
+		return errors.New("post already published")
+	}
+
+	postMeta.Status = models.PostStatusDraft
+	postMeta.PublishAt = nil
+	if publishAt != nil {
+		at := *publishAt
+		postMeta.Status = models.PostStatusScheduled
+		postMeta.PublishAt = &at
+	}
+	repo.posts[postID] = postMeta
+	return nil
+}
+
+// PublishPost publishes a draft or scheduled post that is not in the trash,
+// dating it to the time it was published
+func (repo *InMemoryRepo) PublishPost(postID string, at time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, exists := repo.posts[postID]
+	if !exists {
+		return false, errors.New("post metadata not found")
+	}
+	if postMeta.Published() || postMeta.DeletedAt != nil {
+		return false, nil
+	}
+
+	postMeta.Status = models.PostStatusPublished
+	postMeta.PublishAt = nil
+	postMeta.CreatedAt = at
+	postMeta.Unannounced = true
+	repo.posts[postID] = postMeta
+	return true, nil
+}
+
+// GetUnannouncedPosts retrieves the published posts that are not in the
+// trash and were left unannounced since before the given time
+func (repo *InMemoryRepo) GetUnannouncedPosts(before time.Time) ([]models.PostMetaDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var posts []models.PostMetaDTO
+	for _, post := range repo.posts {
+		if post.Unannounced && isLive(post) && post.CreatedAt.Before(before) {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// MarkPostAnnounced clears the unannounced marker of a post
+func (repo *InMemoryRepo) MarkPostAnnounced(postID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, exists := repo.posts[postID]
+	if !exists {
+		return errors.New("post metadata not found")
+	}
+
+	postMeta.Unannounced = false
+	repo.posts[postID] = postMeta
+	return nil
+}
+
+// DeletePostByID moves a post to the trash
+func (repo *InMemoryRepo) DeletePostByID(postID string, deletedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, exists := repo.posts[postID]
+	if !exists || postMeta.DeletedAt != nil {
+		return errors.New("post metadata not found")
+	}
+
+	postMeta.DeletedAt = &deletedAt
+	repo.posts[postID] = postMeta
+	return nil
+}
+
+// RestorePostByID brings a post back from the trash
+func (repo *InMemoryRepo) RestorePostByID(postID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postMeta, exists := repo.posts[postID]
+	if !exists {
+		return errors.New("post metadata not found")
+	}
+	if postMeta.DeletedAt == nil {
+		return errors.New("post not deleted")
+	}
+
+	postMeta.DeletedAt = nil
+	repo.posts[postID] = postMeta
+	return nil
+}
+
 /*------------------------------------------------------------------------
 *                             Comment
 ------------------------------------------------------------------------*/
 // SaveComment saves a comment to the in-memory database
-func (repo *InMemoryRepo) SaveComment(reqComment models.CommentRequestDTO) (string, error) {
+func (repo *InMemoryRepo) SaveComment(reqComment models.CommentRequestDTO, createdAt time.Time) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	comment := models.CommentDTO{
 		Id:        uuid.New().String(),
 		Content:   reqComment.Comment,
 		PostId:    reqComment.PostId,
+		ParentId:  reqComment.ParentId,
 		Creator:   reqComment.AuthorId,
-		CreatedAt: time.Now(),
+		Mentions:  reqComment.Mentions,
+		CreatedAt: createdAt,
 	}
 
 	repo.appendPostCommentsMap(reqComment.PostId, comment.Id)
@@ -100,6 +397,9 @@ func (repo *InMemoryRepo) SaveComment(reqComment models.CommentRequestDTO) (stri
 
 // GetCommentByID retrieves a comment by its ID
 func (repo *InMemoryRepo) GetCommentByID(commentID string) (models.CommentDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
 	comment, exists := repo.comments[commentID]
 	if !exists {
 		return models.CommentDTO{}, errors.New("comment not found")
@@ -107,21 +407,125 @@ func (repo *InMemoryRepo) GetCommentByID(commentID string) (models.CommentDTO, e
 	return comment, nil
 }
 
-// DeleteCommentByID deletes a comment by its ID
-func (repo *InMemoryRepo) DeleteCommentByID(commentID string) error {
+// DeleteCommentByID moves a comment to the trash, recording who deleted it
+func (repo *InMemoryRepo) DeleteCommentByID(commentID string, deletedBy string, deletedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	db_comment, exists := repo.comments[commentID]
+
+	if !exists || db_comment.DeletedAt != nil {
+		return errors.New("comment not found")
+	}
+
+	db_comment.DeletedAt = &deletedAt
+	db_comment.DeletedBy = deletedBy
+	repo.comments[commentID] = db_comment
+
+	return nil
+}
+
+// UpdateComment replaces the content of a comment, keeping the previous
+// version in its revision history
+func (repo *InMemoryRepo) UpdateComment(commentID string, content string, mentions []models.MentionDTO, editedAt time.Time) (models.CommentDTO, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	db_comment, exists := repo.comments[commentID]
+
+	if !exists || db_comment.DeletedAt != nil {
+		return models.CommentDTO{}, errors.New("comment not found")
+	}
+
+	writtenAt := db_comment.CreatedAt
+	if db_comment.EditedAt != nil {
+		writtenAt = *db_comment.EditedAt
+	}
+
+	repo.revisions[commentID] = append(repo.revisions[commentID], models.CommentRevisionDTO{
+		CommentId:  commentID,
+		Content:    db_comment.Content,
+		WrittenAt:  writtenAt,
+		ReplacedAt: editedAt,
+	})
+
+	db_comment.Content = content
+	db_comment.Mentions = mentions
+	db_comment.EditedAt = &editedAt
+	repo.comments[commentID] = db_comment
+
+	return db_comment, nil
+}
+
+// SetCommentHidden hides a comment from the post, or shows it again when
+// hiddenAt is nil
+func (repo *InMemoryRepo) SetCommentHidden(commentID string, hiddenAt *time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	db_comment, exists := repo.comments[commentID]
+
+	if !exists || db_comment.DeletedAt != nil {
+		return errors.New("comment not found")
+	}
+
+	db_comment.HiddenAt = nil
+	if hiddenAt != nil {
+		at := *hiddenAt
+		db_comment.HiddenAt = &at
+	}
+	repo.comments[commentID] = db_comment
+
+	return nil
+}
+
+// GetCommentRevisions retrieves the previous versions of a comment, oldest first
+func (repo *InMemoryRepo) GetCommentRevisions(commentID string) ([]models.CommentRevisionDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if _, exists := repo.comments[commentID]; !exists {
+		return nil, errors.New("comment not found")
+	}
+
+	revisions := make([]models.CommentRevisionDTO, len(repo.revisions[commentID]))
+	copy(revisions, repo.revisions[commentID])
+
+	return revisions, nil
+}
+
+// RestoreCommentByID brings a comment back from the trash
+func (repo *InMemoryRepo) RestoreCommentByID(commentID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
 	db_comment, exists := repo.comments[commentID]
 
 	if !exists {
 		return errors.New("comment not found")
 	}
+	if db_comment.DeletedAt == nil {
+		return errors.New("comment not deleted")
+	}
 
-	delete(repo.comments, commentID)
-	repo.removePostCommentsMap(db_comment.PostId, commentID)
+	db_comment.DeletedAt = nil
+	db_comment.DeletedBy = ""
+	repo.comments[commentID] = db_comment
 
 	return nil
 }
 
-func (repo *InMemoryRepo) GetPostLatestComments(post_id string, numberOfComments int) ([]models.CommentDTO, error) {
+// GetPostLatestComments retrieves the latest comments of a post, skipping
+// the ones in the trash or hidden by the post author
+func (repo *InMemoryRepo) GetPostLatestComments(post_id string, numberOfComments int, excluded_authors []string) ([]models.CommentDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	excluded := make(map[string]bool, len(excluded_authors))
+	for _, author := range excluded_authors {
+		excluded[author] = true
+	}
+
 	postComments, exists := repo.postCommentsMap[post_id]
 	if !exists {
 		return nil, errors.New("post not found")
@@ -130,9 +534,13 @@ func (repo *InMemoryRepo) GetPostLatestComments(post_id string, numberOfComments
 	var comments []models.CommentDTO
 
 	for _, commentId := range postComments {
-		comment, err := repo.GetCommentByID(commentId)
-		if err != nil {
-			return nil, err
+		comment, exists := repo.comments[commentId]
+		if !exists {
+			return nil, errors.New("comment not found")
+		}
+
+		if comment.DeletedAt != nil || comment.HiddenAt != nil || excluded[comment.Creator] {
+			continue
 		}
 
 		comments = append(comments, comment)
@@ -151,6 +559,369 @@ func (repo *InMemoryRepo) GetPostLatestComments(post_id string, numberOfComments
 	return comments, nil
 }
 
+// GetPostComments retrieves every comment of a post, including the ones in
+// the trash, oldest first
+func (repo *InMemoryRepo) GetPostComments(post_id string) ([]models.CommentDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	postComments := repo.postCommentsMap[post_id]
+	comments := make([]models.CommentDTO, 0, len(postComments))
+
+	for _, commentId := range postComments {
+		comment, exists := repo.comments[commentId]
+		if !exists {
+			return nil, errors.New("comment not found")
+		}
+
+		comments = append(comments, comment)
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+
+	return comments, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Social graph
+------------------------------------------------------------------------*/
+// FollowUser makes follower_id follow followee_id
+func (repo *InMemoryRepo) FollowUser(follower_id string, followee_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+
+	if adjacencyOf(repo.following, follower_id, true).add(followee_id, now) {
+		adjacencyOf(repo.followers, followee_id, true).add(follower_id, now)
+	}
+	return nil
+}
+
+// UnfollowUser removes the follow relationship, if any
+func (repo *InMemoryRepo) UnfollowUser(follower_id string, followee_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if adjacencyOf(repo.following, follower_id, false).remove(followee_id) {
+		adjacencyOf(repo.followers, followee_id, false).remove(follower_id)
+	}
+	return nil
+}
+
+// IsFollowing tells whether follower_id follows followee_id
+func (repo *InMemoryRepo) IsFollowing(follower_id string, followee_id string) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return adjacencyOf(repo.following, follower_id, false).has(followee_id), nil
+}
+
+// GetFollowers reads a page of the followers of a user, most recent first
+func (repo *InMemoryRepo) GetFollowers(user_id string, cursor string, limit int) ([]models.FollowDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return followPage(adjacencyOf(repo.followers, user_id, false), cursor, limit)
+}
+
+// GetFollowing reads a page of the users a user follows, most recent first
+func (repo *InMemoryRepo) GetFollowing(user_id string, cursor string, limit int) ([]models.FollowDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return followPage(adjacencyOf(repo.following, user_id, false), cursor, limit)
+}
+
+// CountFollows counts the followers of a user and the users it follows
+func (repo *InMemoryRepo) CountFollows(user_id string) (int, int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return adjacencyOf(repo.followers, user_id, false).len(), adjacencyOf(repo.following, user_id, false).len(), nil
+}
+
+// SaveFollowRequest stores a follow request, replacing any previous one
+// between the same users
+func (repo *InMemoryRepo) SaveFollowRequest(request models.FollowRequestDTO) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	requests, exists := repo.followRequests[request.TargetId]
+	if !exists {
+		requests = make(map[string]models.FollowRequestDTO)
+		repo.followRequests[request.TargetId] = requests
+	}
+
+	requests[request.RequesterId] = request
+	return nil
+}
+
+// GetFollowRequest retrieves the request of requester_id to follow target_id
+func (repo *InMemoryRepo) GetFollowRequest(requester_id string, target_id string) (models.FollowRequestDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	request, exists := repo.followRequests[target_id][requester_id]
+	if !exists {
+		return models.FollowRequestDTO{}, errors.New("follow request not found")
+	}
+	return request, nil
+}
+
+// DeleteFollowRequest removes the request of requester_id to follow target_id
+func (repo *InMemoryRepo) DeleteFollowRequest(requester_id string, target_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.followRequests[target_id], requester_id)
+	return nil
+}
+
+// GetPendingFollowRequests reads a page of the pending requests to follow a
+// user, most recent first
+func (repo *InMemoryRepo) GetPendingFollowRequests(target_id string, cursor string, limit int) ([]models.FollowRequestDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var pending []models.FollowRequestDTO
+	for _, request := range repo.followRequests[target_id] {
+		if request.Status == models.FollowRequestPending {
+			pending = append(pending, request)
+		}
+	}
+
+	pagination.Sort(pending, true, followRequestCursor)
+	return pagination.Paginate(pending, cursor, limit, true, followRequestCursor)
+}
+
+// followRequestCursor is the pagination key of a follow request
+func followRequestCursor(request models.FollowRequestDTO) pagination.Cursor {
+	return pagination.Cursor{Time: request.CreatedAt, Id: request.RequesterId}
+}
+
+// BlockUser makes blocker_id block blocked_id
+func (repo *InMemoryRepo) BlockUser(blocker_id string, blocked_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.blocks[blocker_id][blocked_id]; exists {
+		return nil
+	}
+
+	now := time.Now()
+	relationOf(repo.blocks, blocker_id)[blocked_id] = now
+	relationOf(repo.blockedBy, blocked_id)[blocker_id] = now
+	return nil
+}
+
+// UnblockUser lifts the block of blocker_id on blocked_id, if any
+func (repo *InMemoryRepo) UnblockUser(blocker_id string, blocked_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.blocks[blocker_id], blocked_id)
+	delete(repo.blockedBy[blocked_id], blocker_id)
+	return nil
+}
+
+// IsBlocked tells whether blocker_id blocks blocked_id
+func (repo *InMemoryRepo) IsBlocked(blocker_id string, blocked_id string) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	_, exists := repo.blocks[blocker_id][blocked_id]
+	return exists, nil
+}
+
+// GetBlocks lists the users a user blocks and the users blocking them
+func (repo *InMemoryRepo) GetBlocks(user_id string) ([]string, []string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return newestFirst(repo.blocks[user_id]), newestFirst(repo.blockedBy[user_id]), nil
+}
+
+// MuteUser makes muter_id mute muted_id
+func (repo *InMemoryRepo) MuteUser(muter_id string, muted_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	muted := relationOf(repo.mutes, muter_id)
+	if _, exists := muted[muted_id]; !exists {
+		muted[muted_id] = time.Now()
+	}
+	return nil
+}
+
+// UnmuteUser makes muter_id stop muting muted_id, if they did
+func (repo *InMemoryRepo) UnmuteUser(muter_id string, muted_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.mutes[muter_id], muted_id)
+	return nil
+}
+
+// GetMutedUsers lists the users a user mutes
+func (repo *InMemoryRepo) GetMutedUsers(muter_id string) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return newestFirst(repo.mutes[muter_id]), nil
+}
+
+// AddCloseFriend puts friend_id on the close friends list of user_id
+func (repo *InMemoryRepo) AddCloseFriend(user_id string, friend_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	friends := relationOf(repo.closeFriends, user_id)
+	if _, exists := friends[friend_id]; !exists {
+		friends[friend_id] = time.Now()
+	}
+	return nil
+}
+
+// RemoveCloseFriend takes friend_id off the close friends list of user_id
+func (repo *InMemoryRepo) RemoveCloseFriend(user_id string, friend_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.closeFriends[user_id], friend_id)
+	return nil
+}
+
+// IsCloseFriend tells whether friend_id is on the close friends list of user_id
+func (repo *InMemoryRepo) IsCloseFriend(user_id string, friend_id string) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	_, exists := repo.closeFriends[user_id][friend_id]
+	return exists, nil
+}
+
+// GetCloseFriends lists the close friends of a user
+func (repo *InMemoryRepo) GetCloseFriends(user_id string) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return newestFirst(repo.closeFriends[user_id]), nil
+}
+
+// relationOf returns the users a user relates to, creating the set if needed
+func relationOf(relations map[string]map[string]time.Time, user_id string) map[string]time.Time {
+	related, exists := relations[user_id]
+	if !exists {
+		related = make(map[string]time.Time)
+		relations[user_id] = related
+	}
+	return related
+}
+
+// newestFirst lists the users of a relation, most recently added first
+func newestFirst(related map[string]time.Time) []string {
+	ids := make([]string, 0, len(related))
+	for id := range related {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if !related[ids[i]].Equal(related[ids[j]]) {
+			return related[ids[i]].After(related[ids[j]])
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// adjacencyOf returns the adjacency list of a user. Missing lists are created
+// when create is set; otherwise an empty list is returned in their place.
+func adjacencyOf(graph map[string]*adjacency, user_id string, create bool) *adjacency {
+	adj, exists := graph[user_id]
+	if !exists {
+		adj = newAdjacency()
+		if create {
+			graph[user_id] = adj
+		}
+	}
+	return adj
+}
+
+func followPage(adj *adjacency, cursor string, limit int) ([]models.FollowDTO, string, error) {
+	edges, next, err := adj.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	follows := make([]models.FollowDTO, 0, len(edges))
+	for _, e := range edges {
+		follows = append(follows, models.FollowDTO{UserId: e.userID, CreatedAt: e.createdAt})
+	}
+	return follows, next, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Trash
+------------------------------------------------------------------------*/
+// PurgeDeletedBefore permanently removes the posts and comments that were
+// moved to the trash before the cutoff. Purging a post also removes its
+// image and all of its comments.
+func (repo *InMemoryRepo) PurgeDeletedBefore(cutoff time.Time) ([]string, []string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var postIDs, commentIDs []string
+
+	for postID, postMeta := range repo.posts {
+		if postMeta.DeletedAt == nil || !postMeta.DeletedAt.Before(cutoff) {
+			continue
+		}
+
+		for _, commentID := range repo.postCommentsMap[postID] {
+			delete(repo.comments, commentID)
+			delete(repo.revisions, commentID)
+			commentIDs = append(commentIDs, commentID)
+		}
+		delete(repo.postCommentsMap, postID)
+		delete(repo.images, postMeta.ImageId)
+		delete(repo.posts, postID)
+		postIDs = append(postIDs, postID)
+	}
+
+	// A trashed comment that still has replies is kept as the parent of its
+	// thread; it becomes purgeable once its replies are gone
+	for {
+		parents := make(map[string]bool)
+		for _, comment := range repo.comments {
+			if comment.ParentId != "" {
+				parents[comment.ParentId] = true
+			}
+		}
+
+		progress := false
+		for commentID, comment := range repo.comments {
+			if comment.DeletedAt == nil || !comment.DeletedAt.Before(cutoff) || parents[commentID] {
+				continue
+			}
+
+			delete(repo.comments, commentID)
+			delete(repo.revisions, commentID)
+			repo.removePostCommentsMap(comment.PostId, commentID)
+			commentIDs = append(commentIDs, commentID)
+			progress = true
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	return postIDs, commentIDs, nil
+}
+
 /*------------------------------------------------------------------------
 *                             Private functions
 ------------------------------------------------------------------------*/
@@ -32,6 +32,17 @@ func TestGetImageByID_NotFound(t *testing.T) {
 	assert.EqualError(t, err, "image not found")
 }
 
+func TestDeleteImageByID(t *testing.T) {
+	repo := NewInMemoryRepo()
+	imgID, _ := repo.SaveImage(image.NewRGBA(image.Rect(0, 0, 10, 10)))
+
+	assert.NoError(t, repo.DeleteImageByID(imgID))
+
+	_, err := repo.GetImageByID(imgID)
+	assert.EqualError(t, err, "image not found")
+	assert.EqualError(t, repo.DeleteImageByID(imgID), "image not found")
+}
+
 func TestSavePostMeta(t *testing.T) {
 	repo := NewInMemoryRepo()
 	postMeta := models.PostMetaDTO{
@@ -84,6 +95,119 @@ func TestGetAllPostMetas(t *testing.T) {
 	assert.Len(t, allPosts, 2)
 }
 
+func TestCountPostsByCreator(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	_, _ = repo.SavePostMeta(models.PostMetaDTO{Creator: "user1"})
+	trashedID, _ := repo.SavePostMeta(models.PostMetaDTO{Creator: "user1"})
+	_, _ = repo.SavePostMeta(models.PostMetaDTO{Creator: "user2"})
+	_ = repo.DeletePostByID(trashedID, time.Now())
+
+	count, err := repo.CountPostsByCreator("user1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestGetPostMetasByCreator(t *testing.T) {
+	repo := NewInMemoryRepo()
+	start := time.Now()
+
+	_, _ = repo.SavePostMeta(models.PostMetaDTO{Caption: "old", Creator: "user1", CreatedAt: start})
+	_, _ = repo.SavePostMeta(models.PostMetaDTO{Caption: "new", Creator: "user1", CreatedAt: start.Add(time.Minute)})
+	_, _ = repo.SavePostMeta(models.PostMetaDTO{Caption: "other", Creator: "user2", CreatedAt: start})
+
+	posts, next, err := repo.GetPostMetasByCreator("user1", "", 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "new", posts[0].Caption)
+
+	posts, next, err = repo.GetPostMetasByCreator("user1", next, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "old", posts[0].Caption)
+	assert.Empty(t, next)
+}
+
+func TestUnpublishedPosts(t *testing.T) {
+	repo := NewInMemoryRepo()
+	start := time.Now()
+	later := start.Add(time.Hour)
+
+	live, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "live", Creator: "user1", CreatedAt: start, Status: models.PostStatusPublished})
+	draft, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "draft", Creator: "user1", CreatedAt: start, Status: models.PostStatusDraft})
+	scheduled, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "scheduled", Creator: "user1", CreatedAt: start.Add(time.Minute), Status: models.PostStatusScheduled, PublishAt: &later})
+
+	// Only published posts are listed and counted
+	posts, _ := repo.GetAllPostMetas()
+	assert.Len(t, posts, 1)
+	posts, _, _ = repo.GetPostMetasByCreator("user1", "", 10)
+	assert.Len(t, posts, 1)
+	count, _ := repo.CountPostsByCreator("user1")
+	assert.Equal(t, 1, count)
+
+	posts, err := repo.GetUnpublishedPostMetas("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "scheduled", posts[0].Caption)
+	assert.Equal(t, "draft", posts[1].Caption)
+
+	posts, _ = repo.GetDueScheduledPosts(start)
+	assert.Empty(t, posts)
+	posts, _ = repo.GetDueScheduledPosts(later)
+	assert.Equal(t, scheduled, posts[0].Id)
+
+	// Editing a post keeps its publication state
+	assert.NoError(t, repo.SetPostCaption(draft, "edited", nil))
+	post, _ := repo.GetPostMetaByID(draft)
+	assert.Equal(t, "edited", post.Caption)
+	assert.Equal(t, models.PostStatusDraft, post.Status)
+	assert.Equal(t, start, post.CreatedAt)
+
+	assert.NoError(t, repo.SchedulePost(draft, &later))
+	post, _ = repo.GetPostMetaByID(draft)
+	assert.Equal(t, models.PostStatusScheduled, post.Status)
+	assert.Equal(t, later, *post.PublishAt)
+
+	assert.NoError(t, repo.SchedulePost(draft, nil))
+	post, _ = repo.GetPostMetaByID(draft)
+	assert.Equal(t, models.PostStatusDraft, post.Status)
+	assert.Nil(t, post.PublishAt)
+
+	assert.EqualError(t, repo.SchedulePost(live, &later), "post already published")
+
+	// Posts are published once
+	published, err := repo.PublishPost(scheduled, later)
+	assert.NoError(t, err)
+	assert.True(t, published)
+
+	// and stay unannounced until they are marked
+	posts, _ = repo.GetUnannouncedPosts(later.Add(time.Second))
+	assert.Equal(t, scheduled, posts[0].Id)
+	posts, _ = repo.GetUnannouncedPosts(later)
+	assert.Empty(t, posts)
+
+	assert.NoError(t, repo.MarkPostAnnounced(scheduled))
+	posts, _ = repo.GetUnannouncedPosts(later.Add(time.Second))
+	assert.Empty(t, posts)
+
+	published, _ = repo.PublishPost(scheduled, later.Add(time.Minute))
+	assert.False(t, published)
+
+	post, _ = repo.GetPostMetaByID(scheduled)
+	assert.Equal(t, models.PostStatusPublished, post.Status)
+	assert.Equal(t, later, post.CreatedAt)
+	assert.Nil(t, post.PublishAt)
+
+	posts, _ = repo.GetDueScheduledPosts(later)
+	assert.Empty(t, posts)
+
+	// Trashed drafts are not published
+	assert.NoError(t, repo.DeletePostByID(draft, time.Now()))
+	published, _ = repo.PublishPost(draft, later)
+	assert.False(t, published)
+}
+
 func TestSaveComment(t *testing.T) {
 	repo := NewInMemoryRepo()
 	postMeta := models.PostMetaDTO{
@@ -100,7 +224,7 @@ func TestSaveComment(t *testing.T) {
 		AuthorId: "user456",
 	}
 
-	commentID, err := repo.SaveComment(commentReq)
+	commentID, err := repo.SaveComment(commentReq, time.Now())
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, commentID)
@@ -136,14 +260,119 @@ func TestDeleteCommentByID(t *testing.T) {
 		AuthorId: "user456",
 	}
 
-	commentID, _ := repo.SaveComment(commentReq)
+	commentID, _ := repo.SaveComment(commentReq, time.Now())
+
+	err := repo.DeleteCommentByID(commentID, "user456", time.Now())
+	assert.NoError(t, err)
+
+	// The comment is kept in the trash but hidden from the post
+	trashed, err := repo.GetCommentByID(commentID)
+	assert.NoError(t, err)
+	assert.NotNil(t, trashed.DeletedAt)
 
-	err := repo.DeleteCommentByID(commentID)
+	latestComments, err := repo.GetPostLatestComments(postID, 2, nil)
 	assert.NoError(t, err)
+	assert.Empty(t, latestComments)
+
+	err = repo.DeleteCommentByID(commentID, "user456", time.Now())
+	assert.EqualError(t, err, "comment not found")
+}
+
+func TestRestoreCommentByID(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post"})
+
+	commentID, _ := repo.SaveComment(models.CommentRequestDTO{
+		PostId:   postID,
+		Comment:  "Nice post!",
+		AuthorId: "user456",
+	}, time.Now())
+
+	assert.EqualError(t, repo.RestoreCommentByID(commentID), "comment not deleted")
 
+	_ = repo.DeleteCommentByID(commentID, "user456", time.Now())
+	assert.NoError(t, repo.RestoreCommentByID(commentID))
+
+	latestComments, err := repo.GetPostLatestComments(postID, 2, nil)
+	assert.NoError(t, err)
+	assert.Len(t, latestComments, 1)
+}
+
+func TestSetPostSettings(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post", CommentPolicy: models.CommentPolicyEveryone, Visibility: models.VisibilityPublic})
+
+	// Empty settings are left as they are
+	assert.NoError(t, repo.SetPostSettings(postID, models.CommentPolicyOff, ""))
+	post, _ := repo.GetPostMetaByID(postID)
+	assert.Equal(t, models.CommentPolicyOff, post.CommentPolicy)
+	assert.Equal(t, models.VisibilityPublic, post.Visibility)
+
+	// Posts in the trash stay as they were deleted
+	assert.NoError(t, repo.DeletePostByID(postID, time.Now()))
+	assert.EqualError(t, repo.SetPostSettings(postID, "", models.VisibilityOnlyMe), "post in trash")
+	assert.EqualError(t, repo.SetPostCaption(postID, "edited", nil), "post in trash")
+
+	post, _ = repo.GetPostMetaByID(postID)
+	assert.NotNil(t, post.DeletedAt)
+	assert.Equal(t, "Test Post", post.Caption)
+	assert.Equal(t, models.VisibilityPublic, post.Visibility)
+
+	assert.EqualError(t, repo.SetPostCaption("missing", "edited", nil), "post metadata not found")
+}
+
+func TestDeletePostByID(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post"})
+
+	err := repo.DeletePostByID(postID, time.Now())
+	assert.NoError(t, err)
+
+	allPosts, err := repo.GetAllPostMetas()
+	assert.NoError(t, err)
+	assert.Empty(t, allPosts)
+
+	assert.NoError(t, repo.RestorePostByID(postID))
+
+	allPosts, _ = repo.GetAllPostMetas()
+	assert.Len(t, allPosts, 1)
+}
+
+func TestPurgeDeletedBefore(t *testing.T) {
+	repo := NewInMemoryRepo()
+	imgID, _ := repo.SaveImage(image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post", ImageId: imgID})
+	keptPostID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Kept Post"})
+
+	commentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "On purged post"}, time.Now())
+	trashedCommentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: keptPostID, Comment: "Trashed"}, time.Now())
+	keptCommentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: keptPostID, Comment: "Kept"}, time.Now())
+
+	_ = repo.DeletePostByID(postID, time.Now())
+	_ = repo.DeleteCommentByID(trashedCommentID, "user456", time.Now())
+
+	// Nothing was deleted before this cutoff
+	postIDs, commentIDs, err := repo.PurgeDeletedBefore(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, postIDs)
+	assert.Empty(t, commentIDs)
+
+	postIDs, commentIDs, err = repo.PurgeDeletedBefore(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{postID}, postIDs)
+	assert.ElementsMatch(t, []string{commentID, trashedCommentID}, commentIDs)
+
+	_, err = repo.GetPostMetaByID(postID)
+	assert.Error(t, err)
+	_, err = repo.GetImageByID(imgID)
+	assert.Error(t, err)
 	_, err = repo.GetCommentByID(commentID)
 	assert.Error(t, err)
-	assert.EqualError(t, err, "comment not found")
+	_, err = repo.GetCommentByID(trashedCommentID)
+	assert.Error(t, err)
+
+	_, err = repo.GetCommentByID(keptCommentID)
+	assert.NoError(t, err)
 }
 
 func TestGetPostLatestComments(t *testing.T) {
@@ -168,13 +397,245 @@ func TestGetPostLatestComments(t *testing.T) {
 		AuthorId: "user2",
 	}
 
-	_, _ = repo.SaveComment(commentReq1)
+	_, _ = repo.SaveComment(commentReq1, time.Now())
 	time.Sleep(1 * time.Second) // Ensure different timestamps
-	_, _ = repo.SaveComment(commentReq2)
+	_, _ = repo.SaveComment(commentReq2, time.Now())
 
-	latestComments, err := repo.GetPostLatestComments(postID, 1)
+	latestComments, err := repo.GetPostLatestComments(postID, 1, nil)
 
 	assert.NoError(t, err)
 	assert.Len(t, latestComments, 1)
 	assert.Equal(t, "Second comment", latestComments[0].Content)
 }
+
+func TestPurgeDeletedBefore_KeepsParentOfLiveReplies(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post"})
+
+	parentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "Parent"}, time.Now())
+	replyID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: postID, ParentId: parentID, Comment: "Reply"}, time.Now())
+
+	_ = repo.DeleteCommentByID(parentID, "user456", time.Now())
+
+	_, commentIDs, err := repo.PurgeDeletedBefore(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, commentIDs)
+
+	// Once the reply is trashed as well, the whole thread goes
+	_ = repo.DeleteCommentByID(replyID, "user456", time.Now())
+
+	_, commentIDs, err = repo.PurgeDeletedBefore(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{parentID, replyID}, commentIDs)
+
+	comments, err := repo.GetPostComments(postID)
+	assert.NoError(t, err)
+	assert.Empty(t, comments)
+}
+
+func TestUpdateComment_KeepsRevisions(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post"})
+	commentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "Nice psot!"}, time.Now())
+
+	_, _ = repo.UpdateComment(commentID, "Nice post", nil, time.Now())
+	updated, err := repo.UpdateComment(commentID, "Nice post!", nil, time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Nice post!", updated.Content)
+	assert.NotNil(t, updated.EditedAt)
+
+	revisions, err := repo.GetCommentRevisions(commentID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, "Nice psot!", revisions[0].Content)
+	assert.Equal(t, "Nice post", revisions[1].Content)
+	assert.Equal(t, revisions[0].ReplacedAt, revisions[1].WrittenAt)
+}
+
+func TestSetCommentHidden(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Caption: "Test Post"})
+	commentID, _ := repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "Abusive"}, time.Now())
+
+	hiddenAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.SetCommentHidden(commentID, &hiddenAt))
+
+	comment, _ := repo.GetCommentByID(commentID)
+	assert.Equal(t, hiddenAt, *comment.HiddenAt)
+
+	latestComments, _ := repo.GetPostLatestComments(postID, 2, nil)
+	assert.Empty(t, latestComments)
+
+	assert.NoError(t, repo.SetCommentHidden(commentID, nil))
+
+	latestComments, _ = repo.GetPostLatestComments(postID, 2, nil)
+	assert.Len(t, latestComments, 1)
+}
+
+func TestFollowUser(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	assert.NoError(t, repo.FollowUser("user1", "user2"))
+
+	following, err := repo.IsFollowing("user1", "user2")
+	assert.NoError(t, err)
+	assert.True(t, following)
+
+	following, _ = repo.IsFollowing("user2", "user1")
+	assert.False(t, following)
+
+	assert.NoError(t, repo.UnfollowUser("user1", "user2"))
+
+	following, _ = repo.IsFollowing("user1", "user2")
+	assert.False(t, following)
+}
+
+func TestGetFollowers_Paginates(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	for _, follower := range []string{"user1", "user2", "user3", "user4"} {
+		_ = repo.FollowUser(follower, "star")
+	}
+	_ = repo.UnfollowUser("user3", "star")
+
+	page, next, err := repo.GetFollowers("star", "", 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "user4", page[0].UserId)
+	assert.Equal(t, "user2", page[1].UserId)
+	assert.NotEmpty(t, next)
+
+	page, next, err = repo.GetFollowers("star", next, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "user1", page[0].UserId)
+	assert.Empty(t, next)
+
+	following, _, _ := repo.GetFollowing("user1", "", 10)
+	assert.Len(t, following, 1)
+	assert.Equal(t, "star", following[0].UserId)
+}
+
+func TestCountFollows(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	_ = repo.FollowUser("user1", "user2")
+	_ = repo.FollowUser("user1", "user2")
+	_ = repo.FollowUser("user2", "user1")
+	_ = repo.FollowUser("user3", "user1")
+
+	followers, following, err := repo.CountFollows("user1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, followers)
+	assert.Equal(t, 1, following)
+
+	_ = repo.UnfollowUser("user3", "user1")
+
+	followers, _, _ = repo.CountFollows("user1")
+	assert.Equal(t, 1, followers)
+}
+
+func TestFollowRequests(t *testing.T) {
+	repo := NewInMemoryRepo()
+	now := time.Now()
+
+	_ = repo.SaveFollowRequest(models.FollowRequestDTO{RequesterId: "user1", TargetId: "private", Status: models.FollowRequestPending, CreatedAt: now})
+	_ = repo.SaveFollowRequest(models.FollowRequestDTO{RequesterId: "user2", TargetId: "private", Status: models.FollowRequestPending, CreatedAt: now.Add(time.Minute)})
+	_ = repo.SaveFollowRequest(models.FollowRequestDTO{RequesterId: "user3", TargetId: "private", Status: models.FollowRequestDenied, CreatedAt: now})
+
+	pending, next, err := repo.GetPendingFollowRequests("private", "", 10)
+
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, "user2", pending[0].RequesterId)
+
+	request, err := repo.GetFollowRequest("user3", "private")
+	assert.NoError(t, err)
+	assert.Equal(t, models.FollowRequestDenied, request.Status)
+
+	_ = repo.DeleteFollowRequest("user1", "private")
+
+	_, err = repo.GetFollowRequest("user1", "private")
+	assert.EqualError(t, err, "follow request not found")
+}
+
+func TestGetPostLatestComments_ExcludesAuthors(t *testing.T) {
+	repo := NewInMemoryRepo()
+	postID, _ := repo.SavePostMeta(models.PostMetaDTO{Creator: "user1"})
+
+	_, _ = repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "Hi", AuthorId: "user2"}, time.Now())
+	_, _ = repo.SaveComment(models.CommentRequestDTO{PostId: postID, Comment: "Spam", AuthorId: "muted"}, time.Now())
+
+	latestComments, err := repo.GetPostLatestComments(postID, 2, []string{"muted"})
+
+	assert.NoError(t, err)
+	assert.Len(t, latestComments, 1)
+	assert.Equal(t, "user2", latestComments[0].Creator)
+}
+
+func TestBlockUser(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	assert.NoError(t, repo.BlockUser("user1", "user2"))
+	_ = repo.BlockUser("user3", "user1")
+
+	blocked, _ := repo.IsBlocked("user1", "user2")
+	assert.True(t, blocked)
+	blocked, _ = repo.IsBlocked("user2", "user1")
+	assert.False(t, blocked)
+
+	blockedUsers, blockedBy, err := repo.GetBlocks("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, blockedUsers)
+	assert.Equal(t, []string{"user3"}, blockedBy)
+
+	_ = repo.UnblockUser("user1", "user2")
+
+	blockedUsers, _, _ = repo.GetBlocks("user1")
+	assert.Empty(t, blockedUsers)
+	_, blockedBy, _ = repo.GetBlocks("user2")
+	assert.Empty(t, blockedBy)
+}
+
+func TestMuteUser(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	assert.NoError(t, repo.MuteUser("user1", "user2"))
+
+	muted, err := repo.GetMutedUsers("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, muted)
+
+	_ = repo.UnmuteUser("user1", "user2")
+
+	muted, _ = repo.GetMutedUsers("user1")
+	assert.Empty(t, muted)
+}
+
+func TestCloseFriends(t *testing.T) {
+	repo := NewInMemoryRepo()
+
+	assert.NoError(t, repo.AddCloseFriend("user1", "user2"))
+
+	isFriend, err := repo.IsCloseFriend("user1", "user2")
+	assert.NoError(t, err)
+	assert.True(t, isFriend)
+
+	// The list is one-sided
+	isFriend, _ = repo.IsCloseFriend("user2", "user1")
+	assert.False(t, isFriend)
+
+	friends, err := repo.GetCloseFriends("user1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, friends)
+
+	_ = repo.RemoveCloseFriend("user1", "user2")
+
+	isFriend, _ = repo.IsCloseFriend("user1", "user2")
+	assert.False(t, isFriend)
+}
@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"hash/fnv"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+)
+
+// Number of shards the likes of each post are spread over
+const likeShards = 16
+
+// InMemoryLikeRepo is an in-memory implementation of ILikeRepository. The
+// likes of a post are spread over shards by user, each with its own lock, so
+// a viral post takes many concurrent likes without them all queueing on a
+// single counter. Counts are the sum of the shards. Reactions on comments
+// are kept with a lock per comment.
+type InMemoryLikeRepo struct {
+	mu       sync.RWMutex
+	posts    map[string]*postLikes
+	comments map[string]*commentReactions
+}
+
+// postLikes holds the likes of a single post
+type postLikes struct {
+	shards [likeShards]likeShard
+}
+
+type likeShard struct {
+	mu     sync.RWMutex
+	likers *adjacency
+}
+
+// commentReactions holds the reactions on a single comment, by user, along
+// with running counts by reaction
+type commentReactions struct {
+	mu     sync.RWMutex
+	byUser map[string]string
+	counts map[string]int
+}
+
+// NewInMemoryLikeRepo creates a new instance of InMemoryLikeRepo
+func NewInMemoryLikeRepo() *InMemoryLikeRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ ILikeRepository = (*InMemoryLikeRepo)(nil)
+
+	return &InMemoryLikeRepo{
+		posts:    make(map[string]*postLikes),
+		comments: make(map[string]*commentReactions),
+	}
+}
+
+// LikePost records a like, unless the user already likes the post
+func (repo *InMemoryLikeRepo) LikePost(postID string, userID string) (bool, error) {
+	shard := repo.likesOf(postID, true).shardOf(userID)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.likers.add(userID, time.Now()), nil
+}
+
+// UnlikePost removes a like, if any
+func (repo *InMemoryLikeRepo) UnlikePost(postID string, userID string) (bool, error) {
+	likes := repo.likesOf(postID, false)
+	if likes == nil {
+		return false, nil
+	}
+
+	shard := likes.shardOf(userID)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.likers.remove(userID), nil
+}
+
+// CountLikes adds up the shards of each post
+func (repo *InMemoryLikeRepo) CountLikes(postIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(postIDs))
+
+	for _, postID := range postIDs {
+		likes := repo.likesOf(postID, false)
+		if likes == nil {
+			counts[postID] = 0
+			continue
+		}
+
+		count := 0
+		for i := range likes.shards {
+			shard := &likes.shards[i]
+			shard.mu.RLock()
+			count += shard.likers.len()
+			shard.mu.RUnlock()
+		}
+		counts[postID] = count
+	}
+
+	return counts, nil
+}
+
+// GetLikedPosts tells which of the posts a user likes
+func (repo *InMemoryLikeRepo) GetLikedPosts(userID string, postIDs []string) (map[string]bool, error) {
+	liked := make(map[string]bool, len(postIDs))
+
+	for _, postID := range postIDs {
+		likes := repo.likesOf(postID, false)
+		if likes == nil {
+			liked[postID] = false
+			continue
+		}
+
+		shard := likes.shardOf(userID)
+		shard.mu.RLock()
+		liked[postID] = shard.likers.has(userID)
+		shard.mu.RUnlock()
+	}
+
+	return liked, nil
+}
+
+// GetLikes merges the newest likes of every shard into a single page
+func (repo *InMemoryLikeRepo) GetLikes(postID string, cursor string, limit int) ([]models.LikeDTO, string, error) {
+	limit = pagination.ClampLimit(limit)
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	likes := repo.likesOf(postID, false)
+	if likes == nil {
+		return []models.LikeDTO{}, "", nil
+	}
+
+	// Each shard gives its own newest likes after the cursor; the page is the
+	// newest of all of them
+	var merged []edge
+	more := false
+	for i := range likes.shards {
+		shard := &likes.shards[i]
+
+		shard.mu.RLock()
+		edges, next, err := shard.likers.page(cursor, limit)
+		shard.mu.RUnlock()
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		merged = append(merged, edges...)
+		more = more || next != ""
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return edgeBefore(merged[j], merged[i])
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+		more = true
+	}
+
+	page := make([]models.LikeDTO, 0, len(merged))
+	for _, e := range merged {
+		page = append(page, models.LikeDTO{UserId: e.userID, CreatedAt: e.createdAt})
+	}
+
+	if !more || len(merged) == 0 {
+		return page, "", nil
+	}
+	return page, merged[len(merged)-1].cursor().Encode(), nil
+}
+
+// SetCommentReaction records the reaction of a user, replacing their
+// previous one
+func (repo *InMemoryLikeRepo) SetCommentReaction(commentID string, userID string, reaction string) error {
+	reactions := repo.reactionsOf(commentID, true)
+
+	reactions.mu.Lock()
+	defer reactions.mu.Unlock()
+
+	if previous, exists := reactions.byUser[userID]; exists {
+		reactions.uncount(previous)
+	}
+
+	reactions.byUser[userID] = reaction
+	reactions.counts[reaction]++
+	return nil
+}
+
+// RemoveCommentReaction removes the reaction of a user, if any
+func (repo *InMemoryLikeRepo) RemoveCommentReaction(commentID string, userID string) error {
+	reactions := repo.reactionsOf(commentID, false)
+	if reactions == nil {
+		return nil
+	}
+
+	reactions.mu.Lock()
+	defer reactions.mu.Unlock()
+
+	if previous, exists := reactions.byUser[userID]; exists {
+		reactions.uncount(previous)
+		delete(reactions.byUser, userID)
+	}
+	return nil
+}
+
+// CountCommentReactions copies the running counts of each comment. Comments
+// without reactions are left out.
+func (repo *InMemoryLikeRepo) CountCommentReactions(commentIDs []string) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int, len(commentIDs))
+
+	for _, commentID := range commentIDs {
+		reactions := repo.reactionsOf(commentID, false)
+		if reactions == nil {
+			continue
+		}
+
+		reactions.mu.RLock()
+		if len(reactions.counts) > 0 {
+			counts[commentID] = maps.Clone(reactions.counts)
+		}
+		reactions.mu.RUnlock()
+	}
+
+	return counts, nil
+}
+
+// GetUserCommentReactions looks up the reaction of a user on each comment
+func (repo *InMemoryLikeRepo) GetUserCommentReactions(userID string, commentIDs []string) (map[string]string, error) {
+	mine := make(map[string]string)
+
+	for _, commentID := range commentIDs {
+		reactions := repo.reactionsOf(commentID, false)
+		if reactions == nil {
+			continue
+		}
+
+		reactions.mu.RLock()
+		if reaction, exists := reactions.byUser[userID]; exists {
+			mine[commentID] = reaction
+		}
+		reactions.mu.RUnlock()
+	}
+
+	return mine, nil
+}
+
+// PurgePostLikes drops the likes of the posts altogether
+func (repo *InMemoryLikeRepo) PurgePostLikes(postIDs []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, postID := range postIDs {
+		delete(repo.posts, postID)
+	}
+	return nil
+}
+
+// PurgeCommentReactions drops the reactions on the comments altogether
+func (repo *InMemoryLikeRepo) PurgeCommentReactions(commentIDs []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, commentID := range commentIDs {
+		delete(repo.comments, commentID)
+	}
+	return nil
+}
+
+// likesOf returns the likes of a post. Missing posts are created when create
+// is set; otherwise nil is returned for them.
+func (repo *InMemoryLikeRepo) likesOf(postID string, create bool) *postLikes {
+	repo.mu.RLock()
+	likes, exists := repo.posts[postID]
+	repo.mu.RUnlock()
+
+	if exists || !create {
+		return likes
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// Another like may have created it in the meantime
+	if likes, exists = repo.posts[postID]; !exists {
+		likes = &postLikes{}
+		for i := range likes.shards {
+			likes.shards[i].likers = newAdjacency()
+		}
+		repo.posts[postID] = likes
+	}
+	return likes
+}
+
+// shardOf picks the shard holding the like of a user
+func (likes *postLikes) shardOf(userID string) *likeShard {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return &likes.shards[h.Sum32()%likeShards]
+}
+
+// reactionsOf returns the reactions on a comment. Missing comments are
+// created when create is set; otherwise nil is returned for them.
+func (repo *InMemoryLikeRepo) reactionsOf(commentID string, create bool) *commentReactions {
+	repo.mu.RLock()
+	reactions, exists := repo.comments[commentID]
+	repo.mu.RUnlock()
+
+	if exists || !create {
+		return reactions
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if reactions, exists = repo.comments[commentID]; !exists {
+		reactions = &commentReactions{
+			byUser: make(map[string]string),
+			counts: make(map[string]int),
+		}
+		repo.comments[commentID] = reactions
+	}
+	return reactions
+}
+
+// uncount takes a reaction off the running counts
+func (reactions *commentReactions) uncount(reaction string) {
+	reactions.counts[reaction]--
+	if reactions.counts[reaction] == 0 {
+		delete(reactions.counts, reaction)
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type ILikeRepository interface {
+	// Record that a User likes a Post. Returns false if they already did.
+	LikePost(post_id string, user_id string) (bool, error)
+
+	// Remove the like of a User from a Post. Returns false if there was none.
+	UnlikePost(post_id string, user_id string) (bool, error)
+
+	// Count the likes of each of the Posts
+	CountLikes(post_ids []string) (counts map[string]int, err error)
+
+	// Tell which of the Posts a User likes
+	GetLikedPosts(user_id string, post_ids []string) (liked map[string]bool, err error)
+
+	// Read a page of the likes of a Post, most recent first
+	GetLikes(post_id string, cursor string, limit int) (likes []models.LikeDTO, next_cursor string, err error)
+
+	// Set the reaction of a User on a Comment, replacing any previous one
+	SetCommentReaction(comment_id string, user_id string, reaction string) error
+
+	// Remove the reaction of a User from a Comment, if any
+	RemoveCommentReaction(comment_id string, user_id string) error
+
+	// Count the reactions on each of the Comments, by reaction
+	CountCommentReactions(comment_ids []string) (counts map[string]map[string]int, err error)
+
+	// Get the reactions of a User on the Comments they reacted to
+	GetUserCommentReactions(user_id string, comment_ids []string) (reactions map[string]string, err error)
+
+	// Remove all the likes of the Posts, once they are gone for good
+	PurgePostLikes(post_ids []string) error
+
+	// Remove all the reactions on the Comments, once they are gone for good
+	PurgeCommentReactions(comment_ids []string) error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLikePost_Idempotent(t *testing.T) {
+	repo := NewInMemoryLikeRepo()
+
+	added, err := repo.LikePost("post1", "user1")
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, _ = repo.LikePost("post1", "user1")
+	assert.False(t, added)
+
+	counts, err := repo.CountLikes([]string{"post1", "post2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"post1": 1, "post2": 0}, counts)
+
+	liked, err := repo.GetLikedPosts("user1", []string{"post1", "post2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"post1": true, "post2": false}, liked)
+
+	removed, _ := repo.UnlikePost("post1", "user1")
+	assert.True(t, removed)
+	removed, _ = repo.UnlikePost("post1", "user1")
+	assert.False(t, removed)
+
+	counts, _ = repo.CountLikes([]string{"post1"})
+	assert.Equal(t, 0, counts["post1"])
+}
+
+func TestLikePost_Concurrent(t *testing.T) {
+	repo := NewInMemoryLikeRepo()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Every user likes twice; only the first one counts
+			_, _ = repo.LikePost("post1", fmt.Sprintf("user%d", i%100))
+		}(i)
+	}
+	wg.Wait()
+
+	counts, _ := repo.CountLikes([]string{"post1"})
+	assert.Equal(t, 100, counts["post1"])
+}
+
+func TestGetLikes_PaginatesAcrossShards(t *testing.T) {
+	repo := NewInMemoryLikeRepo()
+
+	for i := 0; i < 50; i++ {
+		_, _ = repo.LikePost("post1", fmt.Sprintf("user%d", i))
+	}
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 10)
+
+		likes, next, err := repo.GetLikes("post1", cursor, 7)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(likes), 7)
+
+		for i, like := range likes {
+			assert.False(t, seen[like.UserId], "%s listed twice", like.UserId)
+			seen[like.UserId] = true
+
+			if i > 0 {
+				assert.False(t, like.CreatedAt.After(likes[i-1].CreatedAt))
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	assert.Len(t, seen, 50)
+
+	_, _, err := repo.GetLikes("post1", "not a cursor", 7)
+	assert.Error(t, err)
+}
+
+func TestCommentReactions(t *testing.T) {
+	repo := NewInMemoryLikeRepo()
+
+	assert.NoError(t, repo.SetCommentReaction("comment1", "user1", "👍"))
+	assert.NoError(t, repo.SetCommentReaction("comment1", "user2", "👍"))
+	assert.NoError(t, repo.SetCommentReaction("comment1", "user3", "😂"))
+
+	// A new reaction replaces the previous one
+	assert.NoError(t, repo.SetCommentReaction("comment1", "user3", "❤️"))
+
+	counts, err := repo.CountCommentReactions([]string{"comment1", "comment2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{"comment1": {"👍": 2, "❤️": 1}}, counts)
+
+	mine, err := repo.GetUserCommentReactions("user3", []string{"comment1", "comment2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"comment1": "❤️"}, mine)
+
+	assert.NoError(t, repo.RemoveCommentReaction("comment1", "user3"))
+	assert.NoError(t, repo.RemoveCommentReaction("comment2", "user3"))
+
+	counts, _ = repo.CountCommentReactions([]string{"comment1"})
+	assert.Equal(t, map[string]int{"👍": 2}, counts["comment1"])
+
+	mine, _ = repo.GetUserCommentReactions("user3", []string{"comment1"})
+	assert.Empty(t, mine)
+}
+
+func TestPurgeLikesAndReactions(t *testing.T) {
+	repo := NewInMemoryLikeRepo()
+
+	_, _ = repo.LikePost("post1", "user1")
+	_, _ = repo.LikePost("post2", "user1")
+	_ = repo.SetCommentReaction("comment1", "user1", "👍")
+	_ = repo.SetCommentReaction("comment2", "user1", "👍")
+
+	assert.NoError(t, repo.PurgePostLikes([]string{"post1", "missing"}))
+	assert.NoError(t, repo.PurgeCommentReactions([]string{"comment1", "missing"}))
+
+	counts, _ := repo.CountLikes([]string{"post1", "post2"})
+	assert.Equal(t, map[string]int{"post1": 0, "post2": 1}, counts)
+
+	reactions, _ := repo.CountCommentReactions([]string{"comment1", "comment2"})
+	assert.Equal(t, map[string]map[string]int{"comment2": {"👍": 1}}, reactions)
+}
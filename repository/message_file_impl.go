@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// FileMessageRepo is a persistent implementation of IMessageRepository.
+// Conversations and messages are served from memory and every change is
+// appended to a log file, which is replayed on start.
+type FileMessageRepo struct {
+	*InMemoryMessageRepo
+
+	writeMu sync.Mutex
+	log     jsonLog
+}
+
+// NewFileMessageRepo opens the message log at path, creating it on first write
+func NewFileMessageRepo(path string) (*FileMessageRepo, error) {
+
+	// compile-time check to ensure we implement the interface
+	var _ IMessageRepository = (*FileMessageRepo)(nil)
+
+	repo := &FileMessageRepo{
+		InMemoryMessageRepo: NewInMemoryMessageRepo(),
+		log:                 jsonLog{path: path},
+	}
+
+	err := repo.log.load(func(data []byte) error {
+		var record messageRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		repo.apply(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// SaveConversation saves a new conversation and appends it to the log
+func (repo *FileMessageRepo) SaveConversation(conversation models.ConversationDTO) (models.ConversationDTO, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	repo.mu.RLock()
+	existing, exists := repo.directConversation(conversation)
+	repo.mu.RUnlock()
+
+	if exists {
+		return existing, nil
+	}
+
+	conversation = newConversation(conversation)
+	if err := repo.commit(messageRecord{Conversation: &conversation}); err != nil {
+		return models.ConversationDTO{}, err
+	}
+	return conversation, nil
+}
+
+// SaveMessage saves a new message and appends it to the log
+func (repo *FileMessageRepo) SaveMessage(message models.MessageDTO) (models.MessageDTO, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	if _, err := repo.GetConversation(message.ConversationId); err != nil {
+		return models.MessageDTO{}, err
+	}
+
+	message.Id = uuid.New().String()
+	if err := repo.commit(messageRecord{Message: &message}); err != nil {
+		return models.MessageDTO{}, err
+	}
+	return message, nil
+}
+
+// SetReadReceipt moves a read receipt forward and appends it to the log
+func (repo *FileMessageRepo) SetReadReceipt(receipt models.ReadReceiptDTO) (bool, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	repo.mu.RLock()
+	moves, err := repo.receiptMoves(receipt)
+	repo.mu.RUnlock()
+
+	if err != nil || !moves {
+		return false, err
+	}
+
+	if err := repo.commit(messageRecord{Receipt: &receipt}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// commit appends record to the log and only then applies it in memory, so a
+// failed write changes nothing. The caller must hold writeMu.
+func (repo *FileMessageRepo) commit(record messageRecord) error {
+	if err := repo.log.append(record); err != nil {
+		return err
+	}
+
+	repo.apply(record)
+	return nil
+}
+
+func (repo *FileMessageRepo) apply(record messageRecord) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	switch {
+	case record.Conversation != nil:
+		repo.addConversation(*record.Conversation)
+	case record.Message != nil:
+		repo.addMessage(*record.Message)
+	case record.Receipt != nil:
+		repo.addReceipt(*record.Receipt)
+	}
+}
+
+// messageRecord is one change in the log of a FileMessageRepo; exactly one
+// of its fields is set
+type messageRecord struct {
+	Conversation *models.ConversationDTO `json:"conversation,omitempty"`
+	Message      *models.MessageDTO      `json:"message,omitempty"`
+	Receipt      *models.ReadReceiptDTO  `json:"receipt,omitempty"`
+}
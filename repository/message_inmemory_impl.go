@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// InMemoryMessageRepo is an in-memory implementation of IMessageRepository.
+// Each user keeps their conversations ordered by their last message, and
+// each conversation keeps its messages ordered by when they were sent.
+type InMemoryMessageRepo struct {
+	mu sync.RWMutex
+
+	conversations map[string]models.ConversationDTO
+
+	// Direct conversations by the sorted ids of their two members
+	direct map[string]string
+
+	// Conversations by member, by the time of their last message
+	inboxes map[string]*adjacency
+
+	messages map[string]models.MessageDTO
+
+	// Messages by conversation
+	threads map[string]*adjacency
+
+	// Read receipts by conversation, then by member
+	receipts map[string]map[string]models.ReadReceiptDTO
+}
+
+// NewInMemoryMessageRepo creates a new instance of InMemoryMessageRepo
+func NewInMemoryMessageRepo() *InMemoryMessageRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ IMessageRepository = (*InMemoryMessageRepo)(nil)
+
+	return &InMemoryMessageRepo{
+		conversations: make(map[string]models.ConversationDTO),
+		direct:        make(map[string]string),
+		inboxes:       make(map[string]*adjacency),
+		messages:      make(map[string]models.MessageDTO),
+		threads:       make(map[string]*adjacency),
+		receipts:      make(map[string]map[string]models.ReadReceiptDTO),
+	}
+}
+
+// SaveConversation saves a new conversation, or returns the direct
+// conversation its two members already have
+func (repo *InMemoryMessageRepo) SaveConversation(conversation models.ConversationDTO) (models.ConversationDTO, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if existing, exists := repo.directConversation(conversation); exists {
+		return existing, nil
+	}
+
+	conversation = newConversation(conversation)
+	repo.addConversation(conversation)
+	return conversation, nil
+}
+
+// GetConversation retrieves a conversation by id
+func (repo *InMemoryMessageRepo) GetConversation(conversation_id string) (models.ConversationDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	conversation, exists := repo.conversations[conversation_id]
+	if !exists {
+		return models.ConversationDTO{}, errors.New("conversation not found")
+	}
+	return conversation, nil
+}
+
+// GetUserConversations reads a page of the conversations of a user, most
+// recently active first
+func (repo *InMemoryMessageRepo) GetUserConversations(user_id string, cursor string, limit int) ([]models.ConversationDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	inbox, exists := repo.inboxes[user_id]
+	if !exists {
+		inbox = newAdjacency()
+	}
+
+	edges, next, err := inbox.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conversations := make([]models.ConversationDTO, 0, len(edges))
+	for _, e := range edges {
+		conversations = append(conversations, repo.conversations[e.userID])
+	}
+	return conversations, next, nil
+}
+
+// SaveMessage saves a new message and moves its conversation to the top of
+// the inbox of each member
+func (repo *InMemoryMessageRepo) SaveMessage(message models.MessageDTO) (models.MessageDTO, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.conversations[message.ConversationId]; !exists {
+		return models.MessageDTO{}, errors.New("conversation not found")
+	}
+
+	message.Id = uuid.New().String()
+	repo.addMessage(message)
+	return message, nil
+}
+
+// GetMessage retrieves a message by id
+func (repo *InMemoryMessageRepo) GetMessage(message_id string) (models.MessageDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	message, exists := repo.messages[message_id]
+	if !exists {
+		return models.MessageDTO{}, errors.New("message not found")
+	}
+	return message, nil
+}
+
+// GetMessages reads a page of the messages of a conversation, newest first
+func (repo *InMemoryMessageRepo) GetMessages(conversation_id string, cursor string, limit int) ([]models.MessageDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	thread, exists := repo.threads[conversation_id]
+	if !exists {
+		thread = newAdjacency()
+	}
+
+	edges, next, err := thread.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages := make([]models.MessageDTO, 0, len(edges))
+	for _, e := range edges {
+		messages = append(messages, repo.messages[e.userID])
+	}
+	return messages, next, nil
+}
+
+// SetReadReceipt moves the read receipt of a member forward
+func (repo *InMemoryMessageRepo) SetReadReceipt(receipt models.ReadReceiptDTO) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	moves, err := repo.receiptMoves(receipt)
+	if err != nil || !moves {
+		return false, err
+	}
+
+	repo.addReceipt(receipt)
+	return true, nil
+}
+
+// GetReadReceipts lists the read receipts of a conversation, in no
+// particular order
+func (repo *InMemoryMessageRepo) GetReadReceipts(conversation_id string) ([]models.ReadReceiptDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	receipts := make([]models.ReadReceiptDTO, 0, len(repo.receipts[conversation_id]))
+	for _, receipt := range repo.receipts[conversation_id] {
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// CountUnreadMessages counts the messages of others after the read receipt
+// of a user, walking back from the newest message
+func (repo *InMemoryMessageRepo) CountUnreadMessages(conversation_id, user_id string, ignored []string) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	thread, exists := repo.threads[conversation_id]
+	if !exists {
+		return 0, nil
+	}
+
+	receipt, read := repo.receipts[conversation_id][user_id]
+	last := edge{userID: receipt.MessageId, createdAt: receipt.MessageAt}
+
+	unread := 0
+	for i := len(thread.edges) - 1; i >= 0; i-- {
+		e := thread.edges[i]
+		if read && !edgeBefore(last, e) {
+			break
+		}
+
+		sender := repo.messages[e.userID].SenderId
+		if !e.removed && sender != user_id && !slices.Contains(ignored, sender) {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// directConversation finds the direct conversation the members of
+// conversation already have. The caller must hold the lock.
+func (repo *InMemoryMessageRepo) directConversation(conversation models.ConversationDTO) (models.ConversationDTO, bool) {
+	if conversation.Group {
+		return models.ConversationDTO{}, false
+	}
+
+	conversation_id, exists := repo.direct[directKey(conversation.MemberIds)]
+	if !exists {
+		return models.ConversationDTO{}, false
+	}
+	return repo.conversations[conversation_id], true
+}
+
+// receiptMoves tells whether receipt is ahead of the one its member has.
+// The caller must hold the lock.
+func (repo *InMemoryMessageRepo) receiptMoves(receipt models.ReadReceiptDTO) (bool, error) {
+	if _, exists := repo.conversations[receipt.ConversationId]; !exists {
+		return false, errors.New("conversation not found")
+	}
+
+	current, exists := repo.receipts[receipt.ConversationId][receipt.UserId]
+	return !exists || receiptBefore(current, receipt), nil
+}
+
+func (repo *InMemoryMessageRepo) addConversation(conversation models.ConversationDTO) {
+	repo.conversations[conversation.Id] = conversation
+	if !conversation.Group {
+		repo.direct[directKey(conversation.MemberIds)] = conversation.Id
+	}
+
+	for _, member_id := range conversation.MemberIds {
+		inbox, exists := repo.inboxes[member_id]
+		if !exists {
+			inbox = newAdjacency()
+			repo.inboxes[member_id] = inbox
+		}
+		inbox.add(conversation.Id, conversation.LastMessageAt)
+	}
+}
+
+func (repo *InMemoryMessageRepo) addMessage(message models.MessageDTO) {
+	repo.messages[message.Id] = message
+
+	thread, exists := repo.threads[message.ConversationId]
+	if !exists {
+		thread = newAdjacency()
+		repo.threads[message.ConversationId] = thread
+	}
+	thread.add(message.Id, message.CreatedAt)
+
+	conversation := repo.conversations[message.ConversationId]
+	if message.CreatedAt.Before(conversation.LastMessageAt) {
+		return
+	}
+
+	conversation.LastMessageAt = message.CreatedAt
+	repo.conversations[conversation.Id] = conversation
+
+	for _, member_id := range conversation.MemberIds {
+		inbox := repo.inboxes[member_id]
+		inbox.remove(conversation.Id)
+		inbox.add(conversation.Id, conversation.LastMessageAt)
+	}
+}
+
+func (repo *InMemoryMessageRepo) addReceipt(receipt models.ReadReceiptDTO) {
+	receipts, exists := repo.receipts[receipt.ConversationId]
+	if !exists {
+		receipts = make(map[string]models.ReadReceiptDTO)
+		repo.receipts[receipt.ConversationId] = receipts
+	}
+	receipts[receipt.UserId] = receipt
+}
+
+// newConversation assigns an id to a conversation about to be saved
+func newConversation(conversation models.ConversationDTO) models.ConversationDTO {
+	conversation.Id = uuid.New().String()
+	conversation.MemberIds = slices.Clone(conversation.MemberIds)
+	if conversation.LastMessageAt.IsZero() {
+		conversation.LastMessageAt = conversation.CreatedAt
+	}
+	return conversation
+}
+
+// receiptBefore tells whether a read receipt is on an older message than
+// another
+func receiptBefore(a, b models.ReadReceiptDTO) bool {
+	return edgeBefore(edge{userID: a.MessageId, createdAt: a.MessageAt}, edge{userID: b.MessageId, createdAt: b.MessageAt})
+}
+
+// directKey identifies the direct conversation between two users
+func directKey(member_ids []string) string {
+	sorted := slices.Clone(member_ids)
+	slices.Sort(sorted)
+	return strings.Join(sorted, "|")
+}
@@ -0,0 +1,34 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type IMessageRepository interface {
+	// Save a new Conversation. Two users only ever have one direct
+	// Conversation: saving another one returns the existing one instead.
+	SaveConversation(conversation models.ConversationDTO) (models.ConversationDTO, error)
+
+	GetConversation(conversation_id string) (models.ConversationDTO, error)
+
+	// Read a page of the Conversations of a User, most recently active first
+	GetUserConversations(user_id string, cursor string, limit int) (conversations []models.ConversationDTO, next_cursor string, err error)
+
+	// Save a new Message, making its Conversation the most recently active
+	// one of its members
+	SaveMessage(message models.MessageDTO) (models.MessageDTO, error)
+
+	GetMessage(message_id string) (models.MessageDTO, error)
+
+	// Read a page of the Messages of a Conversation, newest first
+	GetMessages(conversation_id string, cursor string, limit int) (messages []models.MessageDTO, next_cursor string, err error)
+
+	// Move the read receipt of a member forward to a Message. Returns false
+	// if the member had already read up to that Message or past it.
+	SetReadReceipt(receipt models.ReadReceiptDTO) (bool, error)
+
+	// Get the read receipts of the members of a Conversation who read it
+	GetReadReceipts(conversation_id string) ([]models.ReadReceiptDTO, error)
+
+	// Count the Messages of a Conversation after the read receipt of a User,
+	// leaving out those sent by the User and by the ignored senders
+	CountUnreadMessages(conversation_id, user_id string, ignored []string) (int, error)
+}
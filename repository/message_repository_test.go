@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveConversation_OneDirectConversationPerPair(t *testing.T) {
+	repo := NewInMemoryMessageRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	direct, err := repo.SaveConversation(models.ConversationDTO{CreatorId: "alice", MemberIds: []string{"alice", "bob"}, CreatedAt: base})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, direct.Id)
+	assert.Equal(t, base, direct.LastMessageAt)
+
+	again, _ := repo.SaveConversation(models.ConversationDTO{CreatorId: "bob", MemberIds: []string{"bob", "alice"}, CreatedAt: base.Add(time.Hour)})
+	assert.Equal(t, direct, again)
+
+	// Groups with the same members are separate conversations
+	group1, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, Group: true, CreatedAt: base})
+	group2, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, Group: true, CreatedAt: base})
+	assert.NotEqual(t, group1.Id, group2.Id)
+
+	_, err = repo.GetConversation("missing")
+	assert.EqualError(t, err, "conversation not found")
+}
+
+func TestSaveMessage_MovesConversationToTop(t *testing.T) {
+	repo := NewInMemoryMessageRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	older, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, CreatedAt: base})
+	newer, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "carol"}, CreatedAt: base.Add(time.Minute)})
+
+	conversations, _, err := repo.GetUserConversations("alice", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{newer.Id, older.Id}, conversationIds(conversations))
+
+	_, err = repo.SaveMessage(models.MessageDTO{ConversationId: older.Id, SenderId: "bob", Text: "hi", CreatedAt: base.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	conversations, _, _ = repo.GetUserConversations("alice", "", 10)
+	assert.Equal(t, []string{older.Id, newer.Id}, conversationIds(conversations))
+	assert.Equal(t, base.Add(time.Hour), conversations[0].LastMessageAt)
+
+	conversations, _, _ = repo.GetUserConversations("carol", "", 10)
+	assert.Equal(t, []string{newer.Id}, conversationIds(conversations))
+
+	_, err = repo.SaveMessage(models.MessageDTO{ConversationId: "missing", SenderId: "bob"})
+	assert.EqualError(t, err, "conversation not found")
+}
+
+func TestReadReceipts(t *testing.T) {
+	repo := NewInMemoryMessageRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	conversation, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob", "carol"}, Group: true, CreatedAt: base})
+
+	var messages []models.MessageDTO
+	for i, sender := range []string{"alice", "bob", "carol", "bob"} {
+		message, _ := repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: sender, CreatedAt: base.Add(time.Duration(i+1) * time.Minute)})
+		messages = append(messages, message)
+	}
+
+	page, next, err := repo.GetMessages(conversation.Id, "", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MessageDTO{messages[3], messages[2], messages[1]}, page)
+
+	page, next, _ = repo.GetMessages(conversation.Id, next, 3)
+	assert.Equal(t, []models.MessageDTO{messages[0]}, page)
+	assert.Empty(t, next)
+
+	// Nothing read yet: everything others sent is unread
+	unread, _ := repo.CountUnreadMessages(conversation.Id, "alice", nil)
+	assert.Equal(t, 3, unread)
+	unread, _ = repo.CountUnreadMessages(conversation.Id, "alice", []string{"carol"})
+	assert.Equal(t, 2, unread)
+
+	receipt := func(message models.MessageDTO) models.ReadReceiptDTO {
+		return models.ReadReceiptDTO{ConversationId: conversation.Id, UserId: "alice", MessageId: message.Id, MessageAt: message.CreatedAt, ReadAt: base.Add(time.Hour)}
+	}
+
+	moved, err := repo.SetReadReceipt(receipt(messages[2]))
+	assert.NoError(t, err)
+	assert.True(t, moved)
+
+	unread, _ = repo.CountUnreadMessages(conversation.Id, "alice", nil)
+	assert.Equal(t, 1, unread)
+
+	// Receipts only move forward
+	moved, _ = repo.SetReadReceipt(receipt(messages[1]))
+	assert.False(t, moved)
+
+	receipts, _ := repo.GetReadReceipts(conversation.Id)
+	assert.Equal(t, []models.ReadReceiptDTO{receipt(messages[2])}, receipts)
+}
+
+func TestFileMessageRepo_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileMessageRepo(path)
+	assert.NoError(t, err)
+
+	conversation, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, CreatedAt: base})
+	first, _ := repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "alice", Text: "hi", CreatedAt: base.Add(time.Minute)})
+	second, _ := repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "alice", PostId: "post1", CreatedAt: base.Add(2 * time.Minute)})
+	_, err = repo.SetReadReceipt(models.ReadReceiptDTO{ConversationId: conversation.Id, UserId: "bob", MessageId: first.Id, MessageAt: first.CreatedAt, ReadAt: base.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	reopened, err := NewFileMessageRepo(path)
+	assert.NoError(t, err)
+
+	messages, _, err := reopened.GetMessages(conversation.Id, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MessageDTO{second, first}, messages)
+
+	conversations, _, _ := reopened.GetUserConversations("bob", "", 10)
+	assert.Equal(t, base.Add(2*time.Minute), conversations[0].LastMessageAt)
+
+	unread, _ := reopened.CountUnreadMessages(conversation.Id, "bob", nil)
+	assert.Equal(t, 1, unread)
+
+	// The pair still has a single direct conversation
+	again, _ := reopened.SaveConversation(models.ConversationDTO{MemberIds: []string{"bob", "alice"}, CreatedAt: base})
+	assert.Equal(t, conversation.Id, again.Id)
+}
+
+func TestFileMessageRepo_FailedWriteChangesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileMessageRepo(path)
+	assert.NoError(t, err)
+	conversation, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, CreatedAt: base})
+	first, _ := repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "alice", Text: "hi", CreatedAt: base.Add(time.Minute)})
+
+	// Opening a directory for writing fails
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, os.Mkdir(path, 0o755))
+
+	_, err = repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "alice", Text: "again", CreatedAt: base.Add(2 * time.Minute)})
+	assert.Error(t, err)
+	_, err = repo.SetReadReceipt(models.ReadReceiptDTO{ConversationId: conversation.Id, UserId: "bob", MessageId: first.Id, MessageAt: first.CreatedAt})
+	assert.Error(t, err)
+
+	messages, _, _ := repo.GetMessages(conversation.Id, "", 10)
+	assert.Equal(t, []models.MessageDTO{first}, messages)
+	receipts, _ := repo.GetReadReceipts(conversation.Id)
+	assert.Empty(t, receipts)
+}
+
+func TestFileMessageRepo_DropsTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.log")
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileMessageRepo(path)
+	assert.NoError(t, err)
+	conversation, _ := repo.SaveConversation(models.ConversationDTO{MemberIds: []string{"alice", "bob"}, CreatedAt: base})
+	first, _ := repo.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "alice", Text: "hi", CreatedAt: base.Add(time.Minute)})
+
+	// A crash in the middle of an append leaves half a record behind
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"message":{"id":"torn"`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	reopened, err := NewFileMessageRepo(path)
+	assert.NoError(t, err)
+	second, err := reopened.SaveMessage(models.MessageDTO{ConversationId: conversation.Id, SenderId: "bob", Text: "hey", CreatedAt: base.Add(2 * time.Minute)})
+	assert.NoError(t, err)
+
+	reopened, err = NewFileMessageRepo(path)
+	assert.NoError(t, err)
+	messages, _, _ := reopened.GetMessages(conversation.Id, "", 10)
+	assert.Equal(t, []models.MessageDTO{second, first}, messages)
+}
+
+func conversationIds(conversations []models.ConversationDTO) []string {
+	ids := []string{}
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.Id)
+	}
+	return ids
+}
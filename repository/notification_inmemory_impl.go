@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/google/uuid"
+)
+
+// InMemoryNotificationRepo is an in-memory implementation of
+// INotificationRepository
+type InMemoryNotificationRepo struct {
+	mu sync.RWMutex
+
+	// Notifications by user, newest first
+	notifications map[string][]models.NotificationDTO
+
+	// Ids of the stored notifications by what they are about
+	keys map[string]string
+}
+
+// NewInMemoryNotificationRepo creates a new instance of InMemoryNotificationRepo
+func NewInMemoryNotificationRepo() *InMemoryNotificationRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ INotificationRepository = (*InMemoryNotificationRepo)(nil)
+
+	return &InMemoryNotificationRepo{
+		notifications: make(map[string][]models.NotificationDTO),
+		keys:          make(map[string]string),
+	}
+}
+
+// AddNotification inserts a notification in the list of its user, keeping it
+// newest first, unless the user already has it
+func (repo *InMemoryNotificationRepo) AddNotification(notification models.NotificationDTO) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := notificationKey(notification)
+	if id, exists := repo.keys[key]; exists {
+		return id, nil
+	}
+
+	notification.Id = uuid.New().String()
+	repo.keys[key] = notification.Id
+	list := repo.notifications[notification.UserId]
+
+	// Notifications nearly always arrive in time order, so this is nearly
+	// always the front
+	pos := sort.Search(len(list), func(i int) bool {
+		return newerNotification(notification, list[i])
+	})
+
+	list = append(list, models.NotificationDTO{})
+	copy(list[pos+1:], list[pos:])
+	list[pos] = notification
+
+	repo.notifications[notification.UserId] = list
+	return notification.Id, nil
+}
+
+// GetNotifications reads a page of the notifications of a user, newest first
+func (repo *InMemoryNotificationRepo) GetNotifications(userID string, cursor string, limit int) ([]models.NotificationDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	page, next, err := pagination.Paginate(repo.notifications[userID], cursor, limit, true, notificationCursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Copy out of the stored list, which keeps changing
+	return append([]models.NotificationDTO(nil), page...), next, nil
+}
+
+// notificationKey identifies what a notification is about
+func notificationKey(notification models.NotificationDTO) string {
+	return strings.Join([]string{notification.UserId, notification.Type, notification.ActorId, notification.PostId, notification.CommentId}, "/")
+}
+
+// notificationCursor is the pagination key of a notification
+func notificationCursor(notification models.NotificationDTO) pagination.Cursor {
+	return pagination.Cursor{Time: notification.CreatedAt, Id: notification.Id}
+}
+
+// newerNotification orders notifications newest first, with ties broken by id
+func newerNotification(a, b models.NotificationDTO) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.Id > b.Id
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type INotificationRepository interface {
+	// Save a Notification for the User it is addressed to. A Notification of
+	// the same type, from the same actor and about the same post and comment
+	// as one the User already has is not saved again; the id of the stored
+	// one is returned.
+	AddNotification(notification models.NotificationDTO) (notification_id string, err error)
+
+	// Read a page of the Notifications of a User, most recent first
+	GetNotifications(user_id string, cursor string, limit int) (notifications []models.NotificationDTO, next_cursor string, err error)
+}
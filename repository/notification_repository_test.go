@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifications_NewestFirst(t *testing.T) {
+	repo := NewInMemoryNotificationRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, postID := range []string{"post1", "post3", "post2"} {
+		at := base.Add(time.Duration(i) * time.Minute)
+		if postID == "post2" {
+			// Arrives late, but sorts by when it happened
+			at = base.Add(30 * time.Second)
+		}
+
+		_, err := repo.AddNotification(models.NotificationDTO{UserId: "alice", Type: models.NotificationMention, ActorId: "bob", PostId: postID, CreatedAt: at})
+		assert.NoError(t, err)
+	}
+	_, _ = repo.AddNotification(models.NotificationDTO{UserId: "carol", Type: models.NotificationMention, PostId: "post4", CreatedAt: base})
+
+	page, next, err := repo.GetNotifications("alice", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "post3", page[0].PostId)
+	assert.Equal(t, "post2", page[1].PostId)
+	assert.NotEmpty(t, page[0].Id)
+
+	page, next, err = repo.GetNotifications("alice", next, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "post1", page[0].PostId)
+	assert.Empty(t, next)
+}
+
+func TestNotifications_AddedOnce(t *testing.T) {
+	repo := NewInMemoryNotificationRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	mention := models.NotificationDTO{UserId: "alice", Type: models.NotificationMention, ActorId: "bob", PostId: "post1", CreatedAt: base}
+
+	first, err := repo.AddNotification(mention)
+	assert.NoError(t, err)
+
+	mention.CreatedAt = base.Add(time.Minute)
+	again, err := repo.AddNotification(mention)
+	assert.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	// A mention in a comment on the same post is another notification
+	mention.CommentId = "comment1"
+	_, _ = repo.AddNotification(mention)
+
+	page, _, _ := repo.GetNotifications("alice", "", 10)
+	assert.Len(t, page, 2)
+	assert.Equal(t, base, page[1].CreatedAt)
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/anandh86/instagram/geo"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/google/uuid"
+)
+
+// InMemoryPlaceRepo is an in-memory implementation of IPlaceRepository.
+// Places and posts each have a geohash index to find those near a point,
+// and each place keeps its posts ordered by creation time.
+type InMemoryPlaceRepo struct {
+	mu sync.RWMutex
+
+	places      map[string]models.PlaceDTO
+	placesIndex *geoIndex
+
+	// Posts on the map by post id
+	posts      map[string]models.LocatedPostDTO
+	postsIndex *geoIndex
+
+	// Posts by place id
+	placePosts map[string]*adjacency
+}
+
+// NewInMemoryPlaceRepo creates a new instance of InMemoryPlaceRepo
+func NewInMemoryPlaceRepo() *InMemoryPlaceRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ IPlaceRepository = (*InMemoryPlaceRepo)(nil)
+
+	return &InMemoryPlaceRepo{
+		places:      make(map[string]models.PlaceDTO),
+		placesIndex: newGeoIndex(),
+		posts:       make(map[string]models.LocatedPostDTO),
+		postsIndex:  newGeoIndex(),
+		placePosts:  make(map[string]*adjacency),
+	}
+}
+
+// FindOrAddPlace returns the closest place with the same name within the
+// radius, or saves the given place with a new id
+func (repo *InMemoryPlaceRepo) FindOrAddPlace(place models.PlaceDTO, radius_km float64) (models.PlaceDTO, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	name := foldPlaceName(place.Name)
+	point := geo.Point{Lat: place.Latitude, Lng: place.Longitude}
+
+	var closest models.PlaceDTO
+	closestDistance := radius_km
+	found := false
+
+	repo.placesIndex.near(point, radius_km, func(id string, distance float64) {
+		candidate := repo.places[id]
+		if foldPlaceName(candidate.Name) == name && (!found || distance < closestDistance) {
+			closest, closestDistance, found = candidate, distance, true
+		}
+	})
+
+	if found {
+		return closest, nil
+	}
+
+	place.Id = uuid.New().String()
+	repo.places[place.Id] = place
+	repo.placesIndex.set(place.Id, point)
+	return place, nil
+}
+
+// GetPlace retrieves a place by id
+func (repo *InMemoryPlaceRepo) GetPlace(place_id string) (models.PlaceDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	place, exists := repo.places[place_id]
+	if !exists {
+		return models.PlaceDTO{}, errors.New("place not found")
+	}
+	return place, nil
+}
+
+// SetPostLocation files a post under its position and its place
+func (repo *InMemoryPlaceRepo) SetPostLocation(post models.LocatedPostDTO) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.removePost(post.PostId)
+
+	repo.posts[post.PostId] = post
+	repo.postsIndex.set(post.PostId, geo.Point{Lat: post.Latitude, Lng: post.Longitude})
+
+	located, exists := repo.placePosts[post.PlaceId]
+	if !exists {
+		located = newAdjacency()
+		repo.placePosts[post.PlaceId] = located
+	}
+	located.add(post.PostId, post.CreatedAt)
+	return nil
+}
+
+// RemovePostLocation takes a post off the map
+func (repo *InMemoryPlaceRepo) RemovePostLocation(post_id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.removePost(post_id)
+	return nil
+}
+
+// GetPostsNear reads a page of the posts within a radius, newest first
+func (repo *InMemoryPlaceRepo) GetPostsNear(lat, lng, radius_km float64, cursor string, limit int) ([]models.LocatedPostDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var posts []models.LocatedPostDTO
+	repo.postsIndex.near(geo.Point{Lat: lat, Lng: lng}, radius_km, func(id string, _ float64) {
+		posts = append(posts, repo.posts[id])
+	})
+
+	pagination.Sort(posts, true, locatedPostCursor)
+	return pagination.Paginate(posts, cursor, limit, true, locatedPostCursor)
+}
+
+// GetPlacePosts reads a page of the posts at a place, newest first
+func (repo *InMemoryPlaceRepo) GetPlacePosts(place_id string, cursor string, limit int) ([]models.LocatedPostDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	located, exists := repo.placePosts[place_id]
+	if !exists {
+		located = newAdjacency()
+	}
+
+	edges, next, err := located.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	posts := make([]models.LocatedPostDTO, 0, len(edges))
+	for _, e := range edges {
+		posts = append(posts, repo.posts[e.userID])
+	}
+	return posts, next, nil
+}
+
+// CountPlacePosts counts the posts at a place
+func (repo *InMemoryPlaceRepo) CountPlacePosts(place_id string) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	located, exists := repo.placePosts[place_id]
+	if !exists {
+		return 0, nil
+	}
+	return located.len(), nil
+}
+
+func (repo *InMemoryPlaceRepo) removePost(post_id string) {
+	post, exists := repo.posts[post_id]
+	if !exists {
+		return
+	}
+
+	repo.postsIndex.remove(post_id)
+	delete(repo.posts, post_id)
+
+	located := repo.placePosts[post.PlaceId]
+	located.remove(post_id)
+	if located.len() == 0 {
+		delete(repo.placePosts, post.PlaceId)
+	}
+}
+
+func locatedPostCursor(post models.LocatedPostDTO) pagination.Cursor {
+	return pagination.Cursor{Time: post.CreatedAt, Id: post.PostId}
+}
+
+// foldPlaceName makes place names that only differ by case or spacing equal
+func foldPlaceName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type IPlaceRepository interface {
+	// Find the Place with the same name, ignoring case and spacing, within
+	// radius_km of the given one, or save the given one as a new Place
+	FindOrAddPlace(place models.PlaceDTO, radius_km float64) (models.PlaceDTO, error)
+
+	GetPlace(place_id string) (models.PlaceDTO, error)
+
+	// Put a Post on the map, moving it if it is already there
+	SetPostLocation(post models.LocatedPostDTO) error
+
+	// Take a Post off the map; nothing happens if it is not there
+	RemovePostLocation(post_id string) error
+
+	// Read a page of the Posts within radius_km of a point, newest first
+	GetPostsNear(lat, lng, radius_km float64, cursor string, limit int) (posts []models.LocatedPostDTO, next_cursor string, err error)
+
+	// Read a page of the Posts at a Place, newest first
+	GetPlacePosts(place_id string, cursor string, limit int) (posts []models.LocatedPostDTO, next_cursor string, err error)
+
+	// Count the Posts at a Place
+	CountPlacePosts(place_id string) (int, error)
+}
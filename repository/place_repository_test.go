@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/geo"
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOrAddPlace_MergesSameNameNearby(t *testing.T) {
+	repo := NewInMemoryPlaceRepo()
+
+	louvre, err := repo.FindOrAddPlace(models.PlaceDTO{Name: "Louvre Museum", Latitude: 48.8606, Longitude: 2.3376}, 0.2)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, louvre.Id)
+
+	// About 50 meters away, spelled differently
+	again, _ := repo.FindOrAddPlace(models.PlaceDTO{Name: " louvre  museum", Latitude: 48.8610, Longitude: 2.3380}, 0.2)
+	assert.Equal(t, louvre, again)
+
+	// Another name at the same spot, and the same name across town
+	pyramid, _ := repo.FindOrAddPlace(models.PlaceDTO{Name: "Louvre Pyramid", Latitude: 48.8606, Longitude: 2.3376}, 0.2)
+	assert.NotEqual(t, louvre.Id, pyramid.Id)
+
+	elsewhere, _ := repo.FindOrAddPlace(models.PlaceDTO{Name: "Louvre Museum", Latitude: 48.8800, Longitude: 2.3376}, 0.2)
+	assert.NotEqual(t, louvre.Id, elsewhere.Id)
+
+	stored, err := repo.GetPlace(louvre.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, louvre, stored)
+
+	_, err = repo.GetPlace("missing")
+	assert.EqualError(t, err, "place not found")
+}
+
+func TestPlacePosts(t *testing.T) {
+	repo := NewInMemoryPlaceRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	post1 := models.LocatedPostDTO{PostId: "post1", CreatedAt: base, PlaceId: "louvre", Latitude: 48.8606, Longitude: 2.3376}
+	post2 := models.LocatedPostDTO{PostId: "post2", CreatedAt: base.Add(time.Minute), PlaceId: "louvre", Latitude: 48.8606, Longitude: 2.3376}
+	post3 := models.LocatedPostDTO{PostId: "post3", CreatedAt: base.Add(2 * time.Minute), PlaceId: "eiffel", Latitude: 48.8584, Longitude: 2.2945}
+
+	for _, post := range []models.LocatedPostDTO{post1, post2, post3} {
+		assert.NoError(t, repo.SetPostLocation(post))
+	}
+
+	posts, next, err := repo.GetPlacePosts("louvre", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Equal(t, []models.LocatedPostDTO{post2, post1}, posts)
+
+	// Moving a post to another place
+	post1.PlaceId = "eiffel"
+	assert.NoError(t, repo.SetPostLocation(post1))
+
+	count, _ := repo.CountPlacePosts("louvre")
+	assert.Equal(t, 1, count)
+	count, _ = repo.CountPlacePosts("eiffel")
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, repo.RemovePostLocation("post2"))
+	assert.NoError(t, repo.RemovePostLocation("post2"))
+
+	count, _ = repo.CountPlacePosts("louvre")
+	assert.Equal(t, 0, count)
+
+	posts, _, _ = repo.GetPostsNear(48.8606, 2.3376, 10, "", 10)
+	assert.Equal(t, []models.LocatedPostDTO{post3, post1}, posts)
+}
+
+func TestGetPostsNear(t *testing.T) {
+	repo := NewInMemoryPlaceRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	random := rand.New(rand.NewSource(1))
+	center := geo.Point{Lat: 48.8566, Lng: 2.3522}
+
+	// Posts scattered within a few degrees of the center
+	var within []string
+	for i := 0; i < 2000; i++ {
+		post := models.LocatedPostDTO{
+			PostId:    fmt.Sprintf("post%d", i),
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			PlaceId:   "place",
+			Latitude:  center.Lat + (random.Float64()-0.5)*2,
+			Longitude: center.Lng + (random.Float64()-0.5)*2,
+		}
+		assert.NoError(t, repo.SetPostLocation(post))
+
+		if geo.Distance(center, geo.Point{Lat: post.Latitude, Lng: post.Longitude}) <= 20 {
+			within = append(within, post.PostId)
+		}
+	}
+
+	// Read every page and compare with a full scan
+	var found []string
+	cursor := ""
+	for {
+		posts, next, err := repo.GetPostsNear(center.Lat, center.Lng, 20, cursor, 25)
+		assert.NoError(t, err)
+
+		for i, post := range posts {
+			found = append(found, post.PostId)
+			if i > 0 {
+				assert.True(t, post.CreatedAt.Before(posts[i-1].CreatedAt))
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	sort.Strings(within)
+	sort.Strings(found)
+	assert.NotEmpty(t, within)
+	assert.Equal(t, within, found)
+}
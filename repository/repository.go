@@ -2,6 +2,7 @@ package repository
 
 import (
 	"image"
+	"time"
 
 	"github.com/anandh86/instagram/models"
 )
@@ -17,6 +18,9 @@ type IRepository interface {
 	// Get Image by ID
 	GetImageByID(imgId string) (img image.Image, err error)
 
+	// Delete an Image
+	DeleteImageByID(imgId string) error
+
 	/*------------------------------------------------------------------------
 	*                             Post
 	------------------------------------------------------------------------*/
@@ -27,22 +31,164 @@ type IRepository interface {
 	// Get Post Metadata by ID
 	GetPostMetaByID(post_id string) (postMeta models.PostMetaDTO, err error)
 
-	// Get All Post Metadata
+	// Get All Post Metadata, excluding the posts in the trash and the posts
+	// not published yet
 	GetAllPostMetas() ([]models.PostMetaDTO, error)
 
+	// Count the Posts of a User, excluding the posts in the trash and the
+	// posts not published yet
+	CountPostsByCreator(creator_id string) (int, error)
+
+	// Read a page of the Posts of a User, newest first, excluding the posts
+	// in the trash and the posts not published yet
+	GetPostMetasByCreator(creator_id string, cursor string, limit int) (posts []models.PostMetaDTO, next_cursor string, err error)
+
+	// Get the drafts and scheduled Posts of a User that are not in the
+	// trash, newest first
+	GetUnpublishedPostMetas(creator_id string) ([]models.PostMetaDTO, error)
+
+	// Get the scheduled Posts due by the given time that are not in the trash
+	GetDueScheduledPosts(at time.Time) ([]models.PostMetaDTO, error)
+
+	// Change the caption of a Post, along with the mentions it resolves to.
+	// Posts in the trash can't be changed.
+	SetPostCaption(post_id, caption string, mentions []models.MentionDTO) error
+
+	// Change who can comment on a Post and who can see it; empty settings
+	// are left as they are. Posts in the trash can't be changed.
+	SetPostSettings(post_id, comment_policy, visibility string) error
+
+	// Schedule a draft or scheduled Post to be published at the given time,
+	// or make it a draft again when nil
+	SchedulePost(post_id string, publish_at *time.Time) error
+
+	// Publish a draft or scheduled Post as of the given time, in a single
+	// step, so that a Post is only ever published once. Returns false if the
+	// Post was already published or is in the trash. The Post is left
+	// unannounced.
+	PublishPost(post_id string, at time.Time) (bool, error)
+
+	// Get the Posts published before the given time that were never
+	// announced and are not in the trash
+	GetUnannouncedPosts(before time.Time) ([]models.PostMetaDTO, error)
+
+	// Record that a published Post has been announced
+	MarkPostAnnounced(post_id string) error
+
+	// Move a Post to the trash as of the given time
+	DeletePostByID(post_id string, deleted_at time.Time) error
+
+	// Bring a Post back from the trash
+	RestorePostByID(post_id string) error
+
 	/*------------------------------------------------------------------------
 	*                             Comment
 	------------------------------------------------------------------------*/
 
-	// Save a Comment on a Post
-	SaveComment(comment models.CommentRequestDTO) (comment_id string, err error)
+	// Save a Comment on a Post, written at the given time
+	SaveComment(comment models.CommentRequestDTO, created_at time.Time) (comment_id string, err error)
 
 	// Retrieve a Comment on a Post
 	GetCommentByID(comment_id string) (comment models.CommentDTO, err error)
 
-	// Read all latest posts for a particular comment
-	GetPostLatestComments(post_id string, numberOfComments int) ([]models.CommentDTO, error)
+	// Read all latest posts for a particular comment, leaving out the comments
+	// of the excluded authors
+	GetPostLatestComments(post_id string, numberOfComments int, excluded_authors []string) ([]models.CommentDTO, error)
+
+	// Read every Comment on a Post, including the trashed ones, oldest first
+	GetPostComments(post_id string) ([]models.CommentDTO, error)
+
+	// Replace the content of a Comment and its mentions as of the given
+	// time, keeping the previous version
+	UpdateComment(comment_id string, content string, mentions []models.MentionDTO, edited_at time.Time) (comment models.CommentDTO, err error)
+
+	// Hide a Comment from its Post as of the given time, or show it again
+	// when nil
+	SetCommentHidden(comment_id string, hidden_at *time.Time) error
+
+	// Read the previous versions of a Comment, oldest first
+	GetCommentRevisions(comment_id string) ([]models.CommentRevisionDTO, error)
+
+	// Move a Comment on a Post to the trash as of the given time
+	DeleteCommentByID(comment_id string, deleted_by string, deleted_at time.Time) error
+
+	// Bring a Comment back from the trash
+	RestoreCommentByID(comment_id string) error
+
+	/*------------------------------------------------------------------------
+	*                             Social graph
+	------------------------------------------------------------------------*/
+
+	// Make a User follow another User
+	FollowUser(follower_id string, followee_id string) error
+
+	// Make a User stop following another User
+	UnfollowUser(follower_id string, followee_id string) error
+
+	// Check whether a User follows another User
+	IsFollowing(follower_id string, followee_id string) (bool, error)
+
+	// Read a page of the followers of a User, most recent first
+	GetFollowers(user_id string, cursor string, limit int) (followers []models.FollowDTO, next_cursor string, err error)
+
+	// Read a page of the Users a User follows, most recent first
+	GetFollowing(user_id string, cursor string, limit int) (following []models.FollowDTO, next_cursor string, err error)
+
+	// Count the followers of a User and the Users it follows
+	CountFollows(user_id string) (followers int, following int, err error)
+
+	// Save a request to follow a private User, replacing any previous request
+	// between the same Users
+	SaveFollowRequest(request models.FollowRequestDTO) error
+
+	// Get the request of a User to follow another User
+	GetFollowRequest(requester_id string, target_id string) (request models.FollowRequestDTO, err error)
+
+	// Withdraw the request of a User to follow another User, if any
+	DeleteFollowRequest(requester_id string, target_id string) error
+
+	// Read a page of the pending requests to follow a User, most recent first
+	GetPendingFollowRequests(target_id string, cursor string, limit int) (requests []models.FollowRequestDTO, next_cursor string, err error)
+
+	// Make a User block another User
+	BlockUser(blocker_id string, blocked_id string) error
+
+	// Lift the block of a User on another User
+	UnblockUser(blocker_id string, blocked_id string) error
+
+	// Check whether a User blocks another User
+	IsBlocked(blocker_id string, blocked_id string) (bool, error)
+
+	// Get the Users a User blocks and the Users blocking them, most recent first
+	GetBlocks(user_id string) (blocked []string, blocked_by []string, err error)
+
+	// Make a User mute another User
+	MuteUser(muter_id string, muted_id string) error
+
+	// Make a User stop muting another User
+	UnmuteUser(muter_id string, muted_id string) error
+
+	// Get the Users a User mutes, most recent first
+	GetMutedUsers(muter_id string) (muted []string, err error)
+
+	// Add a User to the close friends list of another User
+	AddCloseFriend(user_id string, friend_id string) error
+
+	// Take a User off the close friends list of another User
+	RemoveCloseFriend(user_id string, friend_id string) error
+
+	// Check whether a User is on the close friends list of another User
+	IsCloseFriend(user_id string, friend_id string) (bool, error)
+
+	// Get the close friends list of a User, most recently added first
+	GetCloseFriends(user_id string) (friends []string, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Trash
+	------------------------------------------------------------------------*/
 
-	// Delete a Comment on a Post
-	DeleteCommentByID(comment_id string) error
+	// Permanently remove the Posts and Comments trashed before the cutoff.
+	// Returns the ids of the Posts and Comments removed, including the
+	// Comments of the removed Posts.
+	PurgeDeletedBefore(cutoff time.Time) (post_ids []string, comment_ids []string, err error)
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// FileSessionRepo is a persistent implementation of ISessionRepository.
+// Sessions are served from memory and every change is written through to a
+// JSON file. Sessions that are revoked or expired are dropped on the next
+// write.
+type FileSessionRepo struct {
+	*InMemorySessionRepo
+
+	writeMu sync.Mutex
+	file    jsonFile
+}
+
+// NewFileSessionRepo opens the session store at path, creating it on first write
+func NewFileSessionRepo(path string) (*FileSessionRepo, error) {
+
+	// compile-time check to ensure we implement the interface
+	var _ ISessionRepository = (*FileSessionRepo)(nil)
+
+	repo := &FileSessionRepo{
+		InMemorySessionRepo: NewInMemorySessionRepo(),
+		file:                jsonFile{path: path},
+	}
+
+	var sessions []models.Session
+	if err := repo.file.load(&sessions); err != nil {
+		return nil, err
+	}
+	repo.loadSessions(sessions)
+
+	return repo, nil
+}
+
+// SaveSession saves a new session and persists the store
+func (repo *FileSessionRepo) SaveSession(session models.Session) (string, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	session.Id = uuid.New().String()
+	if err := repo.commit(session, session.CreatedAt); err != nil {
+		return "", err
+	}
+	return session.Id, nil
+}
+
+// RotateRefreshToken rotates the refresh token and persists the store
+func (repo *FileSessionRepo) RotateRefreshToken(sessionID, oldHash, newHash string, usedAt, expiresAt time.Time) error {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	session, err := repo.GetSessionByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := rotateRefreshToken(&session, oldHash, newHash, usedAt, expiresAt); err != nil {
+		return err
+	}
+	return repo.commit(session, usedAt)
+}
+
+// RevokeSession revokes the session and persists the store
+func (repo *FileSessionRepo) RevokeSession(sessionID string, revokedAt time.Time) error {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	session, err := repo.GetSessionByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if !revokeSession(&session, revokedAt) {
+		return nil
+	}
+	return repo.commit(session, revokedAt)
+}
+
+// commit writes a snapshot with session applied and only then stores it in
+// memory, so a failed write changes nothing. The other sessions that are
+// revoked or expired as of now can never be used again, so they are left out
+// of the snapshot and dropped from memory, and the store only grows with the
+// sessions in use. The caller must hold writeMu.
+func (repo *FileSessionRepo) commit(session models.Session, now time.Time) error {
+	var sessions, pruned []models.Session
+	for _, stored := range repo.allSessions() {
+		switch {
+		case stored.Id == session.Id:
+		case stored.RevokedAt != nil || !now.Before(stored.ExpiresAt):
+			pruned = append(pruned, stored)
+		default:
+			sessions = append(sessions, stored)
+		}
+	}
+	sessions = append(sessions, session)
+
+	if err := repo.file.save(sessions); err != nil {
+		return err
+	}
+	repo.dropSessions(pruned)
+	repo.putSession(session)
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// Number of replaced refresh tokens remembered per session for reuse detection
+const maxUsedRefreshHashes = 100
+
+// InMemorySessionRepo is an in-memory implementation of ISessionRepository
+type InMemorySessionRepo struct {
+	mu           sync.RWMutex
+	sessions     map[string]models.Session
+	userSessions map[string][]string
+}
+
+// NewInMemorySessionRepo creates a new instance of InMemorySessionRepo
+func NewInMemorySessionRepo() *InMemorySessionRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ ISessionRepository = (*InMemorySessionRepo)(nil)
+
+	return &InMemorySessionRepo{
+		sessions:     make(map[string]models.Session),
+		userSessions: make(map[string][]string),
+	}
+}
+
+// SaveSession saves a new session to the in-memory database
+func (repo *InMemorySessionRepo) SaveSession(session models.Session) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session.Id = uuid.New().String()
+	repo.sessions[session.Id] = session
+	repo.userSessions[session.UserId] = append(repo.userSessions[session.UserId], session.Id)
+	return session.Id, nil
+}
+
+// GetSessionByID retrieves a session by its ID
+func (repo *InMemorySessionRepo) GetSessionByID(sessionID string) (models.Session, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	session, exists := repo.sessions[sessionID]
+	if !exists {
+		return models.Session{}, errors.New("session not found")
+	}
+	return session, nil
+}
+
+// GetUserSessions retrieves all the sessions of a user
+func (repo *InMemorySessionRepo) GetUserSessions(userID string) ([]models.Session, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	sessions := make([]models.Session, 0, len(repo.userSessions[userID]))
+	for _, sessionID := range repo.userSessions[userID] {
+		sessions = append(sessions, repo.sessions[sessionID])
+	}
+	return sessions, nil
+}
+
+// RotateRefreshToken replaces the current refresh token of a session
+func (repo *InMemorySessionRepo) RotateRefreshToken(sessionID, oldHash, newHash string, usedAt, expiresAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session, exists := repo.sessions[sessionID]
+	if !exists {
+		return errors.New("session not found")
+	}
+
+	if err := rotateRefreshToken(&session, oldHash, newHash, usedAt, expiresAt); err != nil {
+		return err
+	}
+	repo.sessions[sessionID] = session
+
+	return nil
+}
+
+// RevokeSession marks a session as revoked
+func (repo *InMemorySessionRepo) RevokeSession(sessionID string, revokedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session, exists := repo.sessions[sessionID]
+	if !exists {
+		return errors.New("session not found")
+	}
+
+	if revokeSession(&session, revokedAt) {
+		repo.sessions[sessionID] = session
+	}
+	return nil
+}
+
+// rotateRefreshToken applies a refresh token rotation to session
+func rotateRefreshToken(session *models.Session, oldHash, newHash string, usedAt, expiresAt time.Time) error {
+	if session.RevokedAt != nil {
+		return errors.New("session revoked")
+	}
+
+	if session.RefreshTokenHash != oldHash {
+		if slices.Contains(session.UsedRefreshHashes, oldHash) {
+			return errors.New("token reused")
+		}
+		return errors.New("invalid token")
+	}
+
+	session.UsedRefreshHashes = append(slices.Clone(session.UsedRefreshHashes), oldHash)
+	if len(session.UsedRefreshHashes) > maxUsedRefreshHashes {
+		session.UsedRefreshHashes = session.UsedRefreshHashes[len(session.UsedRefreshHashes)-maxUsedRefreshHashes:]
+	}
+	session.RefreshTokenHash = newHash
+	session.LastUsedAt = usedAt
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+// revokeSession marks session as revoked, reporting whether it changed
+func revokeSession(session *models.Session, revokedAt time.Time) bool {
+	if session.RevokedAt != nil {
+		return false
+	}
+	session.RevokedAt = &revokedAt
+	return true
+}
+
+// allSessions returns every stored session, for snapshots
+func (repo *InMemorySessionRepo) allSessions() []models.Session {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	sessions := make([]models.Session, 0, len(repo.sessions))
+	for _, session := range repo.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// loadSessions replaces the stored sessions, for snapshots
+func (repo *InMemorySessionRepo) loadSessions(sessions []models.Session) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, session := range sessions {
+		repo.sessions[session.Id] = session
+		repo.userSessions[session.UserId] = append(repo.userSessions[session.UserId], session.Id)
+	}
+}
+
+// dropSessions removes sessions, for write-through stores that prune them
+func (repo *InMemorySessionRepo) dropSessions(sessions []models.Session) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, session := range sessions {
+		delete(repo.sessions, session.Id)
+
+		ids := slices.DeleteFunc(repo.userSessions[session.UserId], func(id string) bool {
+			return id == session.Id
+		})
+		if len(ids) == 0 {
+			delete(repo.userSessions, session.UserId)
+		} else {
+			repo.userSessions[session.UserId] = ids
+		}
+	}
+}
+
+// putSession stores a new or changed session, for write-through stores
+func (repo *InMemorySessionRepo) putSession(session models.Session) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.sessions[session.Id]; !exists {
+		repo.userSessions[session.UserId] = append(repo.userSessions[session.UserId], session.Id)
+	}
+	repo.sessions[session.Id] = session
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/anandh86/instagram/models"
+)
+
+type ISessionRepository interface {
+	// Save a new Session
+	SaveSession(session models.Session) (session_id string, err error)
+
+	// Get Session by ID
+	GetSessionByID(session_id string) (session models.Session, err error)
+
+	// Get all the Sessions of a User, including revoked ones. Persistent
+	// stores may drop the Sessions that are revoked or expired.
+	GetUserSessions(user_id string) ([]models.Session, error)
+
+	// Replace the current refresh token of a Session, provided old_hash is
+	// still current. Presenting a replaced token fails with "token reused".
+	RotateRefreshToken(session_id, old_hash, new_hash string, used_at, expires_at time.Time) error
+
+	// Revoke a Session, ending its refresh token family
+	RevokeSession(session_id string, revoked_at time.Time) error
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	repo := NewInMemorySessionRepo()
+	sessionID, _ := repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "hash1"})
+
+	now := time.Now()
+	assert.NoError(t, repo.RotateRefreshToken(sessionID, "hash1", "hash2", now, now.Add(time.Hour)))
+
+	session, err := repo.GetSessionByID(sessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, "hash2", session.RefreshTokenHash)
+	assert.Equal(t, []string{"hash1"}, session.UsedRefreshHashes)
+
+	err = repo.RotateRefreshToken(sessionID, "hash1", "hash3", now, now.Add(time.Hour))
+	assert.EqualError(t, err, "token reused")
+
+	err = repo.RotateRefreshToken(sessionID, "unknown", "hash3", now, now.Add(time.Hour))
+	assert.EqualError(t, err, "invalid token")
+}
+
+func TestRevokeSession(t *testing.T) {
+	repo := NewInMemorySessionRepo()
+	sessionID, _ := repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "hash1"})
+	_, _ = repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "other"})
+
+	assert.NoError(t, repo.RevokeSession(sessionID, time.Now()))
+
+	err := repo.RotateRefreshToken(sessionID, "hash1", "hash2", time.Now(), time.Now())
+	assert.EqualError(t, err, "session revoked")
+
+	sessions, err := repo.GetUserSessions("user123")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
+
+func TestFileSessionRepo_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	repo, err := NewFileSessionRepo(path)
+	assert.NoError(t, err)
+
+	sessionID, _ := repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "hash1"})
+	assert.NoError(t, repo.RotateRefreshToken(sessionID, "hash1", "hash2", time.Now(), time.Now().Add(time.Hour)))
+
+	reopened, err := NewFileSessionRepo(path)
+	assert.NoError(t, err)
+
+	// Reuse detection survives a restart
+	err = reopened.RotateRefreshToken(sessionID, "hash1", "hash3", time.Now(), time.Now())
+	assert.EqualError(t, err, "token reused")
+
+	sessions, _ := reopened.GetUserSessions("user123")
+	assert.Len(t, sessions, 1)
+}
+
+func TestFileSessionRepo_PrunesDeadSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileSessionRepo(path)
+	assert.NoError(t, err)
+
+	revoked, _ := repo.SaveSession(models.Session{UserId: "user123", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	expired, _ := repo.SaveSession(models.Session{UserId: "user123", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	live, _ := repo.SaveSession(models.Session{UserId: "user123", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	assert.NoError(t, repo.RevokeSession(revoked, now))
+
+	// The revoked session is kept until the next write
+	sessions, _ := repo.GetUserSessions("user123")
+	assert.Len(t, sessions, 3)
+
+	later := now.Add(2 * time.Minute)
+	latest, _ := repo.SaveSession(models.Session{UserId: "user123", CreatedAt: later, ExpiresAt: later.Add(time.Hour)})
+
+	for _, sessionID := range []string{revoked, expired} {
+		_, err := repo.GetSessionByID(sessionID)
+		assert.EqualError(t, err, "session not found")
+	}
+
+	reopened, err := NewFileSessionRepo(path)
+	assert.NoError(t, err)
+
+	sessions, _ = reopened.GetUserSessions("user123")
+	ids := []string{}
+	for _, session := range sessions {
+		ids = append(ids, session.Id)
+	}
+	assert.ElementsMatch(t, []string{live, latest}, ids)
+}
+
+func TestFileSessionRepo_FailedWriteChangesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	repo, err := NewFileSessionRepo(path)
+	assert.NoError(t, err)
+	sessionID, _ := repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "hash1"})
+
+	// Renaming the snapshot over a directory fails
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, os.Mkdir(path, 0o755))
+
+	_, err = repo.SaveSession(models.Session{UserId: "user123", RefreshTokenHash: "other"})
+	assert.Error(t, err)
+	assert.Error(t, repo.RotateRefreshToken(sessionID, "hash1", "hash2", time.Now(), time.Now().Add(time.Hour)))
+	assert.Error(t, repo.RevokeSession(sessionID, time.Now()))
+
+	// The old token is still current, so a retry succeeds once the disk recovers
+	session, _ := repo.GetSessionByID(sessionID)
+	assert.Equal(t, "hash1", session.RefreshTokenHash)
+	assert.Empty(t, session.UsedRefreshHashes)
+	assert.Nil(t, session.RevokedAt)
+
+	sessions, _ := repo.GetUserSessions("user123")
+	assert.Len(t, sessions, 1)
+
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, repo.RotateRefreshToken(sessionID, "hash1", "hash2", time.Now(), time.Now().Add(time.Hour)))
+}
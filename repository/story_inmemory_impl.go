@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// InMemoryStoryRepo is an in-memory implementation of IStoryRepository
+type InMemoryStoryRepo struct {
+	mu sync.RWMutex
+
+	stories map[string]models.StoryDTO
+
+	// Story ids of each user, oldest first
+	byAuthor map[string][]string
+
+	// Viewers of each story
+	views map[string]*adjacency
+}
+
+// NewInMemoryStoryRepo creates a new instance of InMemoryStoryRepo
+func NewInMemoryStoryRepo() *InMemoryStoryRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ IStoryRepository = (*InMemoryStoryRepo)(nil)
+
+	return &InMemoryStoryRepo{
+		stories:  make(map[string]models.StoryDTO),
+		byAuthor: make(map[string][]string),
+		views:    make(map[string]*adjacency),
+	}
+}
+
+// SaveStory saves a new story under a fresh id
+func (repo *InMemoryStoryRepo) SaveStory(story models.StoryDTO) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	story.Id = uuid.New().String()
+	repo.stories[story.Id] = story
+
+	// Stories are almost always saved in time order, so this is nearly
+	// always an append
+	ids := repo.byAuthor[story.AuthorId]
+	pos := len(ids)
+	for pos > 0 && repo.stories[ids[pos-1]].CreatedAt.After(story.CreatedAt) {
+		pos--
+	}
+	repo.byAuthor[story.AuthorId] = slices.Insert(ids, pos, story.Id)
+
+	return story.Id, nil
+}
+
+// GetStory retrieves a story by its ID
+func (repo *InMemoryStoryRepo) GetStory(storyID string) (models.StoryDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	story, exists := repo.stories[storyID]
+	if !exists {
+		return models.StoryDTO{}, errors.New("story not found")
+	}
+	return story, nil
+}
+
+// GetLiveStories lists the stories of a user that expire after the given
+// time, oldest first
+func (repo *InMemoryStoryRepo) GetLiveStories(authorID string, at time.Time) ([]models.StoryDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stories := []models.StoryDTO{}
+	for _, storyID := range repo.byAuthor[authorID] {
+		if story := repo.stories[storyID]; story.ExpiresAt.After(at) {
+			stories = append(stories, story)
+		}
+	}
+	return stories, nil
+}
+
+// DeleteStory removes a story and its views
+func (repo *InMemoryStoryRepo) DeleteStory(storyID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.stories[storyID]; !exists {
+		return errors.New("story not found")
+	}
+
+	repo.delete(storyID)
+	return nil
+}
+
+// DeleteExpiredStories removes the stories that expired by the given time,
+// along with their views
+func (repo *InMemoryStoryRepo) DeleteExpiredStories(at time.Time) ([]models.StoryDTO, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var expired []models.StoryDTO
+	for storyID, story := range repo.stories {
+		if !story.ExpiresAt.After(at) {
+			expired = append(expired, story)
+			repo.delete(storyID)
+		}
+	}
+	return expired, nil
+}
+
+// AddStoryView records a view, unless the user already viewed the story
+func (repo *InMemoryStoryRepo) AddStoryView(storyID string, userID string, at time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.stories[storyID]; !exists {
+		return false, errors.New("story not found")
+	}
+
+	views, exists := repo.views[storyID]
+	if !exists {
+		views = newAdjacency()
+		repo.views[storyID] = views
+	}
+	return views.add(userID, at), nil
+}
+
+// GetStoryViews reads a page of the viewers of a story, most recent first
+func (repo *InMemoryStoryRepo) GetStoryViews(storyID string, cursor string, limit int) ([]models.StoryViewDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	views, exists := repo.views[storyID]
+	if !exists {
+		views = newAdjacency()
+	}
+
+	edges, next, err := views.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	list := make([]models.StoryViewDTO, 0, len(edges))
+	for _, e := range edges {
+		list = append(list, models.StoryViewDTO{UserId: e.userID, ViewedAt: e.createdAt})
+	}
+	return list, next, nil
+}
+
+// CountStoryViews counts the viewers of each story
+func (repo *InMemoryStoryRepo) CountStoryViews(storyIDs []string) (map[string]int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	counts := make(map[string]int, len(storyIDs))
+	for _, storyID := range storyIDs {
+		if views, exists := repo.views[storyID]; exists {
+			counts[storyID] = views.len()
+		}
+	}
+	return counts, nil
+}
+
+// GetViewedStories tells which of the stories a user viewed
+func (repo *InMemoryStoryRepo) GetViewedStories(userID string, storyIDs []string) (map[string]bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	viewed := make(map[string]bool)
+	for _, storyID := range storyIDs {
+		if views, exists := repo.views[storyID]; exists && views.has(userID) {
+			viewed[storyID] = true
+		}
+	}
+	return viewed, nil
+}
+
+func (repo *InMemoryStoryRepo) delete(storyID string) {
+	story := repo.stories[storyID]
+
+	ids := slices.DeleteFunc(repo.byAuthor[story.AuthorId], func(id string) bool {
+		return id == storyID
+	})
+	if len(ids) == 0 {
+		delete(repo.byAuthor, story.AuthorId)
+	} else {
+		repo.byAuthor[story.AuthorId] = ids
+	}
+
+	delete(repo.views, storyID)
+	delete(repo.stories, storyID)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/anandh86/instagram/models"
+)
+
+type IStoryRepository interface {
+	// Save a new Story
+	SaveStory(story models.StoryDTO) (story_id string, err error)
+
+	// Retrieve a Story
+	GetStory(story_id string) (story models.StoryDTO, err error)
+
+	// Get the Stories of a User that are still live at the given time, oldest
+	// first
+	GetLiveStories(author_id string, at time.Time) ([]models.StoryDTO, error)
+
+	// Delete a Story along with its views
+	DeleteStory(story_id string) error
+
+	// Delete the Stories that expired by the given time, along with their
+	// views, returning them
+	DeleteExpiredStories(at time.Time) ([]models.StoryDTO, error)
+
+	// Record that a User viewed a Story. Returns false if they already had.
+	AddStoryView(story_id string, user_id string, at time.Time) (bool, error)
+
+	// Read a page of the viewers of a Story, most recent first
+	GetStoryViews(story_id string, cursor string, limit int) (views []models.StoryViewDTO, next_cursor string, err error)
+
+	// Count the viewers of each of the Stories
+	CountStoryViews(story_ids []string) (counts map[string]int, err error)
+
+	// Tell which of the Stories a User viewed
+	GetViewedStories(user_id string, story_ids []string) (viewed map[string]bool, err error)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func storyIds(stories []models.StoryDTO) []string {
+	ids := []string{}
+	for _, story := range stories {
+		ids = append(ids, story.Id)
+	}
+	return ids
+}
+
+func TestStories(t *testing.T) {
+	repo := NewInMemoryStoryRepo()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newStory := func(author_id string, at time.Time) string {
+		story_id, err := repo.SaveStory(models.StoryDTO{AuthorId: author_id, ImageId: "img", CreatedAt: at, ExpiresAt: at.Add(24 * time.Hour)})
+		assert.NoError(t, err)
+		return story_id
+	}
+
+	second := newStory("user1", start.Add(time.Hour))
+	first := newStory("user1", start)
+	third := newStory("user1", start.Add(2*time.Hour))
+	other := newStory("user2", start)
+
+	story, err := repo.GetStory(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", story.AuthorId)
+
+	live, err := repo.GetLiveStories("user1", start)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first, second, third}, storyIds(live))
+
+	live, _ = repo.GetLiveStories("user1", start.Add(24*time.Hour+30*time.Minute))
+	assert.Equal(t, []string{second, third}, storyIds(live))
+
+	// Expired stories are deleted with their views
+	expired, err := repo.DeleteExpiredStories(start.Add(25 * time.Hour))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{first, second, other}, storyIds(expired))
+
+	_, err = repo.GetStory(first)
+	assert.EqualError(t, err, "story not found")
+
+	assert.NoError(t, repo.DeleteStory(third))
+	live, _ = repo.GetLiveStories("user1", start)
+	assert.Empty(t, live)
+	assert.EqualError(t, repo.DeleteStory(third), "story not found")
+}
+
+func TestStoryViews(t *testing.T) {
+	repo := NewInMemoryStoryRepo()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	story_id, _ := repo.SaveStory(models.StoryDTO{AuthorId: "user1", CreatedAt: start, ExpiresAt: start.Add(24 * time.Hour)})
+	other_id, _ := repo.SaveStory(models.StoryDTO{AuthorId: "user1", CreatedAt: start, ExpiresAt: start.Add(24 * time.Hour)})
+
+	for i, viewer := range []string{"user2", "user3", "user4"} {
+		added, err := repo.AddStoryView(story_id, viewer, start.Add(time.Duration(i)*time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, added)
+	}
+
+	added, err := repo.AddStoryView(story_id, "user2", start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	_, err = repo.AddStoryView("missing", "user2", start)
+	assert.Error(t, err)
+
+	views, next, err := repo.GetStoryViews(story_id, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "user4", views[0].UserId)
+	assert.Equal(t, "user3", views[1].UserId)
+
+	views, next, _ = repo.GetStoryViews(story_id, next, 2)
+	assert.Len(t, views, 1)
+	assert.Equal(t, "user2", views[0].UserId)
+	assert.Empty(t, next)
+
+	counts, err := repo.CountStoryViews([]string{story_id, other_id})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{story_id: 3}, counts)
+
+	viewed, err := repo.GetViewedStories("user3", []string{story_id, other_id})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{story_id: true}, viewed)
+}
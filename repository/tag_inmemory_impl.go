@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+)
+
+// InMemoryTagRepo is an in-memory implementation of ITagRepository. Each
+// hashtag keeps the posts carrying it ordered by creation time, and each
+// post keeps its hashtags, so retagging a post only touches the hashtags
+// that changed.
+type InMemoryTagRepo struct {
+	mu sync.RWMutex
+
+	// Posts by hashtag
+	posts map[string]*adjacency
+
+	// Hashtags by post id
+	tags map[string][]string
+}
+
+// NewInMemoryTagRepo creates a new instance of InMemoryTagRepo
+func NewInMemoryTagRepo() *InMemoryTagRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ ITagRepository = (*InMemoryTagRepo)(nil)
+
+	return &InMemoryTagRepo{
+		posts: make(map[string]*adjacency),
+		tags:  make(map[string][]string),
+	}
+}
+
+// SetPostTags moves a post to the listings of its new hashtags
+func (repo *InMemoryTagRepo) SetPostTags(post models.TaggedPostDTO, tags []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, tag := range repo.tags[post.PostId] {
+		if slices.Contains(tags, tag) {
+			continue
+		}
+
+		tagged := repo.posts[tag]
+		tagged.remove(post.PostId)
+		if tagged.len() == 0 {
+			delete(repo.posts, tag)
+		}
+	}
+
+	for _, tag := range tags {
+		tagged, exists := repo.posts[tag]
+		if !exists {
+			tagged = newAdjacency()
+			repo.posts[tag] = tagged
+		}
+		tagged.add(post.PostId, post.CreatedAt)
+	}
+
+	if len(tags) == 0 {
+		delete(repo.tags, post.PostId)
+	} else {
+		repo.tags[post.PostId] = slices.Clone(tags)
+	}
+	return nil
+}
+
+// GetTaggedPosts reads a page of the posts with a hashtag, newest first
+func (repo *InMemoryTagRepo) GetTaggedPosts(tag string, cursor string, limit int) ([]models.TaggedPostDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tagged, exists := repo.posts[tag]
+	if !exists {
+		tagged = newAdjacency()
+	}
+
+	edges, next, err := tagged.page(cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	posts := make([]models.TaggedPostDTO, 0, len(edges))
+	for _, e := range edges {
+		posts = append(posts, models.TaggedPostDTO{PostId: e.userID, CreatedAt: e.createdAt})
+	}
+	return posts, next, nil
+}
+
+// CountTaggedPosts counts the posts with a hashtag
+func (repo *InMemoryTagRepo) CountTaggedPosts(tag string) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tagged, exists := repo.posts[tag]
+	if !exists {
+		return 0, nil
+	}
+	return tagged.len(), nil
+}
+
+// GetTagsByPrefix lists the hashtags starting with a prefix
+func (repo *InMemoryTagRepo) GetTagsByPrefix(prefix string) ([]models.TagCountDTO, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var tags []models.TagCountDTO
+	for tag, tagged := range repo.posts {
+		if strings.HasPrefix(tag, prefix) {
+			tags = append(tags, models.TagCountDTO{Tag: tag, PostCount: tagged.len()})
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type ITagRepository interface {
+	// Replace the hashtags of a Post; an empty list takes the Post out of
+	// the index
+	SetPostTags(post models.TaggedPostDTO, tags []string) error
+
+	// Read a page of the Posts with a hashtag, newest first
+	GetTaggedPosts(tag string, cursor string, limit int) (posts []models.TaggedPostDTO, next_cursor string, err error)
+
+	// Count the Posts with a hashtag
+	CountTaggedPosts(tag string) (int, error)
+
+	// Get the hashtags starting with a prefix, with how many Posts carry
+	// each, in no particular order
+	GetTagsByPrefix(prefix string) (tags []models.TagCountDTO, err error)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetPostTags_Retag(t *testing.T) {
+	repo := NewInMemoryTagRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	post1 := models.TaggedPostDTO{PostId: "post1", CreatedAt: base}
+	post2 := models.TaggedPostDTO{PostId: "post2", CreatedAt: base.Add(time.Minute)}
+
+	assert.NoError(t, repo.SetPostTags(post1, []string{"beach", "sunset"}))
+	assert.NoError(t, repo.SetPostTags(post2, []string{"beach"}))
+
+	posts, next, err := repo.GetTaggedPosts("beach", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Equal(t, []models.TaggedPostDTO{post2, post1}, posts)
+
+	// Editing the caption moves the post from one hashtag to another
+	assert.NoError(t, repo.SetPostTags(post1, []string{"sunset", "sea"}))
+
+	count, _ := repo.CountTaggedPosts("beach")
+	assert.Equal(t, 1, count)
+	count, _ = repo.CountTaggedPosts("sea")
+	assert.Equal(t, 1, count)
+
+	// No hashtags left takes the post out of the index
+	assert.NoError(t, repo.SetPostTags(post1, nil))
+
+	count, _ = repo.CountTaggedPosts("sunset")
+	assert.Equal(t, 0, count)
+	count, _ = repo.CountTaggedPosts("sea")
+	assert.Equal(t, 0, count)
+}
+
+func TestGetTaggedPosts_Pages(t *testing.T) {
+	repo := NewInMemoryTagRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"a", "b", "c"} {
+		_ = repo.SetPostTags(models.TaggedPostDTO{PostId: id, CreatedAt: base.Add(time.Duration(i) * time.Minute)}, []string{"beach"})
+	}
+
+	page, next, err := repo.GetTaggedPosts("beach", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "c", page[0].PostId)
+	assert.Equal(t, "b", page[1].PostId)
+
+	page, next, err = repo.GetTaggedPosts("beach", next, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "a", page[0].PostId)
+	assert.Empty(t, next)
+
+	_, _, err = repo.GetTaggedPosts("beach", "garbage", 2)
+	assert.EqualError(t, err, "invalid cursor")
+}
+
+func TestGetTagsByPrefix(t *testing.T) {
+	repo := NewInMemoryTagRepo()
+	base := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	_ = repo.SetPostTags(models.TaggedPostDTO{PostId: "post1", CreatedAt: base}, []string{"sunset", "sun"})
+	_ = repo.SetPostTags(models.TaggedPostDTO{PostId: "post2", CreatedAt: base}, []string{"sunset", "beach"})
+
+	tags, err := repo.GetTagsByPrefix("sun")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []models.TagCountDTO{{Tag: "sunset", PostCount: 2}, {Tag: "sun", PostCount: 1}}, tags)
+
+	tags, _ = repo.GetTagsByPrefix("moon")
+	assert.Empty(t, tags)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+)
+
+// InMemoryTimelineRepo is an in-memory implementation of ITimelineRepository.
+// Each timeline keeps only its most recent entries; older posts drop off the
+// end as new ones come in.
+type InMemoryTimelineRepo struct {
+	mu        sync.RWMutex
+	maxLength int
+	timelines map[string][]models.TimelineEntryDTO
+}
+
+// NewInMemoryTimelineRepo creates a new instance of InMemoryTimelineRepo
+// keeping up to maxLength entries per timeline
+func NewInMemoryTimelineRepo(maxLength int) *InMemoryTimelineRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ ITimelineRepository = (*InMemoryTimelineRepo)(nil)
+
+	return &InMemoryTimelineRepo{
+		maxLength: maxLength,
+		timelines: make(map[string][]models.TimelineEntryDTO),
+	}
+}
+
+// AddToTimeline inserts a post in a timeline, keeping it newest first
+func (repo *InMemoryTimelineRepo) AddToTimeline(userID string, entry models.TimelineEntryDTO) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	timeline := repo.timelines[userID]
+
+	for _, existing := range timeline {
+		if existing.PostId == entry.PostId {
+			return nil
+		}
+	}
+
+	pos := sort.Search(len(timeline), func(i int) bool {
+		return newerEntry(entry, timeline[i])
+	})
+
+	// Too old to make it into a full timeline
+	if pos >= repo.maxLength {
+		return nil
+	}
+
+	timeline = append(timeline, models.TimelineEntryDTO{})
+	copy(timeline[pos+1:], timeline[pos:])
+	timeline[pos] = entry
+
+	if len(timeline) > repo.maxLength {
+		timeline = timeline[:repo.maxLength]
+	}
+
+	repo.timelines[userID] = timeline
+	return nil
+}
+
+// RemoveAuthorFromTimeline drops the posts of an author from a timeline
+func (repo *InMemoryTimelineRepo) RemoveAuthorFromTimeline(userID string, authorID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	timeline := repo.timelines[userID]
+
+	kept := timeline[:0]
+	for _, entry := range timeline {
+		if entry.AuthorId != authorID {
+			kept = append(kept, entry)
+		}
+	}
+	clear(timeline[len(kept):])
+
+	repo.timelines[userID] = kept
+	return nil
+}
+
+// GetTimeline reads a page of a timeline, newest first
+func (repo *InMemoryTimelineRepo) GetTimeline(userID string, cursor string, limit int) ([]models.TimelineEntryDTO, string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	page, next, err := pagination.Paginate(repo.timelines[userID], cursor, limit, true, timelineCursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Copy out of the stored timeline, which keeps changing
+	return append([]models.TimelineEntryDTO(nil), page...), next, nil
+}
+
+// timelineCursor is the pagination key of a timeline entry
+func timelineCursor(entry models.TimelineEntryDTO) pagination.Cursor {
+	return pagination.Cursor{Time: entry.CreatedAt, Id: entry.PostId}
+}
+
+// newerEntry orders timeline entries newest first, with ties broken by post id
+func newerEntry(a, b models.TimelineEntryDTO) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.PostId > b.PostId
+}
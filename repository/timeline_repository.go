@@ -0,0 +1,14 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type ITimelineRepository interface {
+	// Add a Post to the timeline of a User; adding it again has no effect
+	AddToTimeline(user_id string, entry models.TimelineEntryDTO) error
+
+	// Remove every Post of an author from the timeline of a User
+	RemoveAuthorFromTimeline(user_id string, author_id string) error
+
+	// Read a page of the timeline of a User, newest first
+	GetTimeline(user_id string, cursor string, limit int) (entries []models.TimelineEntryDTO, next_cursor string, err error)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddToTimeline_KeepsNewestEntries(t *testing.T) {
+	repo := NewInMemoryTimelineRepo(3)
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		entry := models.TimelineEntryDTO{PostId: fmt.Sprintf("post%d", i), CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		assert.NoError(t, repo.AddToTimeline("user1", entry))
+	}
+	// Adding the same post twice has no effect
+	_ = repo.AddToTimeline("user1", models.TimelineEntryDTO{PostId: "post4", CreatedAt: start.Add(4 * time.Minute)})
+
+	entries, next, err := repo.GetTimeline("user1", "", 10)
+
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "post4", entries[0].PostId)
+	assert.Equal(t, "post2", entries[2].PostId)
+}
+
+func TestRemoveAuthorFromTimeline(t *testing.T) {
+	repo := NewInMemoryTimelineRepo(10)
+	now := time.Now()
+
+	_ = repo.AddToTimeline("user1", models.TimelineEntryDTO{PostId: "post1", AuthorId: "alice", CreatedAt: now})
+	_ = repo.AddToTimeline("user1", models.TimelineEntryDTO{PostId: "post2", AuthorId: "carol", CreatedAt: now})
+
+	assert.NoError(t, repo.RemoveAuthorFromTimeline("user1", "alice"))
+
+	entries, _, _ := repo.GetTimeline("user1", "", 10)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "post2", entries[0].PostId)
+}
+
+func TestGetTimeline_Paginates(t *testing.T) {
+	repo := NewInMemoryTimelineRepo(10)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		_ = repo.AddToTimeline("user1", models.TimelineEntryDTO{PostId: fmt.Sprintf("post%d", i), CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	page, next, err := repo.GetTimeline("user1", "", 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, next)
+
+	page, next, err = repo.GetTimeline("user1", next, 2)
+
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "post0", page[0].PostId)
+	assert.Empty(t, next)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// FileUserRepo is a persistent implementation of IUserRepository. Users are
+// served from memory and every change is written through to a JSON file.
+type FileUserRepo struct {
+	*InMemoryUserRepo
+
+	writeMu sync.Mutex
+	file    jsonFile
+}
+
+// NewFileUserRepo opens the user store at path, creating it on first write
+func NewFileUserRepo(path string) (*FileUserRepo, error) {
+
+	// compile-time check to ensure we implement the interface
+	var _ IUserRepository = (*FileUserRepo)(nil)
+
+	repo := &FileUserRepo{
+		InMemoryUserRepo: NewInMemoryUserRepo(),
+		file:             jsonFile{path: path},
+	}
+
+	var users []models.User
+	if err := repo.file.load(&users); err != nil {
+		return nil, err
+	}
+	repo.loadUsers(users)
+
+	return repo, nil
+}
+
+// SaveUser saves a new user and persists the store
+func (repo *FileUserRepo) SaveUser(user models.User) (string, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	if _, err := repo.GetUserByUsername(user.Username); err == nil {
+		return "", errors.New("username taken")
+	}
+
+	user.Id = uuid.New().String()
+	if err := repo.commit(user); err != nil {
+		return "", err
+	}
+	return user.Id, nil
+}
+
+// SetUserProfile changes the given profile fields of a stored user and
+// persists the store
+func (repo *FileUserRepo) SetUserProfile(userID string, profile models.ProfileRequestDTO) (models.User, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	setProfile(&user, profile)
+	if err := repo.commit(user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SetUserAvatar replaces the avatar of a stored user and persists the store
+func (repo *FileUserRepo) SetUserAvatar(userID string, avatar map[int]string) (map[int]string, error) {
+	repo.writeMu.Lock()
+	defer repo.writeMu.Unlock()
+
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := user.Avatar
+	user.Avatar = avatar
+	if err := repo.commit(user); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// commit writes a snapshot with user applied and only then stores it in
+// memory, so a failed write changes nothing. The caller must hold writeMu.
+func (repo *FileUserRepo) commit(user models.User) error {
+	users := repo.allUsers()
+	index := slices.IndexFunc(users, func(stored models.User) bool {
+		return stored.Id == user.Id
+	})
+	if index < 0 {
+		users = append(users, user)
+	} else {
+		users[index] = user
+	}
+
+	if err := repo.file.save(users); err != nil {
+		return err
+	}
+	repo.putUser(user)
+	return nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/google/uuid"
+)
+
+// InMemoryUserRepo is an in-memory implementation of IUserRepository
+type InMemoryUserRepo struct {
+	mu          sync.RWMutex
+	users       map[string]models.User
+	usernameIdx map[string]string
+}
+
+// NewInMemoryUserRepo creates a new instance of InMemoryUserRepo
+func NewInMemoryUserRepo() *InMemoryUserRepo {
+
+	// compile-time check to ensure we implement the interface
+	var _ IUserRepository = (*InMemoryUserRepo)(nil)
+
+	return &InMemoryUserRepo{
+		users:       make(map[string]models.User),
+		usernameIdx: make(map[string]string),
+	}
+}
+
+// SaveUser saves a new user to the in-memory database
+func (repo *InMemoryUserRepo) SaveUser(user models.User) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.usernameIdx[user.Username]; exists {
+		return "", errors.New("username taken")
+	}
+
+	user.Id = uuid.New().String()
+	repo.users[user.Id] = user
+	repo.usernameIdx[user.Username] = user.Id
+	return user.Id, nil
+}
+
+// GetUserByID retrieves a user by its ID
+func (repo *InMemoryUserRepo) GetUserByID(userID string) (models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	user, exists := repo.users[userID]
+	if !exists {
+		return models.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user by its username
+func (repo *InMemoryUserRepo) GetUserByUsername(username string) (models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	userID, exists := repo.usernameIdx[username]
+	if !exists {
+		return models.User{}, errors.New("user not found")
+	}
+	return repo.users[userID], nil
+}
+
+// SetUserProfile changes the given profile fields of a stored user
+func (repo *InMemoryUserRepo) SetUserProfile(userID string, profile models.ProfileRequestDTO) (models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.users[userID]
+	if !exists {
+		return models.User{}, errors.New("user not found")
+	}
+
+	setProfile(&user, profile)
+	repo.users[userID] = user
+	return user, nil
+}
+
+// SetUserAvatar replaces the avatar of a stored user
+func (repo *InMemoryUserRepo) SetUserAvatar(userID string, avatar map[int]string) (map[int]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	user, exists := repo.users[userID]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+
+	previous := user.Avatar
+	user.Avatar = avatar
+	repo.users[userID] = user
+	return previous, nil
+}
+
+// GetAllUsers returns every stored user
+func (repo *InMemoryUserRepo) GetAllUsers() ([]models.User, error) {
+	return repo.allUsers(), nil
+}
+
+// setProfile applies the profile fields that are set to user
+func setProfile(user *models.User, profile models.ProfileRequestDTO) {
+	if profile.DisplayName != nil {
+		user.DisplayName = *profile.DisplayName
+	}
+	if profile.Bio != nil {
+		user.Bio = *profile.Bio
+	}
+	if profile.Website != nil {
+		user.Website = *profile.Website
+	}
+	if profile.Private != nil {
+		user.Private = *profile.Private
+	}
+}
+
+// allUsers returns every stored user, for snapshots
+func (repo *InMemoryUserRepo) allUsers() []models.User {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	users := make([]models.User, 0, len(repo.users))
+	for _, user := range repo.users {
+		users = append(users, user)
+	}
+	return users
+}
+
+// loadUsers replaces the stored users, for snapshots
+func (repo *InMemoryUserRepo) loadUsers(users []models.User) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, user := range users {
+		repo.users[user.Id] = user
+		repo.usernameIdx[user.Username] = user.Id
+	}
+}
+
+// putUser stores a new or changed user, for write-through stores
+func (repo *InMemoryUserRepo) putUser(user models.User) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.users[user.Id] = user
+	repo.usernameIdx[user.Username] = user.Id
+}
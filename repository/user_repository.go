@@ -0,0 +1,23 @@
+package repository
+
+import "github.com/anandh86/instagram/models"
+
+type IUserRepository interface {
+	// Save a new User; usernames are unique
+	SaveUser(user models.User) (user_id string, err error)
+
+	// Get User by ID
+	GetUserByID(user_id string) (user models.User, err error)
+
+	// Get User by username
+	GetUserByUsername(username string) (user models.User, err error)
+
+	// Change the profile fields of a User that are set, keeping the others
+	SetUserProfile(user_id string, profile models.ProfileRequestDTO) (user models.User, err error)
+
+	// Replace the avatar of an existing User, returning the one it replaced
+	SetUserAvatar(user_id string, avatar map[int]string) (previous map[int]string, err error)
+
+	// Get every User, in no particular order
+	GetAllUsers() ([]models.User, error)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveUser(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	user := models.User{Username: "alice", PasswordHash: "hash", CreatedAt: time.Now()}
+
+	userID, err := repo.SaveUser(user)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, userID)
+
+	byID, err := repo.GetUserByID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", byID.Username)
+
+	byUsername, err := repo.GetUserByUsername("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, byUsername.Id)
+}
+
+func TestSaveUser_UsernameTaken(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	_, _ = repo.SaveUser(models.User{Username: "alice"})
+
+	_, err := repo.SaveUser(models.User{Username: "alice"})
+
+	assert.EqualError(t, err, "username taken")
+}
+
+func TestGetUserByUsername_NotFound(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+
+	_, err := repo.GetUserByUsername("nobody")
+
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestSetUserProfile_KeepsOtherFields(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	userID, _ := repo.SaveUser(models.User{Username: "alice", DisplayName: "Alice"})
+
+	bio, private := "hello", true
+	user, err := repo.SetUserProfile(userID, models.ProfileRequestDTO{Bio: &bio})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", user.Bio)
+
+	// Another update of other fields doesn't undo the first
+	_, err = repo.SetUserProfile(userID, models.ProfileRequestDTO{Private: &private})
+	assert.NoError(t, err)
+
+	user, _ = repo.GetUserByID(userID)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "Alice", user.DisplayName)
+	assert.Equal(t, "hello", user.Bio)
+	assert.True(t, user.Private)
+}
+
+func TestSetUserAvatar(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+	userID, _ := repo.SaveUser(models.User{Username: "alice"})
+
+	previous, err := repo.SetUserAvatar(userID, map[int]string{64: "img1"})
+	assert.NoError(t, err)
+	assert.Empty(t, previous)
+
+	// A profile update keeps the new avatar
+	bio := "hello"
+	_, err = repo.SetUserProfile(userID, models.ProfileRequestDTO{Bio: &bio})
+	assert.NoError(t, err)
+
+	previous, err = repo.SetUserAvatar(userID, map[int]string{64: "img2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{64: "img1"}, previous)
+
+	user, _ := repo.GetUserByID(userID)
+	assert.Equal(t, map[int]string{64: "img2"}, user.Avatar)
+	assert.Equal(t, "hello", user.Bio)
+
+	_, err = repo.SetUserAvatar("missing", nil)
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestSetUserProfile_NotFound(t *testing.T) {
+	repo := NewInMemoryUserRepo()
+
+	_, err := repo.SetUserProfile("missing", models.ProfileRequestDTO{})
+
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestFileUserRepo_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	repo, err := NewFileUserRepo(path)
+	assert.NoError(t, err)
+
+	userID, err := repo.SaveUser(models.User{Username: "alice", PasswordHash: "hash"})
+	assert.NoError(t, err)
+
+	reopened, err := NewFileUserRepo(path)
+	assert.NoError(t, err)
+
+	user, err := reopened.GetUserByUsername("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.Id)
+	assert.Equal(t, "hash", user.PasswordHash)
+
+	_, err = reopened.SaveUser(models.User{Username: "alice"})
+	assert.EqualError(t, err, "username taken")
+}
+
+func TestFileUserRepo_FailedWriteChangesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	repo, err := NewFileUserRepo(path)
+	assert.NoError(t, err)
+	userID, _ := repo.SaveUser(models.User{Username: "alice", Bio: "hello"})
+
+	// Renaming the snapshot over a directory fails
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, os.Mkdir(path, 0o755))
+
+	_, err = repo.SaveUser(models.User{Username: "bob"})
+	assert.Error(t, err)
+	changed := "changed"
+	_, err = repo.SetUserProfile(userID, models.ProfileRequestDTO{Bio: &changed})
+	assert.Error(t, err)
+	_, err = repo.SetUserAvatar(userID, map[int]string{64: "img1"})
+	assert.Error(t, err)
+
+	// The failed registration leaves the username free
+	_, err = repo.GetUserByUsername("bob")
+	assert.EqualError(t, err, "user not found")
+
+	user, _ := repo.GetUserByID(userID)
+	assert.Equal(t, "hello", user.Bio)
+	assert.Empty(t, user.Avatar)
+
+	assert.NoError(t, os.Remove(path))
+	_, err = repo.SaveUser(models.User{Username: "bob"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,267 @@
+// Package search is an in-process full-text index of short texts, such as
+// captions, comments and user names, ranked with BM25
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BM25 parameters: how quickly repeating a term stops adding to the score,
+// and how much long documents are penalized
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Most words the last word of a query is expanded to when it is a prefix
+const maxPrefixExpansions = 50
+
+// Hit is a document matching a query
+type Hit struct {
+	Id    string
+	Score float64
+}
+
+// Index is an inverted index of documents by the stems of their words. It
+// is safe for concurrent use; documents can be added, replaced and removed
+// at any time.
+type Index struct {
+	mu sync.RWMutex
+
+	docs map[string]*document
+
+	// Term frequencies by term, then document id
+	postings map[string]map[string]int
+
+	// Sum of the lengths of the documents, for the average length
+	totalLength int
+
+	// Distinct words of all documents, sorted, for prefix queries, and the
+	// number of documents using each
+	words    []string
+	wordRefs map[string]int
+}
+
+type document struct {
+	terms  map[string]int
+	words  []string
+	length int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]int),
+		wordRefs: make(map[string]int),
+	}
+}
+
+// Add indexes the text of a document, replacing any previous text
+func (idx *Index) Add(id, text string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return
+	}
+
+	doc := &document{terms: make(map[string]int), length: len(tokens)}
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		doc.terms[t.term]++
+		if !seen[t.word] {
+			seen[t.word] = true
+			doc.words = append(doc.words, t.word)
+		}
+	}
+
+	for term, tf := range doc.terms {
+		postings, exists := idx.postings[term]
+		if !exists {
+			postings = make(map[string]int)
+			idx.postings[term] = postings
+		}
+		postings[id] = tf
+	}
+
+	for _, word := range doc.words {
+		idx.addWord(word)
+	}
+
+	idx.totalLength += doc.length
+	idx.docs[id] = doc
+}
+
+// Remove takes a document out of the index, if it is there
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+// Len is the number of documents in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Search finds the documents containing every word of the query, best match
+// first, with ties broken by id. Words are matched by their stem, so
+// "running" finds "runs". Unless the query ends with a space or punctuation,
+// its last word also matches the words it is the start of, so results can
+// be shown while the user types.
+func (idx *Index) Search(query string) []Hit {
+	words := splitWords(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(query)
+	prefix := unicode.IsLetter(last) || unicode.IsDigit(last) || unicode.In(last, unicode.Mn, unicode.Mc)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Each group holds the terms a word of the query can match
+	var groups [][]string
+	for i, word := range words {
+		if prefix && i == len(words)-1 {
+			groups = append(groups, idx.expand(word))
+		} else if !stopWords[word] {
+			groups = append(groups, []string{Stem(word)})
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// Keep the documents matching every group
+	var candidates map[string]bool
+	for _, terms := range groups {
+		matching := make(map[string]bool)
+		for _, term := range terms {
+			for id := range idx.postings[term] {
+				if candidates == nil || candidates[id] {
+					matching[id] = true
+				}
+			}
+		}
+		candidates = matching
+	}
+
+	hits := make([]Hit, 0, len(candidates))
+	for id := range candidates {
+		score := 0.0
+		for _, terms := range groups {
+			best := 0.0
+			for _, term := range terms {
+				best = math.Max(best, idx.bm25(term, id))
+			}
+			score += best
+		}
+		hits = append(hits, Hit{Id: id, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
+
+	return hits
+}
+
+// expand lists the terms a word being typed can match: its own stem, and
+// those of the indexed words it is the start of
+func (idx *Index) expand(prefix string) []string {
+	terms := []string{Stem(prefix)}
+	seen := map[string]bool{terms[0]: true}
+
+	start := sort.SearchStrings(idx.words, prefix)
+	for i := start; i < len(idx.words) && i-start < maxPrefixExpansions; i++ {
+		if !strings.HasPrefix(idx.words[i], prefix) {
+			break
+		}
+
+		term := Stem(idx.words[i])
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// bm25 scores how well a term matches a document
+func (idx *Index) bm25(term, id string) float64 {
+	tf := float64(idx.postings[term][id])
+	if tf == 0 {
+		return 0
+	}
+
+	n := float64(len(idx.docs))
+	df := float64(len(idx.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	avgLength := float64(idx.totalLength) / n
+	length := float64(idx.docs[id].length)
+
+	return idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+}
+
+func (idx *Index) remove(id string) {
+	doc, exists := idx.docs[id]
+	if !exists {
+		return
+	}
+
+	for term := range doc.terms {
+		postings := idx.postings[term]
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	for _, word := range doc.words {
+		idx.dropWord(word)
+	}
+
+	idx.totalLength -= doc.length
+	delete(idx.docs, id)
+}
+
+func (idx *Index) addWord(word string) {
+	if idx.wordRefs[word] == 0 {
+		pos := sort.SearchStrings(idx.words, word)
+		idx.words = append(idx.words, "")
+		copy(idx.words[pos+1:], idx.words[pos:])
+		idx.words[pos] = word
+	}
+	idx.wordRefs[word]++
+}
+
+func (idx *Index) dropWord(word string) {
+	idx.wordRefs[word]--
+	if idx.wordRefs[word] > 0 {
+		return
+	}
+
+	delete(idx.wordRefs, word)
+	pos := sort.SearchStrings(idx.words, word)
+	idx.words = append(idx.words[:pos], idx.words[pos+1:]...)
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hitIds(hits []Hit) []string {
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
+
+func TestSearch_StemsAndRanks(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("a", "Running by the sea at sunset")
+	idx.Add("b", "Runners running a marathon, then running home")
+	idx.Add("c", "A quiet morning in the mountains")
+
+	// "runs" and "running" share a stem; b repeats it so ranks first
+	assert.Equal(t, []string{"b", "a"}, hitIds(idx.Search("runs ")))
+
+	// Every word of the query must match
+	assert.Equal(t, []string{"a"}, hitIds(idx.Search("running sea ")))
+	assert.Empty(t, idx.Search("running mountains "))
+
+	// Stop words are ignored
+	assert.Equal(t, []string{"c"}, hitIds(idx.Search("the mountains ")))
+	assert.Empty(t, idx.Search("the "))
+}
+
+func TestSearch_Prefix(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("a", "Sunset over 東京")
+	idx.Add("b", "Sunday brunch")
+	idx.Add("c", "Sun is out")
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, hitIds(idx.Search("Sun")))
+	assert.Equal(t, []string{"c"}, hitIds(idx.Search("sun ")))
+	assert.Equal(t, []string{"b"}, hitIds(idx.Search("brunch sund")))
+	assert.Equal(t, []string{"a"}, hitIds(idx.Search("東")))
+
+	// A word being typed still matches other forms of itself
+	idx.Add("d", "Running late")
+	assert.Equal(t, []string{"d"}, hitIds(idx.Search("runs")))
+}
+
+func TestIndex_ReplaceAndRemove(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("a", "beach day")
+	idx.Add("b", "beach night")
+
+	idx.Add("a", "mountain day")
+	assert.Equal(t, []string{"b"}, hitIds(idx.Search("beach")))
+	assert.Equal(t, []string{"a"}, hitIds(idx.Search("mount")))
+
+	idx.Remove("a")
+	assert.Empty(t, idx.Search("mount"))
+	assert.Empty(t, idx.Search("day"))
+	assert.Equal(t, 1, idx.Len())
+
+	// Nothing worth indexing leaves the document out
+	idx.Add("c", "the, and")
+	assert.Equal(t, 1, idx.Len())
+}
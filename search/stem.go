@@ -0,0 +1,276 @@
+package search
+
+// Stem reduces an English word to its stem with the Porter stemming
+// algorithm, so that "connect", "connected" and "connections" all index as
+// "connect". The word must be lowercase; words that are not plain ASCII
+// letters, or shorter than three letters, are returned as they are.
+//
+// This follows the reference implementation by Martin Porter, including its
+// departures from the published algorithm ("-bli" and "-logi" in step 2).
+func Stem(word string) string {
+	if len(word) < 3 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word), k: len(word) - 1}
+	s.step1ab()
+	if s.k > 0 {
+		s.step1c()
+		s.step2()
+		s.step3()
+		s.step4()
+		s.step5()
+	}
+	return string(s.b[:s.k+1])
+}
+
+// stemmer holds a word being stemmed: b[0..k] is the current word, and j
+// marks the end of the stem once a suffix is matched by ends
+type stemmer struct {
+	b    []byte
+	k, j int
+}
+
+// cons tells whether b[i] is a consonant
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// m measures the number of consonant sequences in b[0..j]. With [C] an
+// optional consonant sequence and [V] an optional vowel sequence, a word is
+// [C](VC){m}[V].
+func (s *stemmer) m() int {
+	n, i := 0, 0
+	for {
+		if i > s.j {
+			return n
+		}
+		if !s.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > s.j {
+				return n
+			}
+			if s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > s.j {
+				return n
+			}
+			if !s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem tells whether b[0..j] contains a vowel
+func (s *stemmer) vowelInStem() bool {
+	for i := 0; i <= s.j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleC tells whether b[j-1..j] is a double consonant
+func (s *stemmer) doubleC(j int) bool {
+	return j >= 1 && s.b[j] == s.b[j-1] && s.cons(j)
+}
+
+// cvc tells whether b[i-2..i] is consonant, vowel, consonant with the last
+// consonant not w, x or y, as in "hop" but not "snow"
+func (s *stemmer) cvc(i int) bool {
+	if i < 2 || !s.cons(i) || s.cons(i-1) || !s.cons(i-2) {
+		return false
+	}
+	switch s.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends tells whether b[0..k] ends with suffix, pointing j before it if so
+func (s *stemmer) ends(suffix string) bool {
+	n := len(suffix)
+	if n > s.k+1 || string(s.b[s.k-n+1:s.k+1]) != suffix {
+		return false
+	}
+	s.j = s.k - n
+	return true
+}
+
+// setTo replaces b[j+1..k] with suffix
+func (s *stemmer) setTo(suffix string) {
+	s.b = append(s.b[:s.j+1], suffix...)
+	s.k = s.j + len(suffix)
+}
+
+// r replaces the matched suffix when the stem has a consonant sequence
+func (s *stemmer) r(suffix string) {
+	if s.m() > 0 {
+		s.setTo(suffix)
+	}
+}
+
+// step1ab removes plurals and -ed or -ing
+func (s *stemmer) step1ab() {
+	if s.b[s.k] == 's' {
+		if s.ends("sses") {
+			s.k -= 2
+		} else if s.ends("ies") {
+			s.setTo("i")
+		} else if s.b[s.k-1] != 's' {
+			s.k--
+		}
+	}
+
+	if s.ends("eed") {
+		if s.m() > 0 {
+			s.k--
+		}
+	} else if (s.ends("ed") || s.ends("ing")) && s.vowelInStem() {
+		s.k = s.j
+		switch {
+		case s.ends("at"):
+			s.setTo("ate")
+		case s.ends("bl"):
+			s.setTo("ble")
+		case s.ends("iz"):
+			s.setTo("ize")
+		case s.doubleC(s.k):
+			s.k--
+			switch s.b[s.k] {
+			case 'l', 's', 'z':
+				s.k++
+			}
+		default:
+			s.j = s.k
+			if s.m() == 1 && s.cvc(s.k) {
+				s.setTo("e")
+			}
+		}
+	}
+}
+
+// step1c turns a terminal y into i when there is another vowel in the stem
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.vowelInStem() {
+		s.b[s.k] = 'i'
+	}
+}
+
+// suffixRule replaces a suffix with another one
+type suffixRule struct {
+	suffix, replacement string
+}
+
+// Double suffixes mapped to single ones, by the next to last letter
+var step2Rules = map[byte][]suffixRule{
+	'a': {{"ational", "ate"}, {"tional", "tion"}},
+	'c': {{"enci", "ence"}, {"anci", "ance"}},
+	'e': {{"izer", "ize"}},
+	'l': {{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}},
+	'o': {{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}},
+	's': {{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}},
+	't': {{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}},
+	'g': {{"logi", "log"}},
+}
+
+// Suffixes such as -ful and -ness, by their last letter
+var step3Rules = map[byte][]suffixRule{
+	'e': {{"icate", "ic"}, {"ative", ""}, {"alize", "al"}},
+	'i': {{"iciti", "ic"}},
+	'l': {{"ical", "ic"}, {"ful", ""}},
+	's': {{"ness", ""}},
+}
+
+// Suffixes removed from long stems, by the next to last letter
+var step4Suffixes = map[byte][]string{
+	'a': {"al"},
+	'c': {"ance", "ence"},
+	'e': {"er"},
+	'i': {"ic"},
+	'l': {"able", "ible"},
+	'n': {"ant", "ement", "ment", "ent"},
+	'o': {"ion", "ou"},
+	's': {"ism"},
+	't': {"ate", "iti"},
+	'u': {"ous"},
+	'v': {"ive"},
+	'z': {"ize"},
+}
+
+func (s *stemmer) applyRules(rules []suffixRule) {
+	for _, rule := range rules {
+		if s.ends(rule.suffix) {
+			s.r(rule.replacement)
+			return
+		}
+	}
+}
+
+func (s *stemmer) step2() {
+	s.applyRules(step2Rules[s.b[s.k-1]])
+}
+
+func (s *stemmer) step3() {
+	s.applyRules(step3Rules[s.b[s.k]])
+}
+
+// step4 takes off -ant, -ence and the like when the stem is long enough
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes[s.b[s.k-1]] {
+		if !s.ends(suffix) {
+			continue
+		}
+		// -ion only goes after s or t
+		if suffix == "ion" && (s.j < 0 || (s.b[s.j] != 's' && s.b[s.j] != 't')) {
+			continue
+		}
+		if s.m() > 1 {
+			s.k = s.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e and reduces a final -ll when the stem is long
+// enough
+func (s *stemmer) step5() {
+	s.j = s.k
+	if s.b[s.k] == 'e' {
+		a := s.m()
+		if a > 1 || a == 1 && !s.cvc(s.k-1) {
+			s.k--
+		}
+	}
+	if s.b[s.k] == 'l' && s.doubleC(s.k) && s.m() > 1 {
+		s.k--
+	}
+}
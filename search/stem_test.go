@@ -0,0 +1,44 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	// Examples from the paper describing the algorithm
+	cases := map[string]string{
+		"caresses": "caress", "ponies": "poni", "ties": "ti", "cats": "cat",
+		"feed": "feed", "agreed": "agre", "plastered": "plaster", "bled": "bled",
+		"motoring": "motor", "sing": "sing", "conflated": "conflat", "troubled": "troubl",
+		"sized": "size", "hopping": "hop", "tanned": "tan", "falling": "fall",
+		"hissing": "hiss", "fizzed": "fizz", "failing": "fail", "filing": "file",
+		"happy": "happi", "sky": "sky", "relational": "relat", "conditional": "condit",
+		"rational": "ration", "digitizer": "digit", "radicalli": "radic",
+		"differentli": "differ", "vietnamization": "vietnam", "predication": "predic",
+		"operator": "oper", "feudalism": "feudal", "decisiveness": "decis",
+		"hopefulness": "hope", "callousness": "callous", "formaliti": "formal",
+		"sensitiviti": "sensit", "sensibiliti": "sensibl", "triplicate": "triplic",
+		"formative": "form", "formalize": "formal", "electrical": "electr",
+		"hopeful": "hope", "goodness": "good", "revival": "reviv", "allowance": "allow",
+		"inference": "infer", "airliner": "airlin", "adjustable": "adjust",
+		"defensible": "defens", "irritant": "irrit", "replacement": "replac",
+		"adjustment": "adjust", "dependent": "depend", "adoption": "adopt",
+		"communism": "commun", "activate": "activ", "homologous": "homolog",
+		"effective": "effect", "bowdlerize": "bowdler", "probate": "probat",
+		"rate": "rate", "cease": "ceas", "controll": "control", "roll": "roll",
+		"generalizations": "gener", "running": "run", "connections": "connect",
+	}
+
+	for word, stem := range cases {
+		assert.Equal(t, stem, Stem(word), word)
+	}
+}
+
+func TestStem_LeavesOtherWordsAlone(t *testing.T) {
+	assert.Equal(t, "is", Stem("is"))
+	assert.Equal(t, "東京", Stem("東京"))
+	assert.Equal(t, "café", Stem("café"))
+	assert.Equal(t, "2024", Stem("2024"))
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Words too common to tell documents apart, left out of the index
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// token is a word of a text along with the term it is indexed under
+type token struct {
+	word string
+	term string
+}
+
+// splitWords breaks a text into lowercase words: runs of letters, digits and
+// combining marks of any script. Everything else, such as spaces,
+// punctuation, '#' and '@', separates words.
+func splitWords(s string) []string {
+	s = strings.ToLower(norm.NFC.String(s))
+
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.In(r, unicode.Mn, unicode.Mc)
+	})
+}
+
+// tokenize lists the words of a text worth indexing, with their stems
+func tokenize(s string) []token {
+	var tokens []token
+
+	for _, word := range splitWords(s) {
+		if !stopWords[word] {
+			tokens = append(tokens, token{word: word, term: Stem(word)})
+		}
+	}
+
+	return tokens
+}
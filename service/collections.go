@@ -0,0 +1,287 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+)
+
+const maxCollectionNameLength = 50
+
+func (s *Service) SavePost(post_id, user_id, collection_id string) (err error) {
+	if _, err := s.getViewablePost(post_id, user_id); err != nil {
+		return err
+	}
+
+	if collection_id != "" {
+		return s.AddToCollection(collection_id, user_id, post_id)
+	}
+
+	if err := s.collections.SavePost(user_id, post_id); err != nil {
+		return errors.New("error saving post")
+	}
+
+	s.publish(Event{Type: EventPostSaved, UserId: user_id, PostId: post_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) UnsavePost(post_id, user_id string) (err error) {
+	if err := s.collections.UnsavePost(user_id, post_id); err != nil {
+		return errors.New("error unsaving post")
+	}
+
+	s.publish(Event{Type: EventPostUnsaved, UserId: user_id, PostId: post_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) ListSavedPosts(user_id string, query models.SavedPostQueryDTO) (page models.SavedPostPageDTO, err error) {
+	saved, err := s.collections.GetSavedPosts(user_id)
+
+	if err != nil {
+		return models.SavedPostPageDTO{}, errors.New("error retrieving saved posts")
+	}
+
+	refs := make([]postRef, 0, len(saved))
+	for _, bookmark := range saved {
+		refs = append(refs, postRef{postId: bookmark.PostId, key: pagination.Cursor{Time: bookmark.SavedAt, Id: bookmark.PostId}})
+	}
+
+	return s.savedPostPage(user_id, refs, query, true)
+}
+
+func (s *Service) CreateCollection(user_id, name string) (collection models.CollectionDTO, err error) {
+	name, err = collectionName(name)
+
+	if err != nil {
+		return models.CollectionDTO{}, err
+	}
+
+	now := s.now()
+	collection = models.CollectionDTO{OwnerId: user_id, Name: name, CreatedAt: now, UpdatedAt: now}
+
+	collection_id, err := s.collections.CreateCollection(collection)
+
+	if err != nil {
+		return models.CollectionDTO{}, errors.New("error creating collection")
+	}
+
+	return s.collections.GetCollection(collection_id)
+}
+
+func (s *Service) ListCollections(user_id string) (collections []models.CollectionDTO, err error) {
+	collections, err = s.collections.GetCollections(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving collections")
+	}
+
+	return collections, nil
+}
+
+func (s *Service) RenameCollection(collection_id, user_id, name string) (collection models.CollectionDTO, err error) {
+	name, err = collectionName(name)
+
+	if err != nil {
+		return models.CollectionDTO{}, err
+	}
+
+	return s.updateCollection(collection_id, user_id, func(collection *models.CollectionDTO) error {
+		collection.Name = name
+		return nil
+	})
+}
+
+func (s *Service) ReorderCollections(user_id string, collection_ids []string) (err error) {
+	if err := s.collections.ReorderCollections(user_id, collection_ids); err != nil {
+		if err.Error() == "invalid order" {
+			return err
+		}
+		return errors.New("error updating collections")
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteCollection(collection_id, user_id string) (err error) {
+	if _, err := s.getOwnedCollection(collection_id, user_id); err != nil {
+		return err
+	}
+
+	if err := s.collections.DeleteCollection(collection_id); err != nil {
+		return errors.New("error deleting collection")
+	}
+
+	return nil
+}
+
+func (s *Service) AddToCollection(collection_id, user_id, post_id string) (err error) {
+	if _, err := s.getViewablePost(post_id, user_id); err != nil {
+		return err
+	}
+
+	_, err = s.updateCollection(collection_id, user_id, func(collection *models.CollectionDTO) error {
+		if !slices.Contains(collection.PostIds, post_id) {
+			collection.PostIds = append(collection.PostIds, post_id)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Posts in a collection are saved posts too
+	if err := s.collections.SavePost(user_id, post_id); err != nil {
+		return errors.New("error saving post")
+	}
+
+	s.publish(Event{Type: EventPostSaved, UserId: user_id, PostId: post_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) RemoveFromCollection(collection_id, user_id, post_id string) (err error) {
+	_, err = s.updateCollection(collection_id, user_id, func(collection *models.CollectionDTO) error {
+		collection.PostIds = slices.DeleteFunc(collection.PostIds, func(id string) bool {
+			return id == post_id
+		})
+		return nil
+	})
+
+	return err
+}
+
+func (s *Service) ReorderCollectionPosts(collection_id, user_id string, post_ids []string) (err error) {
+	_, err = s.updateCollection(collection_id, user_id, func(collection *models.CollectionDTO) error {
+		current := slices.Clone(collection.PostIds)
+		ordered := slices.Clone(post_ids)
+		slices.Sort(current)
+		slices.Sort(ordered)
+
+		if !slices.Equal(current, ordered) {
+			return errors.New("invalid order")
+		}
+
+		collection.PostIds = post_ids
+		return nil
+	})
+
+	return err
+}
+
+func (s *Service) ListCollectionPosts(collection_id, user_id string, query models.SavedPostQueryDTO) (page models.SavedPostPageDTO, err error) {
+	collection, err := s.getOwnedCollection(collection_id, user_id)
+
+	if err != nil {
+		return models.SavedPostPageDTO{}, err
+	}
+
+	// Posts keep the position their owner gave them; the cursor remembers it
+	refs := make([]postRef, 0, len(collection.PostIds))
+	for position, post_id := range collection.PostIds {
+		refs = append(refs, postRef{postId: post_id, key: pagination.Cursor{Rank: position, Id: post_id}})
+	}
+
+	return s.savedPostPage(user_id, refs, query, false)
+}
+
+// postRef is a saved post along with its place in a listing
+type postRef struct {
+	postId string
+	key    pagination.Cursor
+}
+
+// savedPostPage reads a page of saved posts. Posts the user can no longer
+// see, because they were trashed or their author hid them, are left out;
+// posts that are gone for good are also unsaved.
+func (s *Service) savedPostPage(user_id string, refs []postRef, query models.SavedPostQueryDTO, desc bool) (models.SavedPostPageDTO, error) {
+	hidden, err := hiddenUsers(s.repo, user_id)
+
+	if err != nil {
+		return models.SavedPostPageDTO{}, errors.New("error retrieving saved posts")
+	}
+
+	visible := make([]postRef, 0, len(refs))
+	metas := make(map[string]models.PostMetaDTO, len(refs))
+
+	for _, ref := range refs {
+		post_meta, err := s.getViewablePost(ref.postId, user_id)
+
+		if err != nil {
+			if _, lookupErr := s.repo.GetPostMetaByID(ref.postId); lookupErr != nil {
+				_ = s.collections.UnsavePost(user_id, ref.postId)
+			}
+			continue
+		}
+
+		visible = append(visible, ref)
+		metas[ref.postId] = post_meta
+	}
+
+	key := func(ref postRef) pagination.Cursor { return ref.key }
+
+	pageRefs, next, err := pagination.Paginate(visible, query.Cursor, query.Limit, desc, key)
+
+	if err != nil {
+		return models.SavedPostPageDTO{}, err
+	}
+
+	posts := make([]models.PostResponseDTO, 0, len(pageRefs))
+	for _, ref := range pageRefs {
+		post_meta := metas[ref.postId]
+		comments, _ := s.repo.GetPostLatestComments(post_meta.Id, s.config.PreviewComments, hidden)
+
+		posts = append(posts, postPreview(post_meta, comments))
+	}
+
+	return models.SavedPostPageDTO{Posts: posts, NextCursor: next}, nil
+}
+
+// updateCollection applies a change to a collection of the user and stores it
+func (s *Service) updateCollection(collection_id, user_id string, change func(*models.CollectionDTO) error) (models.CollectionDTO, error) {
+	collection, err := s.getOwnedCollection(collection_id, user_id)
+
+	if err != nil {
+		return models.CollectionDTO{}, err
+	}
+
+	if err := change(&collection); err != nil {
+		return models.CollectionDTO{}, err
+	}
+
+	collection.UpdatedAt = s.now()
+
+	if err := s.collections.UpdateCollection(collection); err != nil {
+		return models.CollectionDTO{}, errors.New("error updating collection")
+	}
+
+	return s.collections.GetCollection(collection_id)
+}
+
+// getOwnedCollection retrieves a collection of the user. Collections are
+// private, so those of other users are reported as missing.
+func (s *Service) getOwnedCollection(collection_id, user_id string) (models.CollectionDTO, error) {
+	collection, err := s.collections.GetCollection(collection_id)
+
+	if err != nil || collection.OwnerId != user_id {
+		return models.CollectionDTO{}, errors.New("error retrieving collection")
+	}
+
+	return collection, nil
+}
+
+func collectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLength {
+		return "", errors.New("invalid collection name")
+	}
+
+	return name, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedPosts(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	first := createTestPost(t, svc, "alice", "first")
+	second := createTestPost(t, svc, "alice", "second")
+	private := createTestPost(t, svc, "alice", "private")
+	assert.NoError(t, svc.UpdatePostSettings(private, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+
+	assert.NoError(t, svc.SavePost(first, "bob", ""))
+	assert.NoError(t, svc.SavePost(second, "bob", ""))
+	assert.EqualError(t, svc.SavePost(private, "bob", ""), "error retrieving post")
+
+	page, err := svc.ListSavedPosts("bob", models.SavedPostQueryDTO{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second"}, captions(page.Posts))
+
+	page, err = svc.ListSavedPosts("bob", models.SavedPostQueryDTO{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, captions(page.Posts))
+	assert.Empty(t, page.NextCursor)
+
+	// Posts trashed or hidden since they were saved drop out of the listing
+	assert.NoError(t, svc.DeletePost(second, "alice"))
+	assert.NoError(t, svc.UpdatePostSettings(first, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+
+	page, err = svc.ListSavedPosts("bob", models.SavedPostQueryDTO{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Posts)
+
+	assert.NoError(t, svc.RestorePost(second, "alice"))
+
+	page, _ = svc.ListSavedPosts("bob", models.SavedPostQueryDTO{})
+	assert.Equal(t, []string{"second"}, captions(page.Posts))
+}
+
+func TestCollections(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	first := createTestPost(t, svc, "alice", "first")
+	second := createTestPost(t, svc, "alice", "second")
+
+	_, err := svc.CreateCollection("bob", "  ")
+	assert.EqualError(t, err, "invalid collection name")
+
+	recipes, err := svc.CreateCollection("bob", "Recipes")
+	assert.NoError(t, err)
+	travel, _ := svc.CreateCollection("bob", "Travel")
+
+	assert.NoError(t, svc.AddToCollection(recipes.Id, "bob", first))
+	assert.NoError(t, svc.AddToCollection(recipes.Id, "bob", second))
+	assert.NoError(t, svc.AddToCollection(recipes.Id, "bob", first))
+
+	// Collections are private
+	assert.EqualError(t, svc.AddToCollection(recipes.Id, "carol", first), "error retrieving collection")
+	_, err = svc.ListCollectionPosts(recipes.Id, "carol", models.SavedPostQueryDTO{})
+	assert.EqualError(t, err, "error retrieving collection")
+
+	page, err := svc.ListCollectionPosts(recipes.Id, "bob", models.SavedPostQueryDTO{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, captions(page.Posts))
+
+	// Adding to a collection also saves the post
+	saved, _ := svc.ListSavedPosts("bob", models.SavedPostQueryDTO{})
+	assert.Len(t, saved.Posts, 2)
+
+	assert.EqualError(t, svc.ReorderCollectionPosts(recipes.Id, "bob", []string{second}), "invalid order")
+	assert.NoError(t, svc.ReorderCollectionPosts(recipes.Id, "bob", []string{second, first}))
+
+	page, _ = svc.ListCollectionPosts(recipes.Id, "bob", models.SavedPostQueryDTO{Limit: 1})
+	assert.Equal(t, []string{"second"}, captions(page.Posts))
+	page, _ = svc.ListCollectionPosts(recipes.Id, "bob", models.SavedPostQueryDTO{Cursor: page.NextCursor})
+	assert.Equal(t, []string{"first"}, captions(page.Posts))
+
+	renamed, err := svc.RenameCollection(recipes.Id, "bob", "Dinner")
+	assert.NoError(t, err)
+	assert.Equal(t, "Dinner", renamed.Name)
+	assert.Equal(t, 2, renamed.PostCount)
+
+	assert.NoError(t, svc.ReorderCollections("bob", []string{travel.Id, recipes.Id}))
+	collections, err := svc.ListCollections("bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Travel", "Dinner"}, []string{collections[0].Name, collections[1].Name})
+
+	// Unsaving takes the post out of its collections
+	assert.NoError(t, svc.UnsavePost(first, "bob"))
+	page, _ = svc.ListCollectionPosts(recipes.Id, "bob", models.SavedPostQueryDTO{})
+	assert.Equal(t, []string{"second"}, captions(page.Posts))
+
+	assert.NoError(t, svc.DeleteCollection(travel.Id, "bob"))
+	assert.EqualError(t, svc.DeleteCollection(travel.Id, "bob"), "error retrieving collection")
+}
+
+func TestSavedPosts_PurgedPostsAreUnsaved(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	collections := repository.NewInMemoryCollectionRepo()
+	svc := NewService(repo, WithCollectionRepository(collections))
+
+	post_id := createTestPost(t, svc, "alice", "gone")
+	assert.NoError(t, svc.SavePost(post_id, "bob", ""))
+
+	assert.NoError(t, svc.DeletePost(post_id, "alice"))
+	_, _, err := repo.PurgeDeletedBefore(svc.now().Add(time.Second))
+	assert.NoError(t, err)
+
+	page, err := svc.ListSavedPosts("bob", models.SavedPostQueryDTO{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Posts)
+
+	saved, _ := collections.GetSavedPosts("bob")
+	assert.Empty(t, saved)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+)
+
+func (s *Service) PublishPost(post_id, author_id string) (err error) {
+	post_meta, err := s.getOwnedDraft(post_id, author_id)
+
+	if err != nil {
+		return err
+	}
+
+	published, err := s.publishPost(post_meta)
+
+	if err != nil {
+		return errors.New("error publishing post")
+	}
+
+	// The scheduler got to it first
+	if !published {
+		return errors.New("post already published")
+	}
+
+	return nil
+}
+
+func (s *Service) SchedulePost(post_id, author_id string, publish_at *time.Time) (err error) {
+	post_meta, err := s.getOwnedDraft(post_id, author_id)
+
+	if err != nil {
+		return err
+	}
+
+	if publish_at != nil && !publish_at.After(s.now()) {
+		return errors.New("invalid publish time")
+	}
+
+	if err := s.repo.SchedulePost(post_meta.Id, publish_at); err != nil {
+		if err.Error() == "post already published" {
+			return err
+		}
+		return errors.New("error scheduling post")
+	}
+
+	return nil
+}
+
+func (s *Service) ListDrafts(author_id string) (posts []models.PostResponseDTO, err error) {
+	post_metas, err := s.repo.GetUnpublishedPostMetas(author_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving drafts")
+	}
+
+	posts = make([]models.PostResponseDTO, 0, len(post_metas))
+	for _, post_meta := range post_metas {
+		post := postPreview(post_meta, nil)
+		post.Status = post_meta.Status
+		post.PublishAt = post_meta.PublishAt
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// PublishDuePosts publishes the scheduled posts whose time has come, and
+// announces the posts whose announcement was cut short. A post that fails to
+// publish is logged and retried on the next run.
+func (s *Service) PublishDuePosts() (published int, err error) {
+	now := s.now()
+	post_metas, err := s.repo.GetDueScheduledPosts(now)
+
+	if err != nil {
+		return 0, errors.New("error retrieving scheduled posts")
+	}
+
+	for _, post_meta := range post_metas {
+		ok, err := s.publishPost(post_meta)
+
+		if err != nil {
+			log.Printf("post scheduler: error publishing post %s: %v", post_meta.Id, err)
+			continue
+		}
+
+		if ok {
+			published++
+		}
+	}
+
+	// Posts published within the last interval may still be being announced
+	unannounced, err := s.repo.GetUnannouncedPosts(now.Add(-s.config.PostSchedulerInterval))
+
+	if err != nil {
+		return published, errors.New("error retrieving unannounced posts")
+	}
+
+	for _, post_meta := range unannounced {
+		s.announcePost(post_meta)
+	}
+
+	return published, nil
+}
+
+// RunPostScheduler publishes the scheduled posts as they come due until ctx
+// is cancelled. Posts that came due while the scheduler was not running are
+// published as soon as it starts.
+func (s *Service) RunPostScheduler(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PostSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if published, err := s.PublishDuePosts(); err != nil {
+			log.Printf("post scheduler: %v", err)
+		} else if published > 0 {
+			log.Printf("post scheduler: published %d posts", published)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishPost makes a draft or scheduled post live and announces it. The
+// repository publishes each post once, so a post that the scheduler and its
+// author publish at the same time is only announced by whoever got there
+// first; the other gets false.
+func (s *Service) publishPost(post_meta models.PostMetaDTO) (bool, error) {
+	published, err := s.repo.PublishPost(post_meta.Id, s.now())
+
+	if err != nil || !published {
+		return false, err
+	}
+
+	// Mention whoever the caption mentions as published
+	post_meta, err = s.repo.GetPostMetaByID(post_meta.Id)
+
+	if err != nil {
+		// The scheduler announces it once the post can be read
+		return true, nil
+	}
+
+	s.announcePost(post_meta)
+
+	return true, nil
+}
+
+// announcePost tells the followers, mentions and indexes about a newly
+// published post, then clears its unannounced marker. If that is cut short,
+// the scheduler announces the post again. Timelines, indexes and
+// notifications each keep a post once, so followers, mentions and indexes
+// still hear about it exactly once.
+func (s *Service) announcePost(post_meta models.PostMetaDTO) {
+	s.publish(Event{Type: EventPostCreated, UserId: post_meta.Creator, PostId: post_meta.Id, At: post_meta.CreatedAt})
+	s.notifyMentions(post_meta.Creator, post_meta.Id, "", post_meta.Mentions, nil)
+
+	if err := s.repo.MarkPostAnnounced(post_meta.Id); err != nil {
+		log.Printf("error marking post %s announced: %v", post_meta.Id, err)
+	}
+}
+
+// getOwnedDraft retrieves a draft or scheduled post that is not in the trash
+// and checks that it was written by author_id
+func (s *Service) getOwnedDraft(post_id, author_id string) (models.PostMetaDTO, error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil || post_meta.DeletedAt != nil || post_meta.Published() {
+		return models.PostMetaDTO{}, errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != author_id {
+		return models.PostMetaDTO{}, errors.New("unauthorized")
+	}
+
+	return post_meta, nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDraftTest wires a FeedService to a test Service whose clock the test
+// moves by hand, and counts the posts announced
+func newDraftTest() (*Service, *FeedService, *time.Time, *int) {
+	svc, now := newTestService(0)
+	feed, _ := newTestFeed(svc)
+
+	created := 0
+	svc.Subscribe(func(event Event) {
+		if event.Type == EventPostCreated {
+			created++
+		}
+	})
+
+	return svc, feed, now, &created
+}
+
+func createTestDraft(t *testing.T, svc *Service, author_id, caption string, publish_at *time.Time) string {
+	post_id, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{
+		Caption:   caption,
+		AuthorId:  author_id,
+		Draft:     publish_at == nil,
+		PublishAt: publish_at,
+	})
+	assert.NoError(t, err)
+	return post_id
+}
+
+func allCaptions(t *testing.T, svc *Service, viewer_id string) []string {
+	posts, err := svc.GetAllPosts(viewer_id)
+	assert.NoError(t, err)
+
+	captions := []string{}
+	for _, post := range posts {
+		captions = append(captions, post.Caption)
+	}
+	return captions
+}
+
+func TestDrafts_OnlyAuthorSees(t *testing.T) {
+	svc, feed, _, created := newDraftTest()
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	draft := createTestDraft(t, svc, "alice", "draft", nil)
+
+	assert.Empty(t, allCaptions(t, svc, "alice"))
+	assert.Empty(t, allCaptions(t, svc, "bob"))
+	assert.Empty(t, feedCaptions(t, feed, "bob"))
+	assert.Equal(t, 0, *created)
+
+	_, _, err = svc.GetPostById(draft, "bob")
+	assert.EqualError(t, err, "error retrieving post")
+
+	_, info, err := svc.GetPostById(draft, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", info.Caption)
+
+	_, err = svc.LikePost(draft, "alice")
+	assert.EqualError(t, err, "error retrieving post")
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: draft, AuthorId: "bob", Comment: "early"})
+	assert.Error(t, err)
+
+	drafts, err := svc.ListDrafts("alice")
+	assert.NoError(t, err)
+	assert.Len(t, drafts, 1)
+	assert.Equal(t, models.PostStatusDraft, drafts[0].Status)
+
+	drafts, _ = svc.ListDrafts("bob")
+	assert.Empty(t, drafts)
+
+	// Editing a draft keeps it a draft
+	assert.NoError(t, svc.EditPost(draft, "alice", "draft, edited"))
+	assert.Empty(t, allCaptions(t, svc, "bob"))
+
+	assert.EqualError(t, svc.PublishPost(draft, "bob"), "unauthorized")
+	assert.NoError(t, svc.PublishPost(draft, "alice"))
+
+	assert.Equal(t, []string{"draft, edited"}, allCaptions(t, svc, "bob"))
+	assert.Equal(t, []string{"draft, edited"}, feedCaptions(t, feed, "bob"))
+	assert.Equal(t, 1, *created)
+
+	drafts, _ = svc.ListDrafts("alice")
+	assert.Empty(t, drafts)
+
+	assert.EqualError(t, svc.PublishPost(draft, "alice"), "error retrieving post")
+}
+
+func TestDrafts_Scheduled(t *testing.T) {
+	svc, feed, now, created := newDraftTest()
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	publish_at := now.Add(time.Hour)
+	scheduled := createTestDraft(t, svc, "alice", "scheduled", &publish_at)
+
+	drafts, _ := svc.ListDrafts("alice")
+	assert.Equal(t, models.PostStatusScheduled, drafts[0].Status)
+	assert.Equal(t, publish_at, *drafts[0].PublishAt)
+
+	published, err := svc.PublishDuePosts()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.Empty(t, allCaptions(t, svc, "bob"))
+
+	*now = publish_at
+	published, err = svc.PublishDuePosts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	assert.Equal(t, []string{"scheduled"}, allCaptions(t, svc, "bob"))
+	assert.Equal(t, []string{"scheduled"}, feedCaptions(t, feed, "bob"))
+
+	// The post is dated to when it went live
+	_, info, _ := svc.GetPostById(scheduled, "bob")
+	assert.Equal(t, publish_at, info.CreatedAt)
+
+	published, _ = svc.PublishDuePosts()
+	assert.Equal(t, 0, published)
+	assert.Equal(t, 1, *created)
+}
+
+func TestDrafts_Rescheduling(t *testing.T) {
+	svc, _, now, _ := newDraftTest()
+
+	post_id := createTestDraft(t, svc, "alice", "later", nil)
+
+	past := now.Add(-time.Minute)
+	assert.EqualError(t, svc.SchedulePost(post_id, "alice", &past), "invalid publish time")
+	assert.EqualError(t, svc.SchedulePost(post_id, "bob", nil), "unauthorized")
+
+	publish_at := now.Add(time.Hour)
+	assert.NoError(t, svc.SchedulePost(post_id, "alice", &publish_at))
+
+	// Back to a draft: it is no longer published when the time comes
+	assert.NoError(t, svc.SchedulePost(post_id, "alice", nil))
+	*now = publish_at
+	published, _ := svc.PublishDuePosts()
+	assert.Equal(t, 0, published)
+
+	// Trashed scheduled posts wait until they are restored
+	later := now.Add(time.Hour)
+	assert.NoError(t, svc.SchedulePost(post_id, "alice", &later))
+	assert.NoError(t, svc.DeletePost(post_id, "alice"))
+	*now = later
+	published, _ = svc.PublishDuePosts()
+	assert.Equal(t, 0, published)
+
+	assert.NoError(t, svc.RestorePost(post_id, "alice"))
+	published, _ = svc.PublishDuePosts()
+	assert.Equal(t, 1, published)
+
+	_, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{AuthorId: "alice", PublishAt: &past})
+	assert.EqualError(t, err, "invalid publish time")
+
+	_, err = svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{AuthorId: "alice", Draft: true, PublishAt: &publish_at})
+	assert.EqualError(t, err, "invalid publish time")
+}
+
+func TestDrafts_AnnouncementsCutShortAreReplayed(t *testing.T) {
+	svc, feed, now, created := newDraftTest()
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	// The post went live but the server stopped before announcing it
+	draft := createTestDraft(t, svc, "alice", "draft", nil)
+	published, err := svc.repo.PublishPost(draft, *now)
+	assert.NoError(t, err)
+	assert.True(t, published)
+
+	// Announcements still in progress are left alone
+	_, err = svc.PublishDuePosts()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *created)
+
+	*now = now.Add(svc.config.PostSchedulerInterval + time.Second)
+	_, err = svc.PublishDuePosts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *created)
+	assert.Equal(t, []string{"draft"}, feedCaptions(t, feed, "bob"))
+
+	_, _ = svc.PublishDuePosts()
+	assert.Equal(t, 1, *created)
+}
+
+func TestDrafts_RestartMidAnnouncementNotifiesOnce(t *testing.T) {
+	// The stores outlive the server, which is started twice over them
+	repo := repository.NewInMemoryRepo()
+	users := repository.NewInMemoryUserRepo()
+	stored := repository.NewInMemoryNotificationRepo()
+	timelines := repository.NewInMemoryTimelineRepo(10)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	start := func() (*Service, *NotificationService, *FeedService) {
+		svc := NewService(repo, WithUserRepository(users), WithClock(func() time.Time { return now }))
+
+		notifications := NewNotificationService(repo, stored)
+		svc.Subscribe(notifications.HandleEvent)
+
+		feed := NewFeedService(repo, timelines, svc.config)
+		svc.Subscribe(func(event Event) {
+			if event.Type == EventPostCreated {
+				feed.apply(event)
+			}
+		})
+
+		return svc, notifications, feed
+	}
+
+	alice, _ := users.SaveUser(models.User{Username: "alice"})
+	bob, _ := users.SaveUser(models.User{Username: "bob"})
+
+	svc, _, _ := start()
+	_, err := svc.FollowUser(bob, alice)
+	assert.NoError(t, err)
+	draft := createTestDraft(t, svc, alice, "with @bob", nil)
+
+	// The post went live and its followers and mentions were told, but the
+	// server stopped before recording that it was announced
+	published, err := repo.PublishPost(draft, now)
+	assert.NoError(t, err)
+	assert.True(t, published)
+
+	post_meta, _ := repo.GetPostMetaByID(draft)
+	svc.publish(Event{Type: EventPostCreated, UserId: alice, PostId: draft, At: post_meta.CreatedAt})
+	svc.notifyMentions(alice, draft, "", post_meta.Mentions, nil)
+
+	now = now.Add(svc.config.PostSchedulerInterval + time.Second)
+	svc, notifications, feed := start()
+
+	_, err = svc.PublishDuePosts()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{draft}, mentionedPosts(t, notifications, bob))
+	assert.Equal(t, []string{"with @bob"}, feedCaptions(t, feed, bob))
+
+	post_meta, _ = repo.GetPostMetaByID(draft)
+	assert.False(t, post_meta.Unannounced)
+}
+
+func TestDrafts_PublishedOnceAcrossSchedulers(t *testing.T) {
+	repo := repository.NewInMemoryRepo()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return start.Add(2 * time.Hour) }
+
+	var mu sync.Mutex
+	announced := map[string]int{}
+
+	// Schedulers of several instances, or of the same instance before and
+	// after a restart, sharing the repository
+	schedulers := make([]*Service, 4)
+	for i := range schedulers {
+		schedulers[i] = NewService(repo, WithClock(clock))
+		schedulers[i].Subscribe(func(event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			if event.Type == EventPostCreated {
+				announced[event.PostId]++
+			}
+		})
+	}
+
+	author := NewService(repo, WithClock(func() time.Time { return start }))
+
+	publish_at := start.Add(time.Hour)
+	for i := 0; i < 20; i++ {
+		createTestDraft(t, author, "alice", "scheduled", &publish_at)
+	}
+
+	var wg sync.WaitGroup
+	for _, scheduler := range schedulers {
+		wg.Add(1)
+		go func(scheduler *Service) {
+			defer wg.Done()
+			_, err := scheduler.PublishDuePosts()
+			assert.NoError(t, err)
+		}(scheduler)
+	}
+	wg.Wait()
+
+	assert.Len(t, announced, 20)
+	for _, count := range announced {
+		assert.Equal(t, 1, count)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import "time"
+
+// EventType names a change made through the Service or the UserService
+type EventType string
+
+const (
+	// A user created a post; PostId is set
+	EventPostCreated EventType = "post_created"
+
+	// The author changed the caption of a post; PostId is set
+	EventPostEdited EventType = "post_edited"
+
+	// The author moved a post to the trash; PostId is set
+	EventPostDeleted EventType = "post_deleted"
+
+	// The author brought a post back from the trash; PostId is set
+	EventPostRestored EventType = "post_restored"
+
+	// A user commented on a post; PostId and CommentId are set
+	EventCommentCreated EventType = "comment_created"
+
+	// The author of a comment edited it; PostId and CommentId are set
+	EventCommentEdited EventType = "comment_edited"
+
+	// A comment was moved to the trash, by UserId; PostId and CommentId are set
+	EventCommentDeleted EventType = "comment_deleted"
+
+	// A comment was brought back from the trash; PostId and CommentId are set
+	EventCommentRestored EventType = "comment_restored"
+
+	// The author of a post hid a comment on it; PostId and CommentId are set
+	EventCommentHidden EventType = "comment_hidden"
+
+	// The author of a post showed a hidden comment again; PostId and
+	// CommentId are set
+	EventCommentUnhidden EventType = "comment_unhidden"
+
+	// A user liked a post; PostId is set
+	EventPostLiked EventType = "post_liked"
+
+	// A user took back their like of a post; PostId is set
+	EventPostUnliked EventType = "post_unliked"
+
+	// A user saved a post, possibly again; PostId is set
+	EventPostSaved EventType = "post_saved"
+
+	// A user removed a post from their saved posts; PostId is set
+	EventPostUnsaved EventType = "post_unsaved"
+
+	// A user mentioned another user in a post, or in one of its comments when
+	// CommentId is set; TargetId is the mentioned user
+	EventUserMentioned EventType = "user_mentioned"
+
+	// A user signed up
+	EventUserRegistered EventType = "user_registered"
+
+	// A user changed their profile
+	EventProfileUpdated EventType = "profile_updated"
+
+	// A user followed another user; TargetId is the followed user
+	EventUserFollowed EventType = "user_followed"
+
+	// A user unfollowed another user; TargetId is the unfollowed user
+	EventUserUnfollowed EventType = "user_unfollowed"
+)
+
+// Event describes a change made through the Service or the UserService, for
+// the services that keep data derived from posts, users and the social graph,
+// such as home feeds, the search index and the explore ranking
+type Event struct {
+	Type      EventType
+	UserId    string
+	PostId    string
+	CommentId string
+	TargetId  string
+	At        time.Time
+}
+
+// eventBus hands the events of a service to its listeners
+type eventBus struct {
+	listeners []func(Event)
+}
+
+// Subscribe registers a listener for the events of the service. Listeners
+// are called synchronously, after the change has been stored, in the order
+// they subscribed. Subscribe before serving requests.
+func (b *eventBus) Subscribe(listener func(Event)) {
+	b.listeners = append(b.listeners, listener)
+}
+
+func (b *eventBus) publish(event Event) {
+	for _, listener := range b.listeners {
+		listener(event)
+	}
+}
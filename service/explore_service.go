@@ -0,0 +1,13 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type IExploreService interface {
+	// Get a page of the explore page: recent public posts the viewer can
+	// see, most engaged with lately first
+	GetExplore(viewer_id string, query models.ExploreQueryDTO) (page models.ExplorePageDTO, err error)
+
+	// Keep the explore ranking up to date with a change made through the
+	// Service
+	HandleEvent(event Event)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/anandh86/instagram/trending"
+)
+
+// ExploreService ranks recent public posts by the likes, comments and saves
+// they got from other users, recent engagement counting the most. Scores are
+// updated as each engagement comes in, so serving the explore page only
+// walks down the ranking.
+type ExploreService struct {
+	posts *Service
+	board *trending.Board
+}
+
+func NewExploreService(posts *Service) *ExploreService {
+
+	// compile-time check to ensure we implement the interface
+	var _ IExploreService = (*ExploreService)(nil)
+
+	cfg := posts.config
+
+	formula := trending.Formula{
+		LikeWeight:    cfg.ExploreLikeWeight,
+		CommentWeight: cfg.ExploreCommentWeight,
+		SaveWeight:    cfg.ExploreSaveWeight,
+		HalfLife:      cfg.ExploreHalfLife,
+	}
+
+	return &ExploreService{
+		posts: posts,
+		board: trending.NewBoard(formula, cfg.ExploreWindow, func() time.Time { return posts.now() }),
+	}
+}
+
+func (s *ExploreService) GetExplore(viewer_id string, query models.ExploreQueryDTO) (page models.ExplorePageDTO, err error) {
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.ExplorePageDTO{}, err
+		}
+	}
+
+	hiddenList, err := hiddenUsers(s.posts.repo, viewer_id)
+
+	if err != nil {
+		return models.ExplorePageDTO{}, errors.New("error retrieving posts")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	key := func(entry trending.Entry) pagination.Cursor {
+		return pagination.Cursor{Rank: entry.Rank, Time: entry.CreatedAt, Id: entry.Id}
+	}
+
+	ranking := s.board.Ranking()
+	limit := pagination.ClampLimit(query.Limit)
+	posts := []models.PostResponseDTO{}
+
+	// Posts that were trashed, made private or written by hidden users stay
+	// ranked, so keep reading until the page is full or the ranking runs out
+	for len(posts) < limit {
+		entries, next, err := pagination.Paginate(ranking, cursor, limit-len(posts), true, key)
+
+		if err != nil {
+			return models.ExplorePageDTO{}, err
+		}
+
+		for _, entry := range entries {
+			post_meta, err := s.posts.getViewablePost(entry.Id, viewer_id)
+
+			if err != nil || !s.public(post_meta) || post_meta.Creator == viewer_id || hidden[post_meta.Creator] {
+				continue
+			}
+
+			comments, _ := s.posts.repo.GetPostLatestComments(post_meta.Id, s.posts.config.PreviewComments, hiddenList)
+
+			posts = append(posts, postPreview(post_meta, comments))
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return models.ExplorePageDTO{Posts: posts, NextCursor: cursor}, nil
+}
+
+// public tells whether a post is shown to everyone: a post for everyone on
+// an account that is not private
+func (s *ExploreService) public(post_meta models.PostMetaDTO) bool {
+	return post_meta.Visibility == models.VisibilityPublic && !s.posts.isPrivate(post_meta.Creator)
+}
+
+func (s *ExploreService) HandleEvent(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventPostCreated:
+		_, err = s.track(event.PostId)
+	case EventPostLiked:
+		err = s.engage(event.PostId, "like/"+event.UserId, event.UserId, trending.Engagement{Kind: trending.Like, At: event.At})
+	case EventPostSaved:
+		err = s.engage(event.PostId, "save/"+event.UserId, event.UserId, trending.Engagement{Kind: trending.Save, At: event.At})
+	case EventCommentCreated:
+		err = s.engageComment(event.CommentId, event.At)
+	case EventCommentRestored, EventCommentUnhidden:
+		err = s.engageComment(event.CommentId, time.Time{})
+	case EventPostUnliked:
+		s.board.Disengage(event.PostId, "like/"+event.UserId)
+	case EventPostUnsaved:
+		s.board.Disengage(event.PostId, "save/"+event.UserId)
+	case EventCommentDeleted, EventCommentHidden:
+		s.board.Disengage(event.PostId, "comment/"+event.CommentId)
+	}
+
+	if err != nil {
+		log.Printf("updating explore ranking for %s event: %v", event.Type, err)
+	}
+}
+
+// track puts a post on the board, in case it was created before the board
+func (s *ExploreService) track(post_id string) (models.PostMetaDTO, error) {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return models.PostMetaDTO{}, err
+	}
+
+	s.board.Add(post_meta.Id, post_meta.CreatedAt)
+
+	return post_meta, nil
+}
+
+// engage counts an engagement with a post, unless it comes from its author
+func (s *ExploreService) engage(post_id, key, user_id string, engagement trending.Engagement) error {
+	post_meta, err := s.track(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	if user_id != post_meta.Creator {
+		s.board.Engage(post_id, key, engagement)
+	}
+
+	return nil
+}
+
+// engageComment counts a comment as made at a point in time, or when it was
+// written when at is zero
+func (s *ExploreService) engageComment(comment_id string, at time.Time) error {
+	comment, err := s.posts.repo.GetCommentByID(comment_id)
+
+	if err != nil {
+		return err
+	}
+
+	// A hidden comment comes back when it is shown again, and a trashed one
+	// when it is restored
+	if !isShown(comment) {
+		return nil
+	}
+
+	if at.IsZero() {
+		at = comment.CreatedAt
+	}
+
+	return s.engage(comment.PostId, "comment/"+comment.Id, comment.Creator, trending.Engagement{Kind: trending.Comment, At: at})
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newExploreTest wires an ExploreService to a test Service whose clock the
+// test moves by hand
+func newExploreTest() (*Service, *ExploreService, *time.Time) {
+	svc, now := newTestService(0)
+
+	explore := NewExploreService(svc)
+	svc.Subscribe(explore.HandleEvent)
+
+	return svc, explore, now
+}
+
+func exploreCaptions(t *testing.T, explore *ExploreService, viewer_id string) []string {
+	page, err := explore.GetExplore(viewer_id, models.ExploreQueryDTO{})
+	assert.NoError(t, err)
+	return captions(page.Posts)
+}
+
+func TestExplore_RanksByRecentEngagement(t *testing.T) {
+	svc, explore, now := newExploreTest()
+
+	old := createTestPost(t, svc, "alice", "old")
+	for _, user_id := range []string{"u1", "u2", "u3"} {
+		_, err := svc.LikePost(old, user_id)
+		assert.NoError(t, err)
+	}
+
+	*now = now.Add(time.Hour)
+	fresh := createTestPost(t, svc, "bob", "fresh")
+	assert.NoError(t, svc.SavePost(fresh, "u1", ""))
+
+	*now = now.Add(time.Hour)
+	createTestPost(t, svc, "carol", "quiet")
+
+	// A save is worth more than three likes that are an hour older
+	assert.Equal(t, []string{"fresh", "old", "quiet"}, exploreCaptions(t, explore, "dave"))
+
+	// Comments count too, and taking engagement back lowers the score
+	_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: old, AuthorId: "u4", Comment: "nice"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old", "fresh", "quiet"}, exploreCaptions(t, explore, "dave"))
+
+	for _, user_id := range []string{"u1", "u2", "u3"} {
+		_, err := svc.UnlikePost(old, user_id)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"fresh", "old", "quiet"}, exploreCaptions(t, explore, "dave"))
+
+	// Saving the same post again doesn't count twice; unsaving takes it back
+	assert.NoError(t, svc.SavePost(fresh, "u1", ""))
+	assert.NoError(t, svc.UnsavePost(fresh, "u1"))
+	assert.Equal(t, []string{"old", "quiet", "fresh"}, exploreCaptions(t, explore, "dave"))
+}
+
+func TestExplore_IgnoresAuthorsOwnEngagement(t *testing.T) {
+	svc, explore, now := newExploreTest()
+
+	mine := createTestPost(t, svc, "alice", "mine")
+	*now = now.Add(time.Minute)
+	createTestPost(t, svc, "bob", "other")
+
+	_, err := svc.LikePost(mine, "alice")
+	assert.NoError(t, err)
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: mine, AuthorId: "alice", Comment: "first"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"other", "mine"}, exploreCaptions(t, explore, "dave"))
+
+	// Viewers don't see their own posts
+	assert.Equal(t, []string{"other"}, exploreCaptions(t, explore, "alice"))
+}
+
+func TestExplore_HiddenCommentsDontCount(t *testing.T) {
+	svc, explore, now := newExploreTest()
+
+	commented := createTestPost(t, svc, "alice", "commented")
+	*now = now.Add(time.Minute)
+	createTestPost(t, svc, "bob", "quiet")
+
+	comment_id, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: commented, AuthorId: "u1", Comment: "spam"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"commented", "quiet"}, exploreCaptions(t, explore, "dave"))
+
+	assert.NoError(t, svc.HideComment(comment_id, "alice"))
+	assert.Equal(t, []string{"quiet", "commented"}, exploreCaptions(t, explore, "dave"))
+
+	assert.NoError(t, svc.UnhideComment(comment_id, "alice"))
+	assert.Equal(t, []string{"commented", "quiet"}, exploreCaptions(t, explore, "dave"))
+}
+
+func TestExplore_OnlyRecentPublicPosts(t *testing.T) {
+	svc, explore, now := newExploreTest()
+
+	createTestPost(t, svc, "alice", "stale")
+	*now = now.Add(7*24*time.Hour - time.Hour)
+
+	restricted := createTestPost(t, svc, "alice", "followers")
+	assert.NoError(t, svc.UpdatePostSettings(restricted, "alice", models.PostSettingsDTO{Visibility: models.VisibilityFollowers}))
+
+	*now = now.Add(time.Minute)
+	trashed := createTestPost(t, svc, "alice", "trashed")
+	*now = now.Add(time.Minute)
+	createTestPost(t, svc, "bob", "blocked")
+	*now = now.Add(time.Minute)
+	createTestPost(t, svc, "carol", "public")
+
+	assert.Equal(t, []string{"public", "blocked", "trashed", "stale"}, exploreCaptions(t, explore, "dave"))
+
+	assert.NoError(t, svc.DeletePost(trashed, "alice"))
+	assert.NoError(t, svc.BlockUser("bob", "dave"))
+	*now = now.Add(2 * time.Hour)
+
+	assert.Equal(t, []string{"public"}, exploreCaptions(t, explore, "dave"))
+	assert.Equal(t, []string{"public", "blocked"}, exploreCaptions(t, explore, ""))
+}
+
+func TestExplore_Pages(t *testing.T) {
+	svc, explore, now := newExploreTest()
+
+	for i, caption := range []string{"a", "b", "c", "d", "e"} {
+		post_id := createTestPost(t, svc, "alice", caption)
+		for j := 0; j < i%3; j++ {
+			_, err := svc.LikePost(post_id, string(rune('p'+j)))
+			assert.NoError(t, err)
+		}
+		*now = now.Add(time.Minute)
+	}
+
+	var captions []string
+	cursor := ""
+	for {
+		page, err := explore.GetExplore("dave", models.ExploreQueryDTO{Cursor: cursor, Limit: 2})
+		assert.NoError(t, err)
+		for _, post := range page.Posts {
+			captions = append(captions, post.Caption)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, exploreCaptions(t, explore, "dave"), captions)
+	assert.Len(t, captions, 5)
+
+	_, err := explore.GetExplore("dave", models.ExploreQueryDTO{Cursor: "garbage"})
+	assert.EqualError(t, err, "invalid cursor")
+}
@@ -0,0 +1,12 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type IFeedService interface {
+	// Get a page of the home feed of a user: the posts of the accounts they
+	// follow, newest first
+	GetFeed(user_id string, query models.FeedQueryDTO) (page models.FeedPageDTO, err error)
+
+	// Keep the home feeds up to date with a change made through the Service
+	HandleEvent(event Event)
+}
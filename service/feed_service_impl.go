@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/anandh86/instagram/repository"
+)
+
+// Number of events that can wait for the fan-out worker. Events that find the
+// queue full are handled without it, see overflow.
+const feedQueueSize = 1024
+
+// FeedService builds the home feed of each user. Posts by regular accounts
+// are copied into the timeline of every follower when they are created
+// (fan-out on write). Posts by accounts with more followers than the fan-out
+// threshold would be too costly to copy, so they are merged into each feed
+// when it is read instead (fan-out on read).
+type FeedService struct {
+	repo      repository.IRepository
+	timelines repository.ITimelineRepository
+	config    config.Config
+
+	// Events waiting for RunFanOut, and how many are queued or being applied
+	queue   chan Event
+	pending sync.WaitGroup
+
+	// Whether each account is past the fan-out threshold, refreshed as it
+	// gains and loses followers
+	largeMu sync.RWMutex
+	large   map[string]bool
+}
+
+func NewFeedService(repo repository.IRepository, timelines repository.ITimelineRepository, cfg config.Config) *FeedService {
+
+	// compile-time check to ensure we implement the interface
+	var _ IFeedService = (*FeedService)(nil)
+
+	return &FeedService{
+		repo:      repo,
+		timelines: timelines,
+		config:    cfg,
+		queue:     make(chan Event, feedQueueSize),
+		large:     make(map[string]bool),
+	}
+}
+
+func (s *FeedService) GetFeed(user_id string, query models.FeedQueryDTO) (page models.FeedPageDTO, err error) {
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.FeedPageDTO{}, err
+		}
+	}
+
+	large, err := s.largeFollowees(user_id)
+
+	if err != nil {
+		return models.FeedPageDTO{}, errors.New("error retrieving feed")
+	}
+
+	hiddenList, err := hiddenUsers(s.repo, user_id)
+
+	if err != nil {
+		return models.FeedPageDTO{}, errors.New("error retrieving feed")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	posts := []models.PostResponseDTO{}
+
+	// Entries whose post was trashed since it was added, or whose author is
+	// now muted, are skipped, so keep reading until the page is full or the
+	// feed runs out
+	for len(posts) < limit {
+		entries, more, err := s.readFeed(user_id, large, cursor, limit-len(posts))
+
+		if err != nil {
+			return models.FeedPageDTO{}, errors.New("error retrieving feed")
+		}
+
+		for _, entry := range entries {
+			post_meta, err := s.repo.GetPostMetaByID(entry.PostId)
+
+			if err != nil || post_meta.DeletedAt != nil || hidden[post_meta.Creator] {
+				continue
+			}
+
+			// Visibility can change after the post reached the timeline
+			if visible, err := postVisibleTo(s.repo, user_id, post_meta); err != nil || !visible {
+				continue
+			}
+
+			comments, _ := s.repo.GetPostLatestComments(post_meta.Id, s.config.PreviewComments, hiddenList)
+
+			posts = append(posts, postPreview(post_meta, comments))
+		}
+
+		if !more || len(entries) == 0 {
+			cursor = ""
+			break
+		}
+		cursor = feedCursor(entries[len(entries)-1]).Encode()
+	}
+
+	return models.FeedPageDTO{Posts: posts, NextCursor: cursor}, nil
+}
+
+// readFeed merges the precomputed timeline of a user with the posts of the
+// large accounts they follow, returning up to limit entries after the cursor
+// and whether more entries follow them
+func (s *FeedService) readFeed(user_id string, large []string, cursor string, limit int) ([]models.TimelineEntryDTO, bool, error) {
+	entries, next, err := s.timelines.GetTimeline(user_id, cursor, limit)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	more := next != ""
+
+	for _, author_id := range large {
+		post_metas, next, err := s.repo.GetPostMetasByCreator(author_id, cursor, limit)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		more = more || next != ""
+
+		for _, post_meta := range post_metas {
+			entries = append(entries, models.TimelineEntryDTO{
+				PostId:    post_meta.Id,
+				AuthorId:  post_meta.Creator,
+				CreatedAt: post_meta.CreatedAt,
+			})
+		}
+	}
+
+	// A large account may have been fanned out before it grew past the
+	// threshold, so the same post can come from both sources
+	seen := make(map[string]bool, len(entries))
+	merged := entries[:0]
+	for _, entry := range entries {
+		if !seen[entry.PostId] {
+			seen[entry.PostId] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	pagination.Sort(merged, true, feedCursor)
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+		more = true
+	}
+
+	return merged, more, nil
+}
+
+// largeFollowees lists the accounts a user follows whose posts are merged
+// into the feed when it is read
+func (s *FeedService) largeFollowees(user_id string) ([]string, error) {
+	var large []string
+
+	err := eachFollow(s.repo.GetFollowing, user_id, func(followee_id string) error {
+		isLarge, err := s.isLarge(followee_id)
+
+		if err == nil && isLarge {
+			large = append(large, followee_id)
+		}
+		return err
+	})
+
+	return large, err
+}
+
+// isLarge tells whether an account has more followers than the fan-out
+// threshold, counting them only the first time it is asked
+func (s *FeedService) isLarge(user_id string) (bool, error) {
+	s.largeMu.RLock()
+	large, known := s.large[user_id]
+	s.largeMu.RUnlock()
+
+	if known {
+		return large, nil
+	}
+
+	large, err := s.countLarge(user_id)
+
+	if err != nil {
+		return false, err
+	}
+
+	s.largeMu.Lock()
+	// A refresh made while counting is more recent
+	if _, known := s.large[user_id]; !known {
+		s.large[user_id] = large
+	}
+	s.largeMu.Unlock()
+
+	return large, nil
+}
+
+// refreshLarge counts the followers of an account again after they changed.
+// An account that drops back under the threshold is backfilled into the
+// timelines of its followers, which missed its posts while it was merged on
+// read.
+func (s *FeedService) refreshLarge(user_id string) error {
+	large, err := s.countLarge(user_id)
+
+	s.largeMu.Lock()
+	was_large := s.large[user_id]
+	if err != nil {
+		delete(s.large, user_id)
+	} else {
+		s.large[user_id] = large
+	}
+	s.largeMu.Unlock()
+
+	if err != nil || large || !was_large {
+		return err
+	}
+
+	return eachFollow(s.repo.GetFollowers, user_id, func(follower_id string) error {
+		return s.backfill(follower_id, user_id)
+	})
+}
+
+func (s *FeedService) countLarge(user_id string) (bool, error) {
+	followers, _, err := s.repo.CountFollows(user_id)
+	return followers > s.config.FeedFanoutThreshold, err
+}
+
+// eachFollow calls fn with every user of a follower or following list
+func eachFollow(list func(string, string, int) ([]models.FollowDTO, string, error), user_id string, fn func(string) error) error {
+	cursor := ""
+
+	for {
+		follows, next, err := list(user_id, cursor, pagination.MaxLimit)
+
+		if err != nil {
+			return err
+		}
+
+		for _, follow := range follows {
+			if err := fn(follow.UserId); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+/*------------------------------------------------------------------------
+*                             Fan-out
+------------------------------------------------------------------------*/
+
+// HandleEvent queues the events that change timelines for RunFanOut, so a
+// post by an account with thousands of followers doesn't hold up the request
+// that created it
+func (s *FeedService) HandleEvent(event Event) {
+	switch event.Type {
+	case EventPostCreated, EventUserFollowed, EventUserUnfollowed:
+		s.pending.Add(1)
+
+		select {
+		case s.queue <- event:
+		default:
+			s.pending.Done()
+			s.overflow(event)
+		}
+	}
+}
+
+// overflow handles an event that found RunFanOut stopped or too far behind,
+// without waiting for it. The account the event is about is merged into
+// feeds on read, like a large account, until RunFanOut next refreshes it, and
+// an unfollow takes its posts out of the follower's timeline right away.
+func (s *FeedService) overflow(event Event) {
+	author_id := event.TargetId
+	if event.Type == EventPostCreated {
+		author_id = event.UserId
+	}
+
+	log.Printf("feed fan-out queue full, merging %s into feeds on read", author_id)
+
+	s.largeMu.Lock()
+	s.large[author_id] = true
+	s.largeMu.Unlock()
+
+	if event.Type == EventUserUnfollowed {
+		if err := s.timelines.RemoveAuthorFromTimeline(event.UserId, event.TargetId); err != nil {
+			log.Printf("updating feeds for %s event: %v", event.Type, err)
+		}
+	}
+}
+
+// RunFanOut applies the queued events to the timelines, one at a time and in
+// the order they happened, until ctx is cancelled
+func (s *FeedService) RunFanOut(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.queue:
+			s.apply(event)
+			s.pending.Done()
+		}
+	}
+}
+
+func (s *FeedService) apply(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventPostCreated:
+		err = s.fanOutPost(event.UserId, event.PostId)
+	case EventUserFollowed:
+		if err = s.refreshLarge(event.TargetId); err == nil {
+			err = s.backfill(event.UserId, event.TargetId)
+		}
+	case EventUserUnfollowed:
+		if err = s.refreshLarge(event.TargetId); err == nil {
+			err = s.timelines.RemoveAuthorFromTimeline(event.UserId, event.TargetId)
+		}
+	}
+
+	if err != nil {
+		log.Printf("updating feeds for %s event: %v", event.Type, err)
+	}
+}
+
+// fanOutPost copies a new post into the timelines of its author's followers
+func (s *FeedService) fanOutPost(author_id, post_id string) error {
+	large, err := s.isLarge(author_id)
+
+	if err != nil || large {
+		return err
+	}
+
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	entry := models.TimelineEntryDTO{
+		PostId:    post_meta.Id,
+		AuthorId:  post_meta.Creator,
+		CreatedAt: post_meta.CreatedAt,
+	}
+
+	return eachFollow(s.repo.GetFollowers, author_id, func(follower_id string) error {
+		return s.timelines.AddToTimeline(follower_id, entry)
+	})
+}
+
+// backfill copies the recent posts of a followed account into the follower's
+// timeline, so they show up without waiting for the next post
+func (s *FeedService) backfill(follower_id, followee_id string) error {
+	large, err := s.isLarge(followee_id)
+
+	if err != nil || large {
+		return err
+	}
+
+	// Events can be applied after the follow was undone
+	following, err := s.repo.IsFollowing(follower_id, followee_id)
+
+	if err != nil || !following {
+		return err
+	}
+
+	post_metas, _, err := s.repo.GetPostMetasByCreator(followee_id, "", pagination.MaxLimit)
+
+	if err != nil {
+		return err
+	}
+
+	for _, post_meta := range post_metas {
+		entry := models.TimelineEntryDTO{
+			PostId:    post_meta.Id,
+			AuthorId:  post_meta.Creator,
+			CreatedAt: post_meta.CreatedAt,
+		}
+
+		if err := s.timelines.AddToTimeline(follower_id, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func feedCursor(entry models.TimelineEntryDTO) pagination.Cursor {
+	return pagination.Cursor{Time: entry.CreatedAt, Id: entry.PostId}
+}
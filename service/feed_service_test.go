@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFeedTest wires a FeedService to a test Service whose clock ticks one
+// minute per post, so feeds have a stable order
+func newFeedTest(fanoutThreshold int) (*Service, *FeedService, *repository.InMemoryTimelineRepo) {
+	cfg := config.Default()
+	cfg.FeedFanoutThreshold = fanoutThreshold
+
+	svc, _ := newTestService(time.Minute, WithConfig(cfg))
+	feed, timelines := newTestFeed(svc)
+
+	return svc, feed, timelines
+}
+
+func feedCaptions(t *testing.T, feed *FeedService, user_id string) []string {
+	page, err := feed.GetFeed(user_id, models.FeedQueryDTO{})
+	assert.NoError(t, err)
+	return captions(page.Posts)
+}
+
+func timelineLength(timelines *repository.InMemoryTimelineRepo, user_id string) int {
+	entries, _, _ := timelines.GetTimeline(user_id, "", 100)
+	return len(entries)
+}
+
+func TestFeed_FollowBackfillsAndFansOut(t *testing.T) {
+	svc, feed, timelines := newFeedTest(10)
+
+	createTestPost(t, svc, "alice", "before follow")
+	createTestPost(t, svc, "carol", "not followed")
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	// Following copies the recent posts of the account into the timeline
+	assert.Equal(t, []string{"before follow"}, feedCaptions(t, feed, "bob"))
+
+	createTestPost(t, svc, "alice", "after follow")
+
+	assert.Equal(t, []string{"after follow", "before follow"}, feedCaptions(t, feed, "bob"))
+	assert.Equal(t, 2, timelineLength(timelines, "bob"))
+
+	// Own posts are not part of the home feed
+	assert.Empty(t, feedCaptions(t, feed, "alice"))
+}
+
+func TestFeed_UnfollowRemovesPosts(t *testing.T) {
+	svc, feed, timelines := newFeedTest(10)
+
+	_, _ = svc.FollowUser("bob", "alice")
+	_, _ = svc.FollowUser("bob", "carol")
+	createTestPost(t, svc, "alice", "from alice")
+	createTestPost(t, svc, "carol", "from carol")
+
+	assert.NoError(t, svc.UnfollowUser("bob", "alice"))
+
+	assert.Equal(t, []string{"from carol"}, feedCaptions(t, feed, "bob"))
+	assert.Equal(t, 1, timelineLength(timelines, "bob"))
+
+	// Posts made after unfollowing do not reach the timeline either
+	createTestPost(t, svc, "alice", "later")
+
+	assert.Equal(t, []string{"from carol"}, feedCaptions(t, feed, "bob"))
+}
+
+func TestFeed_LargeAccountsMergedOnRead(t *testing.T) {
+	svc, feed, timelines := newFeedTest(1)
+
+	_, _ = svc.FollowUser("bob", "star")
+	_, _ = svc.FollowUser("carol", "star")
+	_, _ = svc.FollowUser("bob", "alice")
+
+	createTestPost(t, svc, "star", "from star")
+	createTestPost(t, svc, "alice", "from alice")
+
+	// The large account is not fanned out, yet its posts are in the feed
+	assert.Equal(t, 1, timelineLength(timelines, "bob"))
+	assert.Equal(t, 0, timelineLength(timelines, "carol"))
+	assert.Equal(t, []string{"from alice", "from star"}, feedCaptions(t, feed, "bob"))
+	assert.Equal(t, []string{"from star"}, feedCaptions(t, feed, "carol"))
+
+	_ = svc.UnfollowUser("carol", "star")
+
+	assert.Empty(t, feedCaptions(t, feed, "carol"))
+
+	// Back under the threshold, the posts made while it was large are
+	// backfilled and new posts are fanned out again
+	assert.Equal(t, 2, timelineLength(timelines, "bob"))
+
+	createTestPost(t, svc, "star", "small again")
+	assert.Equal(t, 3, timelineLength(timelines, "bob"))
+	assert.Equal(t, []string{"small again", "from alice", "from star"}, feedCaptions(t, feed, "bob"))
+}
+
+func TestFeed_FansOutInTheBackground(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+	timelines := repository.NewInMemoryTimelineRepo(svc.config.FeedTimelineLength)
+	feed := NewFeedService(svc.repo, timelines, svc.config)
+	svc.Subscribe(feed.HandleEvent)
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+	createTestPost(t, svc, "alice", "hello")
+
+	// Creating the post doesn't wait for the timelines
+	assert.Equal(t, 0, timelineLength(timelines, "bob"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feed.RunFanOut(ctx)
+
+	feed.pending.Wait()
+	assert.Equal(t, []string{"hello"}, feedCaptions(t, feed, "bob"))
+}
+
+func TestFeed_FullQueueDoesNotBlock(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+	timelines := repository.NewInMemoryTimelineRepo(svc.config.FeedTimelineLength)
+	feed := NewFeedService(svc.repo, timelines, svc.config)
+	feed.queue = make(chan Event, 1)
+	svc.Subscribe(feed.HandleEvent)
+
+	// With the worker stopped, the follow fills the queue and the rest
+	// overflow
+	_, _ = svc.FollowUser("bob", "alice")
+	_, _ = svc.FollowUser("carol", "alice")
+	createTestPost(t, svc, "alice", "hello")
+	_ = svc.UnfollowUser("carol", "alice")
+
+	// The overflowed account is merged into feeds on read meanwhile
+	assert.Equal(t, 0, timelineLength(timelines, "bob"))
+	assert.Equal(t, []string{"hello"}, feedCaptions(t, feed, "bob"))
+	assert.Empty(t, feedCaptions(t, feed, "carol"))
+
+	// Once the worker catches up, the account is fanned out again
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feed.RunFanOut(ctx)
+
+	feed.pending.Wait()
+	assert.Equal(t, 1, timelineLength(timelines, "bob"))
+	assert.Equal(t, 0, timelineLength(timelines, "carol"))
+	assert.Equal(t, []string{"hello"}, feedCaptions(t, feed, "bob"))
+}
+
+func TestFeed_PaginatesAndSkipsTrashedPosts(t *testing.T) {
+	svc, feed, _ := newFeedTest(10)
+
+	_, _ = svc.FollowUser("bob", "alice")
+	createTestPost(t, svc, "alice", "1")
+	trashed := createTestPost(t, svc, "alice", "2")
+	createTestPost(t, svc, "alice", "3")
+	createTestPost(t, svc, "alice", "4")
+
+	assert.NoError(t, svc.DeletePost(trashed, "alice"))
+
+	page, err := feed.GetFeed("bob", models.FeedQueryDTO{Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Posts, 2)
+	assert.Equal(t, "4", page.Posts[0].Caption)
+	assert.Equal(t, "3", page.Posts[1].Caption)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = feed.GetFeed("bob", models.FeedQueryDTO{Cursor: page.NextCursor, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Posts, 1)
+	assert.Equal(t, "1", page.Posts[0].Caption)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = feed.GetFeed("bob", models.FeedQueryDTO{Cursor: "not a cursor"})
+	assert.EqualError(t, err, "invalid cursor")
+}
+
+func TestFeed_HidesMutedUsers(t *testing.T) {
+	svc, feed, _ := newFeedTest(10)
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+	_, err = svc.FollowUser("bob", "carol")
+	assert.NoError(t, err)
+
+	post_id := createTestPost(t, svc, "alice", "from alice")
+	createTestPost(t, svc, "carol", "from carol")
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, Comment: "noise", AuthorId: "carol"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.MuteUser("bob", "carol"))
+
+	page, err := feed.GetFeed("bob", models.FeedQueryDTO{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Posts, 1)
+	assert.Equal(t, "from alice", page.Posts[0].Caption)
+	assert.Empty(t, page.Posts[0].Comments)
+
+	// Unmuting brings the posts back, as they never left the timeline
+	assert.NoError(t, svc.UnmuteUser("bob", "carol"))
+	assert.Equal(t, []string{"from carol", "from alice"}, feedCaptions(t, feed, "bob"))
+}
+
+func TestFeed_FiltersByVisibility(t *testing.T) {
+	svc, feed, _ := newFeedTest(10)
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	share := func(caption, visibility string) string {
+		post_id, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{Caption: caption, AuthorId: "alice", Visibility: visibility})
+		assert.NoError(t, err)
+		return post_id
+	}
+
+	share("public", models.VisibilityPublic)
+	share("followers", models.VisibilityFollowers)
+	share("close friends", models.VisibilityCloseFriends)
+	share("only me", models.VisibilityOnlyMe)
+
+	assert.Equal(t, []string{"followers", "public"}, feedCaptions(t, feed, "bob"))
+
+	assert.NoError(t, svc.AddCloseFriend("alice", "bob"))
+	assert.Equal(t, []string{"close friends", "followers", "public"}, feedCaptions(t, feed, "bob"))
+
+	// Narrowing a post later takes it out of feeds it already reached
+	post_id := share("changed mind", models.VisibilityPublic)
+	assert.NoError(t, svc.UpdatePostSettings(post_id, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	assert.Equal(t, []string{"close friends", "followers", "public"}, feedCaptions(t, feed, "bob"))
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestService creates a Service over in-memory repositories. Its clock
+// starts on 2024-01-01 and moves by tick on every read, so that everything a
+// test creates has its own time, and whenever the test moves the returned
+// time by hand.
+func newTestService(tick time.Duration, opts ...Option) (*Service, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(tick)
+		return now
+	}
+
+	svc := NewService(repository.NewInMemoryRepo(), append([]Option{WithClock(clock)}, opts...)...)
+
+	return svc, &now
+}
+
+// newTestFeed wires a FeedService to svc. Each event is applied to the
+// timelines before the change that published it returns.
+func newTestFeed(svc *Service) (*FeedService, *repository.InMemoryTimelineRepo) {
+	timelines := repository.NewInMemoryTimelineRepo(svc.config.FeedTimelineLength)
+
+	feed := NewFeedService(svc.repo, timelines, svc.config)
+	go feed.RunFanOut(context.Background())
+
+	svc.Subscribe(func(event Event) {
+		feed.HandleEvent(event)
+		feed.pending.Wait()
+	})
+
+	return feed, timelines
+}
+
+func createTestPost(t *testing.T, svc *Service, author_id, caption string) string {
+	post_id, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{Caption: caption, AuthorId: author_id})
+	assert.NoError(t, err)
+	return post_id
+}
+
+// captions lists the captions of a page of posts, in order
+func captions(posts []models.PostResponseDTO) []string {
+	captions := []string{}
+	for _, post := range posts {
+		captions = append(captions, post.Caption)
+	}
+	return captions
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+)
+
+const maxMessageLength = 2000
+
+func (s *Service) CreateConversation(creator_id string, member_ids []string) (conversation models.ConversationResponseDTO, err error) {
+	members := []string{creator_id}
+	for _, member_id := range member_ids {
+		if member_id != "" && !slices.Contains(members, member_id) {
+			members = append(members, member_id)
+		}
+	}
+
+	if len(members) < 2 || (len(members) > 2 && len(members) > s.config.MaxGroupSize) {
+		return models.ConversationResponseDTO{}, errors.New("invalid members")
+	}
+
+	for _, member_id := range members[1:] {
+		if !s.userExists(member_id) {
+			return models.ConversationResponseDTO{}, errors.New("invalid members")
+		}
+
+		blocked, err := s.eitherBlocks(creator_id, member_id)
+
+		if err != nil {
+			return models.ConversationResponseDTO{}, errors.New("error creating conversation")
+		}
+
+		if blocked {
+			return models.ConversationResponseDTO{}, errors.New("blocked")
+		}
+	}
+
+	saved, err := s.messages.SaveConversation(models.ConversationDTO{
+		CreatorId: creator_id,
+		MemberIds: members,
+		Group:     len(members) > 2,
+		CreatedAt: s.now(),
+	})
+
+	if err != nil {
+		return models.ConversationResponseDTO{}, errors.New("error creating conversation")
+	}
+
+	blocked, err := s.blockedUsers(creator_id)
+
+	if err != nil {
+		return models.ConversationResponseDTO{}, err
+	}
+
+	return s.conversationPreview(saved, creator_id, blocked)
+}
+
+func (s *Service) ListConversations(user_id string, query models.ConversationQueryDTO) (page models.ConversationPageDTO, err error) {
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.ConversationPageDTO{}, err
+		}
+	}
+
+	blocked, err := s.blockedUsers(user_id)
+
+	if err != nil {
+		return models.ConversationPageDTO{}, err
+	}
+
+	conversations := []models.ConversationResponseDTO{}
+
+	// Direct conversations with blocked users are left out, so keep reading
+	// until the page is full or the inbox runs out
+	for len(conversations) < limit {
+		entries, next, err := s.messages.GetUserConversations(user_id, cursor, limit-len(conversations))
+
+		if err != nil {
+			return models.ConversationPageDTO{}, errors.New("error retrieving conversations")
+		}
+
+		for _, entry := range entries {
+			if !entry.Group && slices.ContainsFunc(entry.MemberIds, func(member_id string) bool {
+				return slices.Contains(blocked, member_id)
+			}) {
+				continue
+			}
+
+			conversation, err := s.conversationPreview(entry, user_id, blocked)
+
+			if err != nil {
+				return models.ConversationPageDTO{}, err
+			}
+
+			conversations = append(conversations, conversation)
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return models.ConversationPageDTO{Conversations: conversations, NextCursor: cursor}, nil
+}
+
+func (s *Service) SendMessage(conversation_id, sender_id string, message models.MessageRequestDTO) (sent models.MessageResponseDTO, err error) {
+	conversation, err := s.getMemberConversation(conversation_id, sender_id)
+
+	if err != nil {
+		return models.MessageResponseDTO{}, err
+	}
+
+	text := strings.TrimSpace(message.Text)
+
+	if (text == "" && message.PostId == "") || utf8.RuneCountInString(text) > maxMessageLength {
+		return models.MessageResponseDTO{}, errors.New("invalid message")
+	}
+
+	// Only posts the sender can see can be shared
+	if message.PostId != "" {
+		if _, err := s.getViewablePost(message.PostId, sender_id); err != nil {
+			return models.MessageResponseDTO{}, err
+		}
+	}
+
+	// Blocking ends a direct conversation both ways. In groups, the
+	// messages of blocked users are only hidden from each other.
+	if !conversation.Group {
+		for _, member_id := range conversation.MemberIds {
+			if member_id == sender_id {
+				continue
+			}
+
+			blocked, err := s.eitherBlocks(sender_id, member_id)
+
+			if err != nil {
+				return models.MessageResponseDTO{}, errors.New("error sending message")
+			}
+
+			if blocked {
+				return models.MessageResponseDTO{}, errors.New("blocked")
+			}
+		}
+	}
+
+	saved, err := s.messages.SaveMessage(models.MessageDTO{
+		ConversationId: conversation_id,
+		SenderId:       sender_id,
+		Text:           text,
+		PostId:         message.PostId,
+		CreatedAt:      s.now(),
+	})
+
+	if err != nil {
+		return models.MessageResponseDTO{}, errors.New("error sending message")
+	}
+
+	// Senders have read their own messages
+	if _, err := s.messages.SetReadReceipt(readReceipt(saved, sender_id, saved.CreatedAt)); err != nil {
+		return models.MessageResponseDTO{}, errors.New("error sending message")
+	}
+
+	return s.messageResponse(saved, sender_id), nil
+}
+
+func (s *Service) ListMessages(conversation_id, user_id string, query models.MessageQueryDTO) (page models.MessagePageDTO, err error) {
+	if _, err := s.getMemberConversation(conversation_id, user_id); err != nil {
+		return models.MessagePageDTO{}, err
+	}
+
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.MessagePageDTO{}, err
+		}
+	}
+
+	blocked, err := s.blockedUsers(user_id)
+
+	if err != nil {
+		return models.MessagePageDTO{}, err
+	}
+
+	messages := []models.MessageResponseDTO{}
+
+	// Messages from blocked users are left out, so keep reading until the
+	// page is full or the conversation runs out
+	for len(messages) < limit {
+		entries, next, err := s.messages.GetMessages(conversation_id, cursor, limit-len(messages))
+
+		if err != nil {
+			return models.MessagePageDTO{}, errors.New("error retrieving messages")
+		}
+
+		for _, entry := range entries {
+			if !slices.Contains(blocked, entry.SenderId) {
+				messages = append(messages, s.messageResponse(entry, user_id))
+			}
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	receipts, err := s.messages.GetReadReceipts(conversation_id)
+
+	if err != nil {
+		return models.MessagePageDTO{}, errors.New("error retrieving messages")
+	}
+
+	// Readers see how far the other members have read, most recent first
+	slices.SortFunc(receipts, func(a, b models.ReadReceiptDTO) int {
+		return b.ReadAt.Compare(a.ReadAt)
+	})
+
+	read := []models.ReadReceiptResponseDTO{}
+	for _, receipt := range receipts {
+		if receipt.UserId != user_id && !slices.Contains(blocked, receipt.UserId) {
+			read = append(read, models.ReadReceiptResponseDTO{UserId: receipt.UserId, MessageId: receipt.MessageId, ReadAt: receipt.ReadAt})
+		}
+	}
+
+	return models.MessagePageDTO{Messages: messages, ReadReceipts: read, NextCursor: cursor}, nil
+}
+
+func (s *Service) MarkConversationRead(conversation_id, user_id, message_id string) (err error) {
+	if _, err := s.getMemberConversation(conversation_id, user_id); err != nil {
+		return err
+	}
+
+	var message models.MessageDTO
+
+	// Without a message, the whole conversation is read
+	if message_id == "" {
+		latest, _, err := s.messages.GetMessages(conversation_id, "", 1)
+
+		if err != nil {
+			return errors.New("error retrieving messages")
+		}
+
+		if len(latest) == 0 {
+			return nil
+		}
+
+		message = latest[0]
+	} else {
+		message, err = s.messages.GetMessage(message_id)
+
+		if err != nil || message.ConversationId != conversation_id {
+			return errors.New("error retrieving message")
+		}
+	}
+
+	if _, err := s.messages.SetReadReceipt(readReceipt(message, user_id, s.now())); err != nil {
+		return errors.New("error marking conversation read")
+	}
+
+	return nil
+}
+
+// getMemberConversation returns a conversation the user is a member of.
+// Other users can't tell it exists.
+func (s *Service) getMemberConversation(conversation_id, user_id string) (models.ConversationDTO, error) {
+	conversation, err := s.messages.GetConversation(conversation_id)
+
+	if err != nil || !slices.Contains(conversation.MemberIds, user_id) {
+		return models.ConversationDTO{}, errors.New("error retrieving conversation")
+	}
+
+	return conversation, nil
+}
+
+// conversationPreview lists a conversation in the inbox of a member, with
+// the latest message they can see and how many they haven't read
+func (s *Service) conversationPreview(conversation models.ConversationDTO, viewer_id string, blocked []string) (models.ConversationResponseDTO, error) {
+	preview := models.ConversationResponseDTO{
+		Id:            conversation.Id,
+		MemberIds:     conversation.MemberIds,
+		Group:         conversation.Group,
+		LastMessageAt: conversation.LastMessageAt,
+	}
+
+	cursor := ""
+	for preview.LastMessage == nil {
+		messages, next, err := s.messages.GetMessages(conversation.Id, cursor, pagination.DefaultLimit)
+
+		if err != nil {
+			return models.ConversationResponseDTO{}, errors.New("error retrieving conversations")
+		}
+
+		for _, message := range messages {
+			if !slices.Contains(blocked, message.SenderId) {
+				last := s.messageResponse(message, viewer_id)
+				preview.LastMessage = &last
+				break
+			}
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	unread, err := s.messages.CountUnreadMessages(conversation.Id, viewer_id, blocked)
+
+	if err != nil {
+		return models.ConversationResponseDTO{}, errors.New("error retrieving conversations")
+	}
+
+	preview.UnreadCount = unread
+
+	return preview, nil
+}
+
+// messageResponse prepares a message for a reader, with a preview of the
+// shared post if the reader can see it
+func (s *Service) messageResponse(message models.MessageDTO, reader_id string) models.MessageResponseDTO {
+	response := models.MessageResponseDTO{
+		Id:        message.Id,
+		SenderId:  message.SenderId,
+		Text:      message.Text,
+		PostId:    message.PostId,
+		CreatedAt: message.CreatedAt,
+	}
+
+	if message.PostId != "" {
+		if post_meta, err := s.getViewablePost(message.PostId, reader_id); err == nil {
+			post := postPreview(post_meta, nil)
+			response.Post = &post
+		}
+	}
+
+	return response
+}
+
+// blockedUsers lists the users a user blocks or is blocked by
+func (s *Service) blockedUsers(user_id string) ([]string, error) {
+	blocked, blocked_by, err := s.repo.GetBlocks(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving blocks")
+	}
+
+	return slices.Concat(blocked, blocked_by), nil
+}
+
+// userExists tells whether an account exists. Without a user repository
+// every user does.
+func (s *Service) userExists(user_id string) bool {
+	if s.users == nil {
+		return true
+	}
+
+	_, err := s.users.GetUserByID(user_id)
+	return err == nil
+}
+
+func readReceipt(message models.MessageDTO, user_id string, read_at time.Time) models.ReadReceiptDTO {
+	return models.ReadReceiptDTO{
+		ConversationId: message.ConversationId,
+		UserId:         user_id,
+		MessageId:      message.Id,
+		MessageAt:      message.CreatedAt,
+		ReadAt:         read_at,
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func sendTestMessage(t *testing.T, svc *Service, conversation_id, sender_id, text string) string {
+	message, err := svc.SendMessage(conversation_id, sender_id, models.MessageRequestDTO{Text: text})
+	assert.NoError(t, err)
+	return message.Id
+}
+
+func messageTexts(t *testing.T, svc *Service, conversation_id, user_id string) []string {
+	page, err := svc.ListMessages(conversation_id, user_id, models.MessageQueryDTO{})
+	assert.NoError(t, err)
+
+	texts := []string{}
+	for _, message := range page.Messages {
+		texts = append(texts, message.Text)
+	}
+	return texts
+}
+
+func inbox(t *testing.T, svc *Service, user_id string) []models.ConversationResponseDTO {
+	page, err := svc.ListConversations(user_id, models.ConversationQueryDTO{})
+	assert.NoError(t, err)
+	return page.Conversations
+}
+
+func TestMessages_DirectConversation(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	conversation, err := svc.CreateConversation("alice", []string{"bob", "bob"})
+	assert.NoError(t, err)
+	assert.False(t, conversation.Group)
+	assert.Equal(t, []string{"alice", "bob"}, conversation.MemberIds)
+
+	// Either of them gets back to the same conversation
+	again, _ := svc.CreateConversation("bob", []string{"alice"})
+	assert.Equal(t, conversation.Id, again.Id)
+
+	sendTestMessage(t, svc, conversation.Id, "alice", "hi")
+	sendTestMessage(t, svc, conversation.Id, "alice", " how are you? ")
+
+	conversations := inbox(t, svc, "bob")
+	assert.Len(t, conversations, 1)
+	assert.Equal(t, "how are you?", conversations[0].LastMessage.Text)
+	assert.Equal(t, 2, conversations[0].UnreadCount)
+	assert.Equal(t, 0, inbox(t, svc, "alice")[0].UnreadCount)
+
+	assert.Equal(t, []string{"how are you?", "hi"}, messageTexts(t, svc, conversation.Id, "bob"))
+
+	// Bob sees that Alice read her own messages, and she sees when he
+	// catches up
+	page, _ := svc.ListMessages(conversation.Id, "bob", models.MessageQueryDTO{})
+	assert.Len(t, page.ReadReceipts, 1)
+	assert.Equal(t, "alice", page.ReadReceipts[0].UserId)
+	assert.Equal(t, page.Messages[0].Id, page.ReadReceipts[0].MessageId)
+
+	assert.NoError(t, svc.MarkConversationRead(conversation.Id, "bob", page.Messages[1].Id))
+	assert.Equal(t, 1, inbox(t, svc, "bob")[0].UnreadCount)
+
+	assert.NoError(t, svc.MarkConversationRead(conversation.Id, "bob", ""))
+	assert.Equal(t, 0, inbox(t, svc, "bob")[0].UnreadCount)
+
+	page, _ = svc.ListMessages(conversation.Id, "alice", models.MessageQueryDTO{})
+	assert.Equal(t, "bob", page.ReadReceipts[0].UserId)
+	assert.Equal(t, page.Messages[0].Id, page.ReadReceipts[0].MessageId)
+
+	// Outsiders can't tell the conversation exists
+	_, err = svc.ListMessages(conversation.Id, "carol", models.MessageQueryDTO{})
+	assert.EqualError(t, err, "error retrieving conversation")
+	_, err = svc.SendMessage(conversation.Id, "carol", models.MessageRequestDTO{Text: "hey"})
+	assert.EqualError(t, err, "error retrieving conversation")
+	assert.EqualError(t, svc.MarkConversationRead(conversation.Id, "carol", ""), "error retrieving conversation")
+
+	other, _ := svc.CreateConversation("alice", []string{"carol"})
+	carols := sendTestMessage(t, svc, other.Id, "carol", "hello")
+	assert.EqualError(t, svc.MarkConversationRead(conversation.Id, "bob", carols), "error retrieving message")
+}
+
+func TestMessages_InboxOrderAndPaging(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	withBob, _ := svc.CreateConversation("alice", []string{"bob"})
+	withCarol, _ := svc.CreateConversation("alice", []string{"carol"})
+	group, _ := svc.CreateConversation("alice", []string{"bob", "carol"})
+	assert.True(t, group.Group)
+
+	sendTestMessage(t, svc, withBob.Id, "bob", "first")
+
+	page, err := svc.ListConversations("alice", models.ConversationQueryDTO{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, withBob.Id, page.Conversations[0].Id)
+	assert.Equal(t, group.Id, page.Conversations[1].Id)
+
+	page, err = svc.ListConversations("alice", models.ConversationQueryDTO{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, withCarol.Id, page.Conversations[0].Id)
+	assert.Nil(t, page.Conversations[0].LastMessage)
+	assert.Empty(t, page.NextCursor)
+
+	// Paging through messages
+	for i := 0; i < 5; i++ {
+		sendTestMessage(t, svc, group.Id, "carol", fmt.Sprint(i))
+	}
+
+	messages, err := svc.ListMessages(group.Id, "bob", models.MessageQueryDTO{Limit: 3})
+	assert.NoError(t, err)
+	assert.Len(t, messages.Messages, 3)
+
+	messages, _ = svc.ListMessages(group.Id, "bob", models.MessageQueryDTO{Cursor: messages.NextCursor})
+	assert.Equal(t, "1", messages.Messages[0].Text)
+	assert.Empty(t, messages.NextCursor)
+}
+
+func TestMessages_SharePost(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	conversation, _ := svc.CreateConversation("alice", []string{"bob"})
+	post_id := createTestPost(t, svc, "alice", "look at this")
+
+	sent, err := svc.SendMessage(conversation.Id, "alice", models.MessageRequestDTO{PostId: post_id})
+	assert.NoError(t, err)
+	assert.Equal(t, "look at this", sent.Post.Caption)
+
+	page, _ := svc.ListMessages(conversation.Id, "bob", models.MessageQueryDTO{})
+	assert.Equal(t, "look at this", page.Messages[0].Post.Caption)
+
+	// Once the post is out of Bob's reach, only its id is left
+	assert.NoError(t, svc.UpdatePostSettings(post_id, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+
+	page, _ = svc.ListMessages(conversation.Id, "bob", models.MessageQueryDTO{})
+	assert.Equal(t, post_id, page.Messages[0].PostId)
+	assert.Nil(t, page.Messages[0].Post)
+
+	// Bob can't pass on a post he can't see
+	_, err = svc.SendMessage(conversation.Id, "bob", models.MessageRequestDTO{PostId: post_id})
+	assert.EqualError(t, err, "error retrieving post")
+
+	_, err = svc.SendMessage(conversation.Id, "bob", models.MessageRequestDTO{Text: "   "})
+	assert.EqualError(t, err, "invalid message")
+}
+
+func TestMessages_Blocks(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	direct, _ := svc.CreateConversation("alice", []string{"bob"})
+	group, _ := svc.CreateConversation("alice", []string{"bob", "carol"})
+	sendTestMessage(t, svc, direct.Id, "bob", "hi alice")
+
+	assert.NoError(t, svc.BlockUser("alice", "bob"))
+
+	// Blocking ends the direct conversation both ways
+	_, err := svc.SendMessage(direct.Id, "bob", models.MessageRequestDTO{Text: "hello?"})
+	assert.EqualError(t, err, "blocked")
+	_, err = svc.SendMessage(direct.Id, "alice", models.MessageRequestDTO{Text: "bye"})
+	assert.EqualError(t, err, "blocked")
+
+	_, err = svc.CreateConversation("bob", []string{"alice"})
+	assert.EqualError(t, err, "blocked")
+	_, err = svc.CreateConversation("bob", []string{"carol", "alice"})
+	assert.EqualError(t, err, "blocked")
+
+	conversations := inbox(t, svc, "alice")
+	assert.Len(t, conversations, 1)
+	assert.Equal(t, group.Id, conversations[0].Id)
+
+	// In groups they just don't see each other's messages
+	sendTestMessage(t, svc, group.Id, "carol", "from carol")
+	sendTestMessage(t, svc, group.Id, "bob", "from bob")
+
+	assert.Equal(t, []string{"from carol"}, messageTexts(t, svc, group.Id, "alice"))
+	assert.Equal(t, []string{"from bob", "from carol"}, messageTexts(t, svc, group.Id, "carol"))
+
+	conversations = inbox(t, svc, "alice")
+	assert.Equal(t, "from carol", conversations[0].LastMessage.Text)
+	assert.Equal(t, 1, conversations[0].UnreadCount)
+
+	assert.NoError(t, svc.MarkConversationRead(group.Id, "bob", ""))
+	page, _ := svc.ListMessages(group.Id, "alice", models.MessageQueryDTO{})
+	for _, receipt := range page.ReadReceipts {
+		assert.NotEqual(t, "bob", receipt.UserId)
+	}
+}
+
+func TestMessages_Members(t *testing.T) {
+	svc, _ := newTestService(time.Minute)
+
+	_, err := svc.CreateConversation("alice", nil)
+	assert.EqualError(t, err, "invalid members")
+	_, err = svc.CreateConversation("alice", []string{"alice"})
+	assert.EqualError(t, err, "invalid members")
+
+	members := []string{}
+	for i := 0; i < svc.config.MaxGroupSize; i++ {
+		members = append(members, fmt.Sprint("user", i))
+	}
+	_, err = svc.CreateConversation("alice", members)
+	assert.EqualError(t, err, "invalid members")
+
+	_, err = svc.CreateConversation("alice", members[1:])
+	assert.NoError(t, err)
+
+	// Members must have an account
+	users := repository.NewInMemoryUserRepo()
+	bob, _ := users.SaveUser(models.User{Username: "bob"})
+	svc = NewService(repository.NewInMemoryRepo(), WithUserRepository(users))
+
+	_, err = svc.CreateConversation("alice", []string{bob, "nobody"})
+	assert.EqualError(t, err, "invalid members")
+	_, err = svc.CreateConversation("alice", []string{bob})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,11 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type INotificationService interface {
+	// Get a page of the notifications of a user, most recent first
+	ListNotifications(user_id string, query models.NotificationQueryDTO) (page models.NotificationPageDTO, err error)
+
+	// Notify users of a change made through the Service that concerns them
+	HandleEvent(event Event)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/anandh86/instagram/repository"
+)
+
+// NotificationService keeps the notifications of each user, such as being
+// mentioned in a post or comment
+type NotificationService struct {
+	repo          repository.IRepository
+	notifications repository.INotificationRepository
+}
+
+func NewNotificationService(repo repository.IRepository, notifications repository.INotificationRepository) *NotificationService {
+
+	// compile-time check to ensure we implement the interface
+	var _ INotificationService = (*NotificationService)(nil)
+
+	return &NotificationService{
+		repo:          repo,
+		notifications: notifications,
+	}
+}
+
+func (s *NotificationService) ListNotifications(user_id string, query models.NotificationQueryDTO) (page models.NotificationPageDTO, err error) {
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.NotificationPageDTO{}, err
+		}
+	}
+
+	hiddenList, err := hiddenUsers(s.repo, user_id)
+
+	if err != nil {
+		return models.NotificationPageDTO{}, errors.New("error retrieving notifications")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	notifications := []models.NotificationDTO{}
+
+	// Notifications from users muted or blocked since are skipped, so keep
+	// reading until the page is full or the list runs out
+	for len(notifications) < limit {
+		entries, next, err := s.notifications.GetNotifications(user_id, cursor, limit-len(notifications))
+
+		if err != nil {
+			return models.NotificationPageDTO{}, errors.New("error retrieving notifications")
+		}
+
+		for _, notification := range entries {
+			if !hidden[notification.ActorId] {
+				notifications = append(notifications, notification)
+			}
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return models.NotificationPageDTO{Notifications: notifications, NextCursor: cursor}, nil
+}
+
+func (s *NotificationService) HandleEvent(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventUserMentioned:
+		_, err = s.notifications.AddNotification(models.NotificationDTO{
+			UserId:    event.TargetId,
+			Type:      models.NotificationMention,
+			ActorId:   event.UserId,
+			PostId:    event.PostId,
+			CommentId: event.CommentId,
+			CreatedAt: event.At,
+		})
+	}
+
+	if err != nil {
+		log.Printf("notifying users of %s event: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMentionTest wires a NotificationService to a Service that knows the
+// given usernames, returning their user ids by username
+func newMentionTest(t *testing.T, usernames ...string) (*Service, *NotificationService, map[string]string) {
+	svc, _ := newTestService(time.Minute)
+
+	users := repository.NewInMemoryUserRepo()
+	svc.users = users
+
+	ids := make(map[string]string, len(usernames))
+	for _, username := range usernames {
+		user_id, err := users.SaveUser(models.User{Username: username})
+		assert.NoError(t, err)
+		ids[username] = user_id
+	}
+
+	notifications := NewNotificationService(svc.repo, repository.NewInMemoryNotificationRepo())
+	svc.Subscribe(notifications.HandleEvent)
+
+	return svc, notifications, ids
+}
+
+func mentionedPosts(t *testing.T, notifications *NotificationService, user_id string) []string {
+	page, err := notifications.ListNotifications(user_id, models.NotificationQueryDTO{})
+	assert.NoError(t, err)
+
+	post_ids := []string{}
+	for _, notification := range page.Notifications {
+		assert.Equal(t, models.NotificationMention, notification.Type)
+		post_ids = append(post_ids, notification.PostId)
+	}
+	return post_ids
+}
+
+func TestMentions_InCaptions(t *testing.T) {
+	svc, notifications, ids := newMentionTest(t, "alice", "bob", "carol")
+
+	post_id := createTestPost(t, svc, ids["alice"], "With @Bob, @nobody and @alice")
+
+	_, post, err := svc.GetPostById(post_id, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.MentionDTO{
+		{UserId: ids["bob"], Username: "bob", Start: 5, End: 9},
+		{UserId: ids["alice"], Username: "alice", Start: 23, End: 29},
+	}, post.Mentions)
+
+	// Authors are not notified of their own mentions
+	assert.Equal(t, []string{post_id}, mentionedPosts(t, notifications, ids["bob"]))
+	assert.Empty(t, mentionedPosts(t, notifications, ids["alice"]))
+
+	// Editing the caption only notifies the newly mentioned users
+	assert.NoError(t, svc.EditPost(post_id, ids["alice"], "With @bob and @carol"))
+
+	_, post, _ = svc.GetPostById(post_id, "")
+	assert.Len(t, post.Mentions, 2)
+	assert.Equal(t, []string{post_id}, mentionedPosts(t, notifications, ids["bob"]))
+	assert.Equal(t, []string{post_id}, mentionedPosts(t, notifications, ids["carol"]))
+}
+
+func TestMentions_InComments(t *testing.T) {
+	svc, notifications, ids := newMentionTest(t, "alice", "bob", "carol")
+
+	post_id := createTestPost(t, svc, ids["alice"], "no mentions")
+
+	comment_id, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, AuthorId: ids["bob"], Comment: "hey @alice"})
+	assert.NoError(t, err)
+
+	page, _ := notifications.ListNotifications(ids["alice"], models.NotificationQueryDTO{})
+	assert.Len(t, page.Notifications, 1)
+	assert.Equal(t, ids["bob"], page.Notifications[0].ActorId)
+	assert.Equal(t, comment_id, page.Notifications[0].CommentId)
+
+	comment, err := svc.EditComment(comment_id, ids["bob"], "hey @alice and @carol")
+	assert.NoError(t, err)
+	assert.Len(t, comment.Mentions, 2)
+
+	assert.Len(t, mentionedPosts(t, notifications, ids["alice"]), 1)
+	assert.Equal(t, []string{post_id}, mentionedPosts(t, notifications, ids["carol"]))
+
+	// Muting the actor hides their notifications
+	assert.NoError(t, svc.MuteUser(ids["alice"], ids["bob"]))
+	assert.Empty(t, mentionedPosts(t, notifications, ids["alice"]))
+}
+
+func TestMentions_BlockedAndUnseen(t *testing.T) {
+	svc, notifications, ids := newMentionTest(t, "alice", "bob", "carol")
+
+	// Mentions of users who block the author are dropped
+	assert.NoError(t, svc.BlockUser(ids["bob"], ids["alice"]))
+
+	post_id := createTestPost(t, svc, ids["alice"], "hi @bob and @carol")
+
+	_, post, _ := svc.GetPostById(post_id, "")
+	assert.Equal(t, []models.MentionDTO{{UserId: ids["carol"], Username: "carol", Start: 12, End: 18}}, post.Mentions)
+	assert.Empty(t, mentionedPosts(t, notifications, ids["bob"]))
+
+	// Users who can't see the post keep the mention but are not notified
+	assert.NoError(t, svc.UpdatePostSettings(post_id, ids["alice"], models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	assert.NoError(t, svc.EditPost(post_id, ids["alice"], "hi @carol"))
+
+	other := createTestPost(t, svc, ids["alice"], "draft")
+	assert.NoError(t, svc.UpdatePostSettings(other, ids["alice"], models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	assert.NoError(t, svc.EditPost(other, ids["alice"], "draft for @carol"))
+
+	assert.Equal(t, []string{post_id}, mentionedPosts(t, notifications, ids["carol"]))
+}
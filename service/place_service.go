@@ -0,0 +1,16 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type IPlaceService interface {
+	// Get a page of the posts tagged within a distance of a point that the
+	// viewer can see, newest first
+	GetPostsNear(viewer_id string, query models.NearbyQueryDTO) (page models.NearbyPageDTO, err error)
+
+	// Get a page of the posts tagged with a place that the viewer can see,
+	// newest first, along with how many posts are tagged with it
+	GetPlacePosts(place_id, viewer_id string, query models.PlaceQueryDTO) (page models.PlacePageDTO, err error)
+
+	// Keep the map of posts up to date with a change made through the Service
+	HandleEvent(event Event)
+}
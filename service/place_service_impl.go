@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+
+	"github.com/anandh86/instagram/geo"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+)
+
+// PlaceService puts the posts tagged with a location on the map, in the
+// place repository of the Service, so they can be found by place or by
+// distance. Posts are only on the map while they are live.
+type PlaceService struct {
+	posts *Service
+}
+
+func NewPlaceService(posts *Service) *PlaceService {
+
+	// compile-time check to ensure we implement the interface
+	var _ IPlaceService = (*PlaceService)(nil)
+
+	return &PlaceService{posts: posts}
+}
+
+func (s *PlaceService) GetPostsNear(viewer_id string, query models.NearbyQueryDTO) (page models.NearbyPageDTO, err error) {
+	center := geo.Point{Lat: query.Latitude, Lng: query.Longitude}
+
+	if !validPoint(center) {
+		return models.NearbyPageDTO{}, errors.New("invalid location")
+	}
+
+	radius := query.RadiusKm
+	if radius == 0 {
+		radius = s.posts.config.NearbyRadiusKm
+	}
+
+	if math.IsNaN(radius) || radius < 0 || radius > s.posts.config.MaxNearbyRadiusKm {
+		return models.NearbyPageDTO{}, errors.New("invalid radius")
+	}
+
+	posts, next, err := s.readViewable(viewer_id, query.Cursor, query.Limit, func(cursor string, limit int) ([]models.LocatedPostDTO, string, error) {
+		return s.posts.places.GetPostsNear(center.Lat, center.Lng, radius, cursor, limit)
+	})
+
+	if err != nil {
+		return models.NearbyPageDTO{}, err
+	}
+
+	return models.NearbyPageDTO{Posts: posts, NextCursor: next}, nil
+}
+
+func (s *PlaceService) GetPlacePosts(place_id, viewer_id string, query models.PlaceQueryDTO) (page models.PlacePageDTO, err error) {
+	place, err := s.posts.places.GetPlace(place_id)
+
+	if err != nil {
+		return models.PlacePageDTO{}, errors.New("error retrieving place")
+	}
+
+	count, err := s.posts.places.CountPlacePosts(place_id)
+
+	if err != nil {
+		return models.PlacePageDTO{}, errors.New("error retrieving posts")
+	}
+
+	posts, next, err := s.readViewable(viewer_id, query.Cursor, query.Limit, func(cursor string, limit int) ([]models.LocatedPostDTO, string, error) {
+		return s.posts.places.GetPlacePosts(place_id, cursor, limit)
+	})
+
+	if err != nil {
+		return models.PlacePageDTO{}, err
+	}
+
+	return models.PlacePageDTO{Place: place, PostCount: count, Posts: posts, NextCursor: next}, nil
+}
+
+// readViewable reads a page of located posts, skipping those the viewer
+// cannot see or has muted
+func (s *PlaceService) readViewable(viewer_id, cursor string, limit int, read func(cursor string, limit int) ([]models.LocatedPostDTO, string, error)) ([]models.PostResponseDTO, string, error) {
+	limit = pagination.ClampLimit(limit)
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	hiddenList, err := hiddenUsers(s.posts.repo, viewer_id)
+
+	if err != nil {
+		return nil, "", errors.New("error retrieving posts")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	posts := []models.PostResponseDTO{}
+
+	// Keep reading until the page is full or the posts run out
+	for len(posts) < limit {
+		entries, next, err := read(cursor, limit-len(posts))
+
+		if err != nil {
+			return nil, "", errors.New("error retrieving posts")
+		}
+
+		for _, entry := range entries {
+			post_meta, err := s.posts.getViewablePost(entry.PostId, viewer_id)
+
+			if err != nil || hidden[post_meta.Creator] {
+				continue
+			}
+
+			comments, _ := s.posts.repo.GetPostLatestComments(post_meta.Id, s.posts.config.PreviewComments, hiddenList)
+
+			posts = append(posts, postPreview(post_meta, comments))
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return posts, cursor, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Indexing
+------------------------------------------------------------------------*/
+
+func (s *PlaceService) HandleEvent(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventPostCreated, EventPostRestored:
+		err = s.indexPost(event.PostId)
+	case EventPostDeleted:
+		err = s.posts.places.RemovePostLocation(event.PostId)
+	}
+
+	if err != nil {
+		log.Printf("updating post locations for %s event: %v", event.Type, err)
+	}
+}
+
+// indexPost puts a post on the map at its place, or takes it off while it
+// is trashed
+func (s *PlaceService) indexPost(post_id string) error {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	if post_meta.Location == nil || post_meta.DeletedAt != nil {
+		return s.posts.places.RemovePostLocation(post_id)
+	}
+
+	return s.posts.places.SetPostLocation(models.LocatedPostDTO{
+		PostId:    post_meta.Id,
+		CreatedAt: post_meta.CreatedAt,
+		PlaceId:   post_meta.Location.Id,
+		Latitude:  post_meta.Location.Latitude,
+		Longitude: post_meta.Location.Longitude,
+	})
+}
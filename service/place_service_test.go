@@ -0,0 +1,135 @@
+package service
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	louvre = models.PlaceDTO{Name: "Louvre Museum", Latitude: 48.8606, Longitude: 2.3376}
+	eiffel = models.PlaceDTO{Name: "Eiffel Tower", Latitude: 48.8584, Longitude: 2.2945}
+	london = models.PlaceDTO{Name: "British Museum", Latitude: 51.5194, Longitude: -0.1270}
+)
+
+// newPlaceTest wires a PlaceService to a test Service whose clock moves a
+// minute on every read
+func newPlaceTest() (*Service, *PlaceService) {
+	svc, _ := newTestService(time.Minute)
+
+	places := NewPlaceService(svc)
+	svc.Subscribe(places.HandleEvent)
+
+	return svc, places
+}
+
+func createLocatedPost(t *testing.T, svc *Service, author_id, caption string, place models.PlaceDTO) string {
+	post_id, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{Caption: caption, AuthorId: author_id, Location: &place})
+	assert.NoError(t, err)
+	return post_id
+}
+
+func nearbyCaptions(t *testing.T, places *PlaceService, viewer_id string, place models.PlaceDTO, radius float64) []string {
+	page, err := places.GetPostsNear(viewer_id, models.NearbyQueryDTO{Latitude: place.Latitude, Longitude: place.Longitude, RadiusKm: radius})
+	assert.NoError(t, err)
+	return captions(page.Posts)
+}
+
+func TestPlaces_Nearby(t *testing.T) {
+	svc, places := newPlaceTest()
+
+	createLocatedPost(t, svc, "alice", "mona lisa", louvre)
+	createLocatedPost(t, svc, "bob", "tower", eiffel)
+	createLocatedPost(t, svc, "carol", "rosetta", london)
+	createTestPost(t, svc, "dave", "nowhere")
+
+	assert.Equal(t, []string{"mona lisa"}, nearbyCaptions(t, places, "", louvre, 1))
+	assert.Equal(t, []string{"tower", "mona lisa"}, nearbyCaptions(t, places, "", louvre, 0))
+	assert.Equal(t, []string{"tower", "mona lisa"}, nearbyCaptions(t, places, "", louvre, 100))
+	assert.Equal(t, []string{"rosetta"}, nearbyCaptions(t, places, "", london, 100))
+
+	_, err := places.GetPostsNear("", models.NearbyQueryDTO{Latitude: 91})
+	assert.EqualError(t, err, "invalid location")
+
+	_, err = places.GetPostsNear("", models.NearbyQueryDTO{Latitude: 48, Longitude: 2, RadiusKm: 1000})
+	assert.EqualError(t, err, "invalid radius")
+
+	_, err = places.GetPostsNear("", models.NearbyQueryDTO{Latitude: 48, Longitude: 2, Cursor: "%"})
+	assert.EqualError(t, err, "invalid cursor")
+}
+
+func TestPlaces_GroupsPostsByPlace(t *testing.T) {
+	svc, places := newPlaceTest()
+
+	first := createLocatedPost(t, svc, "alice", "first", louvre)
+
+	// Same place, spelled differently, from a few steps away
+	nearby := models.PlaceDTO{Name: "louvre museum ", Latitude: 48.8608, Longitude: 2.3379}
+	second := createLocatedPost(t, svc, "bob", "second", nearby)
+	createLocatedPost(t, svc, "carol", "elsewhere", eiffel)
+
+	_, info, err := svc.GetPostById(first, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Louvre Museum", info.Location.Name)
+
+	_, other, _ := svc.GetPostById(second, "")
+	assert.Equal(t, info.Location, other.Location)
+
+	page, err := places.GetPlacePosts(info.Location.Id, "", models.PlaceQueryDTO{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, *info.Location, page.Place)
+	assert.Equal(t, 2, page.PostCount)
+	assert.Equal(t, "second", page.Posts[0].Caption)
+	assert.Equal(t, info.Location, page.Posts[0].Location)
+
+	page, err = places.GetPlacePosts(info.Location.Id, "", models.PlaceQueryDTO{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, "first", page.Posts[0].Caption)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = places.GetPlacePosts("missing", "", models.PlaceQueryDTO{})
+	assert.EqualError(t, err, "error retrieving place")
+}
+
+func TestPlaces_OnlyLiveViewablePosts(t *testing.T) {
+	svc, places := newPlaceTest()
+
+	trashed := createLocatedPost(t, svc, "alice", "trashed", louvre)
+	assert.NoError(t, svc.DeletePost(trashed, "alice"))
+	assert.Empty(t, nearbyCaptions(t, places, "", louvre, 1))
+
+	assert.NoError(t, svc.RestorePost(trashed, "alice"))
+	assert.Equal(t, []string{"trashed"}, nearbyCaptions(t, places, "", louvre, 1))
+
+	// Drafts go on the map when they are published
+	draft, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{Caption: "draft", AuthorId: "alice", Location: &louvre, Draft: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"trashed"}, nearbyCaptions(t, places, "alice", louvre, 1))
+
+	assert.NoError(t, svc.PublishPost(draft, "alice"))
+	assert.Equal(t, []string{"draft", "trashed"}, nearbyCaptions(t, places, "", louvre, 1))
+
+	// Blocked users and posts shared with nobody stay hidden
+	private := createLocatedPost(t, svc, "bob", "private", louvre)
+	assert.NoError(t, svc.UpdatePostSettings(private, "bob", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	assert.NoError(t, svc.BlockUser("alice", "carol"))
+
+	assert.Equal(t, []string{"private", "draft", "trashed"}, nearbyCaptions(t, places, "bob", louvre, 1))
+	assert.Empty(t, nearbyCaptions(t, places, "carol", louvre, 1))
+}
+
+func TestPlaces_InvalidLocation(t *testing.T) {
+	svc, _ := newPlaceTest()
+
+	for _, location := range []models.PlaceDTO{
+		{Name: "", Latitude: 48, Longitude: 2},
+		{Name: "North of the pole", Latitude: 95, Longitude: 2},
+		{Name: "Past the antimeridian", Latitude: 0, Longitude: -181},
+	} {
+		_, err := svc.CreatePost(image.NewRGBA(image.Rect(0, 0, 1, 1)), models.PostRequestDTO{AuthorId: "alice", Location: &location})
+		assert.EqualError(t, err, "invalid location")
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anandh86/instagram/geo"
+	"github.com/anandh86/instagram/models"
+)
+
+const maxPlaceNameLength = 100
+
+// resolvePlace validates the location a post is tagged with and files it
+// under a place, the one already known under that name nearby if any. No
+// location gives no place.
+func (s *Service) resolvePlace(location *models.PlaceDTO) (*models.PlaceDTO, error) {
+	if location == nil {
+		return nil, nil
+	}
+
+	name := strings.TrimSpace(location.Name)
+	point := geo.Point{Lat: location.Latitude, Lng: location.Longitude}
+
+	if name == "" || utf8.RuneCountInString(name) > maxPlaceNameLength || !validPoint(point) {
+		return nil, errors.New("invalid location")
+	}
+
+	place, err := s.places.FindOrAddPlace(models.PlaceDTO{Name: name, Latitude: point.Lat, Longitude: point.Lng}, s.config.PlaceMergeRadiusKm)
+
+	if err != nil {
+		return nil, errors.New("error saving location")
+	}
+
+	return &place, nil
+}
+
+// validPoint tells whether a point is on the map, and not NaN
+func validPoint(p geo.Point) bool {
+	return p.Valid() && !math.IsNaN(p.Lat) && !math.IsNaN(p.Lng)
+}
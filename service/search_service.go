@@ -0,0 +1,16 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type ISearchService interface {
+	// Get a page of the posts, users or hashtags matching a query, best
+	// match first, leaving out what the viewer cannot see
+	Search(viewer_id string, query models.SearchQueryDTO) (page models.SearchPageDTO, err error)
+
+	// Index the posts, comments and users from scratch
+	Rebuild() (err error)
+
+	// Keep the search index up to date with a change made through the
+	// Service or the UserService
+	HandleEvent(event Event)
+}
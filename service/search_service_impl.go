@@ -0,0 +1,387 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/anandh86/instagram/repository"
+	"github.com/anandh86/instagram/search"
+	"github.com/anandh86/instagram/text"
+)
+
+// SearchService finds posts by the words of their caption and comments,
+// users by their username and display name, and hashtags by their start.
+// Posts and users are kept in full-text indexes ranked with BM25, updated as
+// posts, comments and profiles change.
+type SearchService struct {
+	posts *Service
+	users repository.IUserRepository
+	tags  repository.ITagRepository
+
+	// Guards the indexes while they are swapped by Rebuild
+	mu sync.RWMutex
+
+	// Captions by post id, and comments by post id and comment id
+	postIndex *search.Index
+	userIndex *search.Index
+}
+
+func NewSearchService(posts *Service, users repository.IUserRepository, tags repository.ITagRepository) *SearchService {
+
+	// compile-time check to ensure we implement the interface
+	var _ ISearchService = (*SearchService)(nil)
+
+	return &SearchService{
+		posts:     posts,
+		users:     users,
+		tags:      tags,
+		postIndex: search.NewIndex(),
+		userIndex: search.NewIndex(),
+	}
+}
+
+func (s *SearchService) Search(viewer_id string, query models.SearchQueryDTO) (page models.SearchPageDTO, err error) {
+	if query.Type == "" {
+		query.Type = models.SearchTypePosts
+	}
+
+	if strings.TrimSpace(query.Query) == "" {
+		return models.SearchPageDTO{}, errors.New("invalid query")
+	}
+
+	if query.Cursor != "" {
+		if _, err := pagination.Decode(query.Cursor); err != nil {
+			return models.SearchPageDTO{}, err
+		}
+	}
+
+	switch query.Type {
+	case models.SearchTypePosts:
+		return s.searchPosts(viewer_id, query)
+	case models.SearchTypeUsers:
+		return s.searchUsers(viewer_id, query)
+	case models.SearchTypeTags:
+		return s.searchTags(query)
+	}
+
+	return models.SearchPageDTO{}, errors.New("invalid search type")
+}
+
+func (s *SearchService) searchPosts(viewer_id string, query models.SearchQueryDTO) (models.SearchPageDTO, error) {
+	postIndex, _ := s.indexes()
+
+	// A post matches through its caption or any of its comments; keep the
+	// best match of each post
+	best := make(map[string]float64)
+	for _, hit := range postIndex.Search(query.Query) {
+		post_id, _, _ := strings.Cut(hit.Id, "/")
+		best[post_id] = math.Max(best[post_id], hit.Score)
+	}
+
+	hits := make([]search.Hit, 0, len(best))
+	for post_id, score := range best {
+		hits = append(hits, search.Hit{Id: post_id, Score: score})
+	}
+
+	hidden, err := hiddenUsers(s.posts.repo, viewer_id)
+
+	if err != nil {
+		return models.SearchPageDTO{}, errors.New("error searching")
+	}
+
+	hiddenAuthors := make(map[string]bool, len(hidden))
+	for _, hidden_id := range hidden {
+		hiddenAuthors[hidden_id] = true
+	}
+
+	posts := []models.PostResponseDTO{}
+
+	next, err := eachResult(hits, query, func(post_id string) bool {
+		post_meta, err := s.posts.getViewablePost(post_id, viewer_id)
+
+		if err != nil || hiddenAuthors[post_meta.Creator] {
+			return false
+		}
+
+		comments, _ := s.posts.repo.GetPostLatestComments(post_meta.Id, s.posts.config.PreviewComments, hidden)
+
+		posts = append(posts, postPreview(post_meta, comments))
+		return true
+	})
+
+	if err != nil {
+		return models.SearchPageDTO{}, err
+	}
+
+	return models.SearchPageDTO{Type: query.Type, Posts: posts, NextCursor: next}, nil
+}
+
+func (s *SearchService) searchUsers(viewer_id string, query models.SearchQueryDTO) (models.SearchPageDTO, error) {
+	_, userIndex := s.indexes()
+
+	user_ids := []string{}
+
+	next, err := eachResult(userIndex.Search(query.Query), query, func(user_id string) bool {
+		if viewer_id != "" && viewer_id != user_id {
+			if blocked, err := s.posts.eitherBlocks(viewer_id, user_id); err != nil || blocked {
+				return false
+			}
+		}
+
+		user_ids = append(user_ids, user_id)
+		return true
+	})
+
+	if err != nil {
+		return models.SearchPageDTO{}, err
+	}
+
+	return models.SearchPageDTO{Type: query.Type, UserIds: user_ids, NextCursor: next}, nil
+}
+
+// searchTags lists the hashtags starting with the query, the most used first
+func (s *SearchService) searchTags(query models.SearchQueryDTO) (models.SearchPageDTO, error) {
+	prefix := strings.TrimSpace(query.Query)
+	prefix = strings.TrimPrefix(strings.TrimPrefix(prefix, "#"), "＃")
+	prefix = text.NormalizeHashtag(prefix)
+
+	tags, err := s.tags.GetTagsByPrefix(prefix)
+
+	if err != nil {
+		return models.SearchPageDTO{}, errors.New("error searching")
+	}
+
+	key := func(tag models.TagCountDTO) pagination.Cursor {
+		return pagination.Cursor{Rank: tag.PostCount, Id: tag.Tag}
+	}
+
+	pagination.Sort(tags, true, key)
+
+	page, next, err := pagination.Paginate(tags, query.Cursor, query.Limit, true, key)
+
+	if err != nil {
+		return models.SearchPageDTO{}, err
+	}
+
+	return models.SearchPageDTO{Type: query.Type, Tags: page, NextCursor: next}, nil
+}
+
+// eachResult walks the hits of a search, best first, from the cursor of the
+// query. Hits keep being read until keep accepted a page of them, as the
+// viewer may not be allowed to see them all. Returns the cursor of the next
+// page.
+func eachResult(hits []search.Hit, query models.SearchQueryDTO, keep func(id string) bool) (string, error) {
+	// Scores are ranked with a fixed precision so they fit in a cursor. Hits
+	// have no time of their own; the epoch is one a cursor can carry.
+	key := func(hit search.Hit) pagination.Cursor {
+		return pagination.Cursor{Rank: int(math.Round(hit.Score * 1e6)), Time: time.Unix(0, 0), Id: hit.Id}
+	}
+
+	pagination.Sort(hits, true, key)
+
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+	kept := 0
+
+	for kept < limit {
+		page, next, err := pagination.Paginate(hits, cursor, limit-kept, true, key)
+
+		if err != nil {
+			return "", err
+		}
+
+		for _, hit := range page {
+			if keep(hit.Id) {
+				kept++
+			}
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return cursor, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Indexing
+------------------------------------------------------------------------*/
+
+func (s *SearchService) HandleEvent(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventPostCreated, EventPostEdited:
+		err = s.indexCaption(event.PostId)
+	case EventPostRestored:
+		err = s.indexPost(event.PostId)
+	case EventPostDeleted:
+		err = s.removePost(event.PostId)
+	case EventCommentCreated, EventCommentEdited, EventCommentRestored, EventCommentUnhidden:
+		err = s.indexComment(event.CommentId)
+	case EventCommentDeleted, EventCommentHidden:
+		postIndex, _ := s.indexes()
+		postIndex.Remove(commentDocId(event.PostId, event.CommentId))
+	case EventUserRegistered, EventProfileUpdated:
+		err = s.indexUser(event.UserId)
+	}
+
+	if err != nil {
+		log.Printf("updating search index for %s event: %v", event.Type, err)
+	}
+}
+
+// Rebuild indexes every live post with its shown comments, and every user,
+// again, then swaps the new indexes in. Changes made while it runs may be
+// missed.
+func (s *SearchService) Rebuild() (err error) {
+	postIndex := search.NewIndex()
+	userIndex := search.NewIndex()
+
+	post_metas, err := s.posts.repo.GetAllPostMetas()
+
+	if err != nil {
+		return err
+	}
+
+	for _, post_meta := range post_metas {
+		if err := addPost(s.posts.repo, postIndex, post_meta); err != nil {
+			return err
+		}
+	}
+
+	users, err := s.users.GetAllUsers()
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		userIndex.Add(user.Id, userText(user))
+	}
+
+	s.mu.Lock()
+	s.postIndex = postIndex
+	s.userIndex = userIndex
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *SearchService) indexes() (*search.Index, *search.Index) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.postIndex, s.userIndex
+}
+
+func (s *SearchService) indexCaption(post_id string) error {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	postIndex, _ := s.indexes()
+	postIndex.Add(post_id, post_meta.Caption)
+	return nil
+}
+
+// indexPost indexes the caption of a post and its shown comments
+func (s *SearchService) indexPost(post_id string) error {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	postIndex, _ := s.indexes()
+	return addPost(s.posts.repo, postIndex, post_meta)
+}
+
+// removePost takes the caption and comments of a post out of the index
+func (s *SearchService) removePost(post_id string) error {
+	comments, err := s.posts.repo.GetPostComments(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	postIndex, _ := s.indexes()
+	postIndex.Remove(post_id)
+	for _, comment := range comments {
+		postIndex.Remove(commentDocId(post_id, comment.Id))
+	}
+	return nil
+}
+
+func (s *SearchService) indexComment(comment_id string) error {
+	comment, err := s.posts.repo.GetCommentByID(comment_id)
+
+	if err != nil {
+		return err
+	}
+
+	postIndex, _ := s.indexes()
+	if !isShown(comment) {
+		postIndex.Remove(commentDocId(comment.PostId, comment.Id))
+		return nil
+	}
+
+	postIndex.Add(commentDocId(comment.PostId, comment.Id), comment.Content)
+	return nil
+}
+
+func (s *SearchService) indexUser(user_id string) error {
+	user, err := s.users.GetUserByID(user_id)
+
+	if err != nil {
+		return err
+	}
+
+	_, userIndex := s.indexes()
+	userIndex.Add(user.Id, userText(user))
+	return nil
+}
+
+// addPost adds the caption of a post and its shown comments to an index
+func addPost(repo repository.IRepository, postIndex *search.Index, post_meta models.PostMetaDTO) error {
+	comments, err := repo.GetPostComments(post_meta.Id)
+
+	if err != nil {
+		return err
+	}
+
+	postIndex.Add(post_meta.Id, post_meta.Caption)
+	for _, comment := range comments {
+		if isShown(comment) {
+			postIndex.Add(commentDocId(post_meta.Id, comment.Id), comment.Content)
+		}
+	}
+	return nil
+}
+
+// isShown tells whether a comment is neither in the trash nor hidden by the
+// author of its post
+func isShown(comment models.CommentDTO) bool {
+	return comment.DeletedAt == nil && comment.HiddenAt == nil
+}
+
+// commentDocId identifies a comment in the post index, so that matching
+// comments lead back to their post
+func commentDocId(post_id, comment_id string) string {
+	return post_id + "/" + comment_id
+}
+
+// userText is what users are found by
+func userText(user models.User) string {
+	return user.Username + " " + user.DisplayName
+}
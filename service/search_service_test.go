@@ -0,0 +1,171 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSearchTest wires a SearchService, along with the hashtag index it
+// completes tags from, to a Service and a UserService
+func newSearchTest() (*Service, *UserService, *SearchService) {
+	svc, _ := newTestService(time.Minute)
+
+	users := repository.NewInMemoryUserRepo()
+	svc.users = users
+
+	cfg := config.Default()
+	cfg.BcryptCost = 4
+	userSvc := NewUserService(users, svc.repo, cfg)
+
+	tagRepo := repository.NewInMemoryTagRepo()
+	tags := NewTagService(svc, tagRepo)
+	svc.Subscribe(tags.HandleEvent)
+
+	searchSvc := NewSearchService(svc, users, tagRepo)
+	svc.Subscribe(searchSvc.HandleEvent)
+	userSvc.Subscribe(searchSvc.HandleEvent)
+
+	return svc, userSvc, searchSvc
+}
+
+func searchCaptions(t *testing.T, searchSvc *SearchService, viewer_id, q string) []string {
+	page, err := searchSvc.Search(viewer_id, models.SearchQueryDTO{Query: q})
+	assert.NoError(t, err)
+	return captions(page.Posts)
+}
+
+func TestSearch_Posts(t *testing.T) {
+	svc, _, searchSvc := newSearchTest()
+
+	running := createTestPost(t, svc, "alice", "Running along the beach")
+	createTestPost(t, svc, "bob", "Runners at sunrise, running and running")
+	quiet := createTestPost(t, svc, "carol", "A quiet morning")
+
+	assert.Equal(t, []string{"Runners at sunrise, running and running", "Running along the beach"}, searchCaptions(t, searchSvc, "", "runs "))
+	assert.Equal(t, []string{"Running along the beach"}, searchCaptions(t, searchSvc, "", "run bea"))
+
+	// Comments make their post findable until they are deleted
+	comment_id, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: quiet, AuthorId: "dave", Comment: "Lovely mountains"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A quiet morning"}, searchCaptions(t, searchSvc, "", "mountain"))
+
+	assert.NoError(t, svc.DeleteComment(comment_id, "dave"))
+	assert.Empty(t, searchCaptions(t, searchSvc, "", "mountain"))
+
+	// and while the author of the post hides them
+	comment_id, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: quiet, AuthorId: "dave", Comment: "Lovely lakes"})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.HideComment(comment_id, "carol"))
+	assert.Empty(t, searchCaptions(t, searchSvc, "", "lake"))
+
+	assert.NoError(t, svc.UnhideComment(comment_id, "carol"))
+	assert.Equal(t, []string{"A quiet morning"}, searchCaptions(t, searchSvc, "", "lake"))
+
+	// Edited captions and trashed posts
+	assert.NoError(t, svc.EditPost(running, "alice", "Walking along the beach"))
+	assert.Equal(t, []string{"Walking along the beach"}, searchCaptions(t, searchSvc, "", "walk"))
+
+	assert.NoError(t, svc.DeletePost(running, "alice"))
+	assert.Empty(t, searchCaptions(t, searchSvc, "", "walk"))
+
+	assert.NoError(t, svc.RestorePost(running, "alice"))
+	assert.Equal(t, []string{"Walking along the beach"}, searchCaptions(t, searchSvc, "", "walk"))
+
+	// Posts the viewer cannot see are left out
+	assert.NoError(t, svc.UpdatePostSettings(running, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	assert.Empty(t, searchCaptions(t, searchSvc, "bob", "walk"))
+	assert.Equal(t, []string{"Walking along the beach"}, searchCaptions(t, searchSvc, "alice", "walk"))
+}
+
+func TestSearch_PostPages(t *testing.T) {
+	svc, _, searchSvc := newSearchTest()
+
+	for _, caption := range []string{"cat", "cat cat", "cat cat cat"} {
+		createTestPost(t, svc, "alice", caption)
+	}
+
+	page, err := searchSvc.Search("", models.SearchQueryDTO{Query: "cat", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Posts, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	last, err := searchSvc.Search("", models.SearchQueryDTO{Query: "cat", Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, last.Posts, 1)
+	assert.Empty(t, last.NextCursor)
+}
+
+func TestSearch_UsersAndTags(t *testing.T) {
+	svc, userSvc, searchSvc := newSearchTest()
+
+	carol, err := userSvc.Register(models.UserRequestDTO{Username: "carol.smith", Password: "password123"})
+	assert.NoError(t, err)
+	bob, err := userSvc.Register(models.UserRequestDTO{Username: "bob", Password: "password123"})
+	assert.NoError(t, err)
+
+	name := "Robert Paulson"
+	_, err = userSvc.UpdateProfile(bob.Id, models.ProfileRequestDTO{DisplayName: &name})
+	assert.NoError(t, err)
+
+	page, err := searchSvc.Search("", models.SearchQueryDTO{Query: "carol", Type: models.SearchTypeUsers})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{carol.Id}, page.UserIds)
+
+	page, _ = searchSvc.Search("", models.SearchQueryDTO{Query: "rob", Type: models.SearchTypeUsers})
+	assert.Equal(t, []string{bob.Id}, page.UserIds)
+
+	// Blocked users can't find each other
+	assert.NoError(t, svc.BlockUser(carol.Id, bob.Id))
+	page, _ = searchSvc.Search(bob.Id, models.SearchQueryDTO{Query: "carol", Type: models.SearchTypeUsers})
+	assert.Empty(t, page.UserIds)
+
+	createTestPost(t, svc, "alice", "#sunset #sun")
+	createTestPost(t, svc, "alice", "#sunset #beach")
+
+	page, err = searchSvc.Search("", models.SearchQueryDTO{Query: "#Sun", Type: models.SearchTypeTags})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCountDTO{{Tag: "sunset", PostCount: 2}, {Tag: "sun", PostCount: 1}}, page.Tags)
+}
+
+func TestSearch_Rebuild(t *testing.T) {
+	svc, _, _ := newSearchTest()
+
+	post_id := createTestPost(t, svc, "alice", "Hiking in the alps")
+	_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, AuthorId: "bob", Comment: "great views"})
+	assert.NoError(t, err)
+	hidden, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, AuthorId: "carol", Comment: "buy followers"})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.HideComment(hidden, "alice"))
+
+	_, err = svc.users.SaveUser(models.User{Username: "dave"})
+	assert.NoError(t, err)
+
+	// A new index over the same repositories starts out empty
+	rebuilt := NewSearchService(svc, svc.users, repository.NewInMemoryTagRepo())
+	assert.Empty(t, searchCaptions(t, rebuilt, "", "hike"))
+
+	assert.NoError(t, rebuilt.Rebuild())
+
+	assert.Equal(t, []string{"Hiking in the alps"}, searchCaptions(t, rebuilt, "", "views"))
+	assert.Empty(t, searchCaptions(t, rebuilt, "", "followers"))
+	page, _ := rebuilt.Search("", models.SearchQueryDTO{Query: "dave", Type: models.SearchTypeUsers})
+	assert.Len(t, page.UserIds, 1)
+}
+
+func TestSearch_InvalidQueries(t *testing.T) {
+	_, _, searchSvc := newSearchTest()
+
+	_, err := searchSvc.Search("", models.SearchQueryDTO{Query: "  "})
+	assert.EqualError(t, err, "invalid query")
+
+	_, err = searchSvc.Search("", models.SearchQueryDTO{Query: "cat", Type: "photos"})
+	assert.EqualError(t, err, "invalid search type")
+
+	_, err = searchSvc.Search("", models.SearchQueryDTO{Query: "cat", Cursor: "garbage"})
+	assert.EqualError(t, err, "invalid cursor")
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"image"
+	"time"
 
 	"github.com/anandh86/instagram/models"
 )
@@ -13,11 +14,125 @@ type IService interface {
 	// Create a new post by uploading an image
 	CreatePost(post_img image.Image, post_info models.PostRequestDTO) (post_id string, err error)
 
-	// Get the image specific to a post by its id
-	GetPostById(post_id string) (post_img image.Image, post_info models.PostResponseDTO, err error)
+	// Get the image specific to a post by its id, if the viewer can see it
+	GetPostById(post_id, viewer_id string) (post_img image.Image, post_info models.PostResponseDTO, err error)
 
-	// Get all the posts
-	GetAllPosts() (posts []models.PostMetaDTO, err error)
+	// Get all the posts the viewer can see
+	GetAllPosts(viewer_id string) (posts []models.PostMetaDTO, err error)
+
+	// Change the caption of a post; Only author's can edit it
+	EditPost(post_id, author_id, caption string) (err error)
+
+	// Move a post to the trash; Only author's would be able to delete
+	DeletePost(post_id, author_id string) (err error)
+
+	// Change the settings of a post, such as who can see or comment on it; Only author's can change them
+	UpdatePostSettings(post_id, author_id string, settings models.PostSettingsDTO) (err error)
+
+	// Bring a post back from the trash within the restore window
+	RestorePost(post_id, author_id string) (err error)
+
+	/*------------------------------------------------------------------------
+	*                             Like
+	------------------------------------------------------------------------*/
+	// Like a post the user can see; liking it again has no effect
+	LikePost(post_id, user_id string) (summary models.LikeSummaryDTO, err error)
+
+	// Take back the like of a user on a post, if any
+	UnlikePost(post_id, user_id string) (summary models.LikeSummaryDTO, err error)
+
+	// Get a page of the users who like a post, most recent first
+	ListPostLikes(post_id, viewer_id string, query models.LikeQueryDTO) (page models.LikePageDTO, err error)
+
+	// Get the like count of each post and whether the viewer likes it
+	GetLikeSummaries(post_ids []string, viewer_id string) (summaries map[string]models.LikeSummaryDTO, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Saved posts
+	------------------------------------------------------------------------*/
+	// Bookmark a post the user can see, into one of their collections if given
+	SavePost(post_id, user_id, collection_id string) (err error)
+
+	// Remove a bookmark, along with the post from every collection of the user
+	UnsavePost(post_id, user_id string) (err error)
+
+	// Get a page of the posts a user saved, most recently saved first
+	ListSavedPosts(user_id string, query models.SavedPostQueryDTO) (page models.SavedPostPageDTO, err error)
+
+	// Create a named private collection of saved posts
+	CreateCollection(user_id, name string) (collection models.CollectionDTO, err error)
+
+	// Get the collections of a user, in the order they arranged them
+	ListCollections(user_id string) (collections []models.CollectionDTO, err error)
+
+	// Rename a collection; Only its owner can change it
+	RenameCollection(collection_id, user_id, name string) (collection models.CollectionDTO, err error)
+
+	// Arrange the collections of a user; every collection must be listed once
+	ReorderCollections(user_id string, collection_ids []string) (err error)
+
+	// Delete a collection; its posts stay saved
+	DeleteCollection(collection_id, user_id string) (err error)
+
+	// Add a post the user can see to the end of one of their collections, saving it
+	AddToCollection(collection_id, user_id, post_id string) (err error)
+
+	// Take a post out of a collection; it stays saved
+	RemoveFromCollection(collection_id, user_id, post_id string) (err error)
+
+	// Arrange the posts of a collection; every post must be listed once
+	ReorderCollectionPosts(collection_id, user_id string, post_ids []string) (err error)
+
+	// Get a page of the posts of a collection, in the order of the collection
+	ListCollectionPosts(collection_id, user_id string, query models.SavedPostQueryDTO) (page models.SavedPostPageDTO, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Drafts and scheduled posts
+	------------------------------------------------------------------------*/
+	// Publish a draft or scheduled post right away; Only the post's author would be able to publish
+	PublishPost(post_id, author_id string) (err error)
+
+	// Schedule a draft or scheduled post for a future time, or make it a draft again when nil
+	SchedulePost(post_id, author_id string, publish_at *time.Time) (err error)
+
+	// Get the drafts and scheduled posts of an author, newest first
+	ListDrafts(author_id string) (posts []models.PostResponseDTO, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Stories
+	------------------------------------------------------------------------*/
+	// Share an image with followers until it expires
+	CreateStory(story_img image.Image, author_id string) (story_id string, err error)
+
+	// Get a live story the viewer can see, recording that they viewed it
+	ViewStory(story_id, viewer_id string) (story_img image.Image, story models.StoryDTO, err error)
+
+	// Get a page of the viewers of a story, most recent first; Only the story's author can see them
+	ListStoryViewers(story_id, author_id string, query models.StoryViewQueryDTO) (page models.StoryViewPageDTO, err error)
+
+	// Delete a story before it expires; Only the story's author would be able to delete
+	DeleteStory(story_id, author_id string) (err error)
+
+	// Get the live stories of the viewer and of the accounts they follow, by author
+	GetStoriesTray(viewer_id string) (tray models.StoryTrayDTO, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Direct messages
+	------------------------------------------------------------------------*/
+	// Start a conversation with one or a few other users; Two users only ever have one direct conversation
+	CreateConversation(creator_id string, member_ids []string) (conversation models.ConversationResponseDTO, err error)
+
+	// Get a page of the conversations of a user, most recently active first
+	ListConversations(user_id string, query models.ConversationQueryDTO) (page models.ConversationPageDTO, err error)
+
+	// Send some text or a post to a conversation; Only its members would be able to send
+	SendMessage(conversation_id, sender_id string, message models.MessageRequestDTO) (sent models.MessageResponseDTO, err error)
+
+	// Get a page of the messages of a conversation, newest first, with how far the other members have read it
+	ListMessages(conversation_id, user_id string, query models.MessageQueryDTO) (page models.MessagePageDTO, err error)
+
+	// Mark a conversation read up to a message, or entirely when message_id is empty
+	MarkConversationRead(conversation_id, user_id, message_id string) (err error)
 
 	/*------------------------------------------------------------------------
 	*                             Comment
@@ -26,9 +141,88 @@ type IService interface {
 	// Comment on a specific post
 	CommentOnPost(comment models.CommentRequestDTO) (comment_id string, err error)
 
-	// Delete a comment; Only author's would be able to delete
+	// Delete a comment; Only the comment's or the post's author would be able to delete
 	DeleteComment(comment_id, author_id string) (err error)
 
-	// Get the comments corresponding to a specific post
-	GetPostComments(post_id string) (comments []models.CommentDTO, err error)
+	// Hide a comment from a post; Only the post's author would be able to hide
+	HideComment(comment_id, post_author_id string) (err error)
+
+	// Show a hidden comment again; Only the post's author would be able to unhide
+	UnhideComment(comment_id, post_author_id string) (err error)
+
+	// Edit a comment within the edit window; Only author's would be able to edit
+	EditComment(comment_id, author_id, content string) (comment models.CommentDTO, err error)
+
+	// React to a comment with one of the comment reactions, replacing any previous reaction
+	ReactToComment(comment_id, user_id, reaction string) (reactions models.CommentReactionsDTO, err error)
+
+	// Take back the reaction of a user on a comment, if any
+	RemoveCommentReaction(comment_id, user_id string) (reactions models.CommentReactionsDTO, err error)
+
+	// Get the reactions on each comment and the viewer's own
+	GetCommentReactions(comment_ids []string, viewer_id string) (reactions map[string]models.CommentReactionsDTO, err error)
+
+	// Get the previous versions of an edited comment; Only moderators can audit them
+	GetCommentRevisions(comment_id, requester_id string) (revisions []models.CommentRevisionDTO, err error)
+
+	// Bring a comment back from the trash within the restore window
+	RestoreComment(comment_id, author_id string) (err error)
+
+	// Get a page of the comments of a post as nested reply threads, if the viewer can see the post
+	ListPostComments(post_id, viewer_id string, query models.CommentQueryDTO) (page models.CommentPageDTO, err error)
+
+	/*------------------------------------------------------------------------
+	*                             Social graph
+	------------------------------------------------------------------------*/
+
+	// Follow another user; following a private account only requests it
+	FollowUser(follower_id, followee_id string) (status string, err error)
+
+	// Stop following a user, or withdraw a follow request
+	UnfollowUser(follower_id, followee_id string) (err error)
+
+	// Get a page of the pending requests to follow a private account, most recent first
+	ListFollowRequests(user_id string, query models.FollowQueryDTO) (page models.FollowRequestPageDTO, err error)
+
+	// Let a user follow the private account they asked to follow
+	ApproveFollowRequest(user_id, requester_id string) (err error)
+
+	// Turn down a request to follow a private account
+	DenyFollowRequest(user_id, requester_id string) (err error)
+
+	// Block a user, which also unfollows them both ways; blocked users cannot
+	// see, comment on or follow the blocker's posts and account
+	BlockUser(blocker_id, blocked_id string) (err error)
+
+	// Lift the block on a user
+	UnblockUser(blocker_id, blocked_id string) (err error)
+
+	// Get the users a user blocks, most recent first
+	ListBlockedUsers(user_id string) (user_ids []string, err error)
+
+	// Mute a user, silently hiding their posts and comments from the muter
+	MuteUser(muter_id, muted_id string) (err error)
+
+	// Stop muting a user
+	UnmuteUser(muter_id, muted_id string) (err error)
+
+	// Get the users a user mutes, most recent first
+	ListMutedUsers(user_id string) (user_ids []string, err error)
+
+	// Add a user to the close friends who can see posts shared with them
+	AddCloseFriend(user_id, friend_id string) (err error)
+
+	// Take a user off the close friends list
+	RemoveCloseFriend(user_id, friend_id string) (err error)
+
+	// Get the close friends of a user, most recently added first
+	ListCloseFriends(user_id string) (user_ids []string, err error)
+
+	// Get a page of the followers of a user, most recent first, if the
+	// viewer can see the posts of the user
+	ListFollowers(user_id, viewer_id string, query models.FollowQueryDTO) (page models.FollowPageDTO, err error)
+
+	// Get a page of the users a user follows, most recent first, if the
+	// viewer can see the posts of the user
+	ListFollowing(user_id, viewer_id string, query models.FollowQueryDTO) (page models.FollowPageDTO, err error)
 }
@@ -1,25 +1,121 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"image"
+	"log"
+	"slices"
+	"time"
 
+	"github.com/anandh86/instagram/config"
 	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
 	"github.com/anandh86/instagram/repository"
+	"github.com/anandh86/instagram/text"
 )
 
 type Service struct {
-	repo repository.IRepository
+	repo        repository.IRepository
+	users       repository.IUserRepository
+	likes       repository.ILikeRepository
+	collections repository.ICollectionRepository
+	stories     repository.IStoryRepository
+	places      repository.IPlaceRepository
+	messages    repository.IMessageRepository
+	config      config.Config
+	now         func() time.Time
+
+	eventBus
+}
+
+// Option customizes a Service created by NewService
+type Option func(*Service)
+
+// WithConfig overrides the default configuration
+func WithConfig(cfg config.Config) Option {
+	return func(s *Service) {
+		s.config = cfg
+	}
+}
+
+// WithClock makes the Service tell the time with now instead of the system
+// clock
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// WithUserRepository gives access to the accounts of post authors, which
+// decide who can see their posts. Without it every account is public.
+func WithUserRepository(users repository.IUserRepository) Option {
+	return func(s *Service) {
+		s.users = users
+	}
+}
+
+// WithLikeRepository stores likes on posts in the given repository instead
+// of in memory
+func WithLikeRepository(likes repository.ILikeRepository) Option {
+	return func(s *Service) {
+		s.likes = likes
+	}
+}
+
+// WithCollectionRepository stores saved posts and collections in the given
+// repository instead of in memory
+func WithCollectionRepository(collections repository.ICollectionRepository) Option {
+	return func(s *Service) {
+		s.collections = collections
+	}
+}
+
+// WithStoryRepository stores stories and their views in the given
+// repository instead of in memory
+func WithStoryRepository(stories repository.IStoryRepository) Option {
+	return func(s *Service) {
+		s.stories = stories
+	}
+}
+
+// WithPlaceRepository stores places and the posts tagged with them in the
+// given repository instead of in memory
+func WithPlaceRepository(places repository.IPlaceRepository) Option {
+	return func(s *Service) {
+		s.places = places
+	}
+}
+
+// WithMessageRepository stores conversations and their messages in the
+// given repository instead of in memory
+func WithMessageRepository(messages repository.IMessageRepository) Option {
+	return func(s *Service) {
+		s.messages = messages
+	}
 }
 
-func NewService(repository repository.IRepository) *Service {
+func NewService(repo repository.IRepository, opts ...Option) *Service {
 
 	// compile-time check to ensure we implement the interface
 	var _ IService = (*Service)(nil)
 
-	return &Service{
-		repo: repository,
+	s := &Service{
+		repo:        repo,
+		likes:       repository.NewInMemoryLikeRepo(),
+		collections: repository.NewInMemoryCollectionRepo(),
+		stories:     repository.NewInMemoryStoryRepo(),
+		places:      repository.NewInMemoryPlaceRepo(),
+		messages:    repository.NewInMemoryMessageRepo(),
+		config:      config.Default(),
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 /*------------------------------------------------------------------------
@@ -28,6 +124,36 @@ func NewService(repository repository.IRepository) *Service {
 
 func (s *Service) CreatePost(post_img image.Image, post_info models.PostRequestDTO) (post_id string, err error) {
 	// Implement the logic to create a new post
+	visibility := post_info.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
+	if !isVisibility(visibility) {
+		return "", errors.New("invalid visibility")
+	}
+
+	now := s.now()
+
+	status := models.PostStatusPublished
+	switch {
+	case post_info.Draft && post_info.PublishAt != nil:
+		return "", errors.New("invalid publish time")
+	case post_info.Draft:
+		status = models.PostStatusDraft
+	case post_info.PublishAt != nil:
+		if !post_info.PublishAt.After(now) {
+			return "", errors.New("invalid publish time")
+		}
+		status = models.PostStatusScheduled
+	}
+
+	location, err := s.resolvePlace(post_info.Location)
+
+	if err != nil {
+		return "", err
+	}
+
 	img_id, img_err := s.repo.SaveImage(post_img)
 
 	if img_err != nil {
@@ -35,20 +161,48 @@ func (s *Service) CreatePost(post_img image.Image, post_info models.PostRequestD
 	}
 
 	post_meta := models.PostMetaDTO{
-		Caption: post_info.Caption,
-		ImageId: img_id,
-		Creator: post_info.AuthorId,
+		Caption:       post_info.Caption,
+		CreatedAt:     now,
+		ImageId:       img_id,
+		Creator:       post_info.AuthorId,
+		CommentPolicy: models.CommentPolicyEveryone,
+		Visibility:    visibility,
+		Mentions:      s.resolveMentions(post_info.AuthorId, post_info.Caption),
+		Location:      location,
+		Status:        status,
+		PublishAt:     post_info.PublishAt,
+		Unannounced:   status == models.PostStatusPublished,
+	}
+
+	post_id, err = s.repo.SavePostMeta(post_meta)
+
+	if err != nil {
+		return "", err
+	}
+
+	// Drafts and scheduled posts are announced when they are published
+	if post_meta.Unannounced {
+		post_meta.Id = post_id
+		s.announcePost(post_meta)
 	}
 
-	return s.repo.SavePostMeta(post_meta)
+	return post_id, nil
 }
 
-func (s *Service) GetPostById(post_id string) (post_img image.Image, post_info models.PostResponseDTO, err error) {
+func (s *Service) GetPostById(post_id, viewer_id string) (post_img image.Image, post_info models.PostResponseDTO, err error) {
 	// Implement the logic to retrieve a post by ID
-	post_meta, post_err := s.repo.GetPostMetaByID(post_id)
+	post_meta, post_err := s.getViewablePost(post_id, viewer_id)
+
+	if post_err != nil {
+		// Authors can look at their drafts and scheduled posts, which don't
+		// exist as far as anyone else can tell
+		if draft, err := s.getOwnedDraft(post_id, viewer_id); err == nil {
+			post_meta, post_err = draft, nil
+		}
+	}
 
 	if post_err != nil {
-		return nil, models.PostResponseDTO{}, errors.New("error retrieving post")
+		return nil, models.PostResponseDTO{}, post_err
 	}
 
 	post_img, err = s.repo.GetImageByID(post_meta.ImageId)
@@ -58,15 +212,21 @@ func (s *Service) GetPostById(post_id string) (post_img image.Image, post_info m
 	}
 
 	post_info = models.PostResponseDTO{
-		Id:       post_meta.Id,
-		Caption:  post_meta.Caption,
-		AuthorId: post_meta.Creator,
+		Id:            post_meta.Id,
+		Caption:       post_meta.Caption,
+		CreatedAt:     post_meta.CreatedAt,
+		AuthorId:      post_meta.Creator,
+		ImageId:       post_meta.ImageId,
+		CommentPolicy: post_meta.CommentPolicy,
+		Visibility:    post_meta.Visibility,
+		Mentions:      post_meta.Mentions,
+		Location:      post_meta.Location,
 	}
 
 	return post_img, post_info, nil
 }
 
-func (s *Service) GetAllPosts() (posts []models.PostMetaDTO, err error) {
+func (s *Service) GetAllPosts(viewer_id string) (posts []models.PostMetaDTO, err error) {
 	// Implement the logic to retrieve all posts
 	postMetaDatas, err := s.repo.GetAllPostMetas()
 	var RetPostMetaDatas []models.PostMetaDTO
@@ -75,24 +235,48 @@ func (s *Service) GetAllPosts() (posts []models.PostMetaDTO, err error) {
 		return nil, errors.New("error retrieving posts")
 	}
 
-	for _, post_meta := range postMetaDatas {
+	hidden, err := hiddenUsers(s.repo, viewer_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving posts")
+	}
 
-		comments, _ := s.GetPostComments(post_meta.Id)
+	// Authors usually have several posts; look each one up once
+	visible := make(map[string]bool)
+	for _, user_id := range hidden {
+		visible[user_id] = false
+	}
+
+	for _, post_meta := range postMetaDatas {
+		canView, checked := visible[post_meta.Creator]
 
-		var respComments []models.CommentResponseDTO
+		if !checked {
+			canView, err = s.canViewPosts(viewer_id, post_meta.Creator)
 
-		for _, c := range comments {
-			resComment := models.CommentResponseDTO{
-				Id:        c.Id,
-				Comment:   c.Content,
-				AuthorId:  c.Creator,
-				CreatedAt: c.CreatedAt,
+			if err != nil {
+				return nil, errors.New("error retrieving posts")
 			}
 
-			respComments = append(respComments, resComment)
+			visible[post_meta.Creator] = canView
+		}
+
+		if !canView {
+			continue
+		}
+
+		canView, err = postVisibleTo(s.repo, viewer_id, post_meta)
+
+		if err != nil {
+			return nil, errors.New("error retrieving posts")
 		}
 
-		post_meta.Comments = respComments
+		if !canView {
+			continue
+		}
+
+		comments, _ := s.repo.GetPostLatestComments(post_meta.Id, s.config.PreviewComments, hidden)
+
+		post_meta.Comments = commentPreviews(comments)
 
 		RetPostMetaDatas = append(RetPostMetaDatas, post_meta)
 	}
@@ -100,6 +284,241 @@ func (s *Service) GetAllPosts() (posts []models.PostMetaDTO, err error) {
 	return RetPostMetaDatas, nil
 }
 
+// postPreview converts a post for a post listing, with its latest comments
+func postPreview(post_meta models.PostMetaDTO, comments []models.CommentDTO) models.PostResponseDTO {
+	return models.PostResponseDTO{
+		Id:            post_meta.Id,
+		Caption:       post_meta.Caption,
+		CreatedAt:     post_meta.CreatedAt,
+		AuthorId:      post_meta.Creator,
+		ImageId:       post_meta.ImageId,
+		CommentPolicy: post_meta.CommentPolicy,
+		Visibility:    post_meta.Visibility,
+		Mentions:      post_meta.Mentions,
+		Location:      post_meta.Location,
+		Comments:      commentPreviews(comments),
+	}
+}
+
+// commentPreviews converts the latest comments of a post for embedding in it
+func commentPreviews(comments []models.CommentDTO) []models.CommentResponseDTO {
+	var respComments []models.CommentResponseDTO
+
+	for _, c := range comments {
+		resComment := models.CommentResponseDTO{
+			Id:        c.Id,
+			ParentId:  c.ParentId,
+			Comment:   c.Content,
+			Mentions:  c.Mentions,
+			AuthorId:  c.Creator,
+			CreatedAt: c.CreatedAt,
+			EditedAt:  c.EditedAt,
+		}
+
+		respComments = append(respComments, resComment)
+	}
+
+	return respComments
+}
+
+func (s *Service) DeletePost(post_id, author_id string) (err error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil || post_meta.DeletedAt != nil {
+		return errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != author_id {
+		return errors.New("unauthorized")
+	}
+
+	deleted_at := s.now()
+
+	if err := s.repo.DeletePostByID(post_id, deleted_at); err != nil {
+		return err
+	}
+
+	if post_meta.Published() {
+		s.publish(Event{Type: EventPostDeleted, UserId: author_id, PostId: post_id, At: deleted_at})
+	}
+
+	return nil
+}
+
+func (s *Service) EditPost(post_id, author_id, caption string) (err error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil || post_meta.DeletedAt != nil {
+		return errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != author_id {
+		return errors.New("unauthorized")
+	}
+
+	mentions := s.resolveMentions(author_id, caption)
+
+	// The post may have been trashed since it was read
+	if err := s.repo.SetPostCaption(post_id, caption, mentions); err != nil {
+		return errors.New("error retrieving post")
+	}
+
+	if post_meta.Published() {
+		s.publish(Event{Type: EventPostEdited, UserId: author_id, PostId: post_id, At: s.now()})
+		s.notifyMentions(author_id, post_id, "", mentions, post_meta.Mentions)
+	}
+
+	return nil
+}
+
+func (s *Service) UpdatePostSettings(post_id, author_id string, settings models.PostSettingsDTO) (err error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil || post_meta.DeletedAt != nil {
+		return errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != author_id {
+		return errors.New("unauthorized")
+	}
+
+	switch settings.CommentPolicy {
+	case "", models.CommentPolicyEveryone, models.CommentPolicyFollowers, models.CommentPolicyOff:
+	default:
+		return errors.New("invalid comment policy")
+	}
+
+	if settings.Visibility != "" && !isVisibility(settings.Visibility) {
+		return errors.New("invalid visibility")
+	}
+
+	// The post may have been trashed since it was read
+	if err := s.repo.SetPostSettings(post_id, settings.CommentPolicy, settings.Visibility); err != nil {
+		return errors.New("error retrieving post")
+	}
+
+	return nil
+}
+
+func (s *Service) RestorePost(post_id, author_id string) (err error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != author_id {
+		return errors.New("unauthorized")
+	}
+
+	if err := s.checkRestorable(post_meta.DeletedAt); err != nil {
+		return err
+	}
+
+	if err := s.repo.RestorePostByID(post_id); err != nil {
+		return err
+	}
+
+	if post_meta.Published() {
+		s.publish(Event{Type: EventPostRestored, UserId: author_id, PostId: post_id, At: s.now()})
+	}
+
+	return nil
+}
+
+/*------------------------------------------------------------------------
+*                             Like
+------------------------------------------------------------------------*/
+
+func (s *Service) LikePost(post_id, user_id string) (summary models.LikeSummaryDTO, err error) {
+	if _, err := s.getViewablePost(post_id, user_id); err != nil {
+		return models.LikeSummaryDTO{}, err
+	}
+
+	liked, err := s.likes.LikePost(post_id, user_id)
+
+	if err != nil {
+		return models.LikeSummaryDTO{}, errors.New("error liking post")
+	}
+
+	if liked {
+		s.publish(Event{Type: EventPostLiked, UserId: user_id, PostId: post_id, At: s.now()})
+	}
+
+	return s.likeSummary(post_id, user_id)
+}
+
+func (s *Service) UnlikePost(post_id, user_id string) (summary models.LikeSummaryDTO, err error) {
+	// Likes can be taken back even from posts the user no longer sees
+	if _, err := s.repo.GetPostMetaByID(post_id); err != nil {
+		return models.LikeSummaryDTO{}, errors.New("error retrieving post")
+	}
+
+	unliked, err := s.likes.UnlikePost(post_id, user_id)
+
+	if err != nil {
+		return models.LikeSummaryDTO{}, errors.New("error unliking post")
+	}
+
+	if unliked {
+		s.publish(Event{Type: EventPostUnliked, UserId: user_id, PostId: post_id, At: s.now()})
+	}
+
+	return s.likeSummary(post_id, user_id)
+}
+
+func (s *Service) ListPostLikes(post_id, viewer_id string, query models.LikeQueryDTO) (page models.LikePageDTO, err error) {
+	if _, err := s.getViewablePost(post_id, viewer_id); err != nil {
+		return models.LikePageDTO{}, err
+	}
+
+	likes, next, err := s.likes.GetLikes(post_id, query.Cursor, query.Limit)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return models.LikePageDTO{}, err
+		}
+		return models.LikePageDTO{}, errors.New("error retrieving likes")
+	}
+
+	return models.LikePageDTO{Likes: likes, NextCursor: next}, nil
+}
+
+func (s *Service) GetLikeSummaries(post_ids []string, viewer_id string) (summaries map[string]models.LikeSummaryDTO, err error) {
+	counts, err := s.likes.CountLikes(post_ids)
+
+	if err != nil {
+		return nil, errors.New("error retrieving likes")
+	}
+
+	liked := map[string]bool{}
+	if viewer_id != "" {
+		liked, err = s.likes.GetLikedPosts(viewer_id, post_ids)
+
+		if err != nil {
+			return nil, errors.New("error retrieving likes")
+		}
+	}
+
+	summaries = make(map[string]models.LikeSummaryDTO, len(post_ids))
+	for _, post_id := range post_ids {
+		summaries[post_id] = models.LikeSummaryDTO{LikeCount: counts[post_id], LikedByMe: liked[post_id]}
+	}
+
+	return summaries, nil
+}
+
+// likeSummary sums up the likes of a single post for a user
+func (s *Service) likeSummary(post_id, user_id string) (models.LikeSummaryDTO, error) {
+	summaries, err := s.GetLikeSummaries([]string{post_id}, user_id)
+
+	if err != nil {
+		return models.LikeSummaryDTO{}, err
+	}
+
+	return summaries[post_id], nil
+}
+
 /*
 ------------------------------------------------------------------------
 *                             Comment
@@ -109,31 +528,1044 @@ func (s *Service) CommentOnPost(comment models.CommentRequestDTO) (comment_id st
 	// Implement the logic to create a new post
 
 	// Check for validity of post id
-	_, post_err := s.repo.GetPostMetaByID(comment.PostId)
+	post_meta, post_err := s.getViewablePost(comment.PostId, comment.AuthorId)
 
 	if post_err != nil {
-		return "", errors.New("error retrieving post")
+		return "", post_err
+	}
+
+	if err := s.checkCommentPolicy(post_meta, comment.AuthorId); err != nil {
+		return "", err
+	}
+
+	// A reply must point to a live comment on the same post
+	if comment.ParentId != "" {
+		parent, parent_err := s.repo.GetCommentByID(comment.ParentId)
+
+		if parent_err != nil || parent.DeletedAt != nil || parent.PostId != comment.PostId {
+			return "", errors.New("error retrieving parent comment")
+		}
+	}
+
+	comment.Mentions = s.resolveMentions(comment.AuthorId, comment.Comment)
+
+	created_at := s.now()
+
+	comment_id, err = s.repo.SaveComment(comment, created_at)
+
+	if err != nil {
+		return "", err
 	}
 
-	return s.repo.SaveComment(comment)
+	s.publish(Event{Type: EventCommentCreated, UserId: comment.AuthorId, PostId: comment.PostId, CommentId: comment_id, At: created_at})
+	s.notifyMentions(comment.AuthorId, comment.PostId, comment_id, comment.Mentions, nil)
+
+	return comment_id, nil
 }
 
 func (s *Service) DeleteComment(comment_id, author_id string) (err error) {
-	// Implement the logic to retrieve a post by ID
-	comment, err := s.repo.GetCommentByID(comment_id)
+	// The author of the comment can delete it, and so can the author of the
+	// post it was written on
+	comment, err := s.getOwnedComment(comment_id, author_id)
 
 	if err != nil {
-		return errors.New("error retrieving comment")
+		if err.Error() != "unauthorized" {
+			return err
+		}
+
+		if comment, err = s.getModeratedComment(comment_id, author_id); err != nil {
+			return err
+		}
 	}
 
-	if comment.Creator != author_id {
-		return errors.New("unauthorized")
+	deleted_at := s.now()
+
+	if err := s.repo.DeleteCommentByID(comment_id, author_id, deleted_at); err != nil {
+		return err
+	}
+
+	s.publish(Event{Type: EventCommentDeleted, UserId: author_id, PostId: comment.PostId, CommentId: comment_id, At: deleted_at})
+
+	return nil
+}
+
+func (s *Service) HideComment(comment_id, post_author_id string) (err error) {
+	comment, err := s.getModeratedComment(comment_id, post_author_id)
+
+	if err != nil {
+		return err
+	}
+
+	hidden_at := s.now()
+
+	if err := s.repo.SetCommentHidden(comment_id, &hidden_at); err != nil {
+		return err
+	}
+
+	s.publish(Event{Type: EventCommentHidden, UserId: post_author_id, PostId: comment.PostId, CommentId: comment_id, At: hidden_at})
+
+	return nil
+}
+
+func (s *Service) UnhideComment(comment_id, post_author_id string) (err error) {
+	comment, err := s.getModeratedComment(comment_id, post_author_id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetCommentHidden(comment_id, nil); err != nil {
+		return err
+	}
+
+	s.publish(Event{Type: EventCommentUnhidden, UserId: post_author_id, PostId: comment.PostId, CommentId: comment_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) EditComment(comment_id, author_id, content string) (comment models.CommentDTO, err error) {
+	if content == "" {
+		return models.CommentDTO{}, errors.New("empty comment")
+	}
+
+	comment, err = s.getOwnedComment(comment_id, author_id)
+
+	if err != nil {
+		return models.CommentDTO{}, err
+	}
+
+	edited_at := s.now()
+
+	if s.config.CommentEditWindow > 0 && edited_at.Sub(comment.CreatedAt) > s.config.CommentEditWindow {
+		return models.CommentDTO{}, errors.New("edit window expired")
+	}
+
+	previous := comment.Mentions
+
+	comment, err = s.repo.UpdateComment(comment_id, content, s.resolveMentions(author_id, content), edited_at)
+
+	if err != nil {
+		return models.CommentDTO{}, err
 	}
 
-	return s.repo.DeleteCommentByID(comment_id)
+	s.publish(Event{Type: EventCommentEdited, UserId: author_id, PostId: comment.PostId, CommentId: comment_id, At: edited_at})
+	s.notifyMentions(author_id, comment.PostId, comment_id, comment.Mentions, previous)
+
+	return comment, nil
+}
+
+func (s *Service) ReactToComment(comment_id, user_id, reaction string) (reactions models.CommentReactionsDTO, err error) {
+	if !slices.Contains(models.CommentReactions, reaction) {
+		return models.CommentReactionsDTO{}, errors.New("invalid reaction")
+	}
+
+	if _, err := s.getViewableComment(comment_id, user_id); err != nil {
+		return models.CommentReactionsDTO{}, err
+	}
+
+	if err := s.likes.SetCommentReaction(comment_id, user_id, reaction); err != nil {
+		return models.CommentReactionsDTO{}, errors.New("error reacting to comment")
+	}
+
+	return s.commentReactions(comment_id, user_id)
+}
+
+func (s *Service) RemoveCommentReaction(comment_id, user_id string) (reactions models.CommentReactionsDTO, err error) {
+	// Reactions can be taken back even from comments the user no longer sees
+	if _, err := s.repo.GetCommentByID(comment_id); err != nil {
+		return models.CommentReactionsDTO{}, errors.New("error retrieving comment")
+	}
+
+	if err := s.likes.RemoveCommentReaction(comment_id, user_id); err != nil {
+		return models.CommentReactionsDTO{}, errors.New("error reacting to comment")
+	}
+
+	return s.commentReactions(comment_id, user_id)
 }
 
-func (s *Service) GetPostComments(post_id string) (comments []models.CommentDTO, err error) {
-	// TODO : Use config to set number of comments to retrieve; also the max file size
-	return s.repo.GetPostLatestComments(post_id, 2)
+func (s *Service) GetCommentReactions(comment_ids []string, viewer_id string) (reactions map[string]models.CommentReactionsDTO, err error) {
+	counts, err := s.likes.CountCommentReactions(comment_ids)
+
+	if err != nil {
+		return nil, errors.New("error retrieving reactions")
+	}
+
+	mine := map[string]string{}
+	if viewer_id != "" {
+		mine, err = s.likes.GetUserCommentReactions(viewer_id, comment_ids)
+
+		if err != nil {
+			return nil, errors.New("error retrieving reactions")
+		}
+	}
+
+	reactions = make(map[string]models.CommentReactionsDTO, len(comment_ids))
+	for _, comment_id := range comment_ids {
+		comment_reactions := counts[comment_id]
+		if comment_reactions == nil {
+			comment_reactions = map[string]int{}
+		}
+
+		reactions[comment_id] = models.CommentReactionsDTO{Reactions: comment_reactions, MyReaction: mine[comment_id]}
+	}
+
+	return reactions, nil
+}
+
+// commentReactions sums up the reactions on a single comment for a user
+func (s *Service) commentReactions(comment_id, user_id string) (models.CommentReactionsDTO, error) {
+	reactions, err := s.GetCommentReactions([]string{comment_id}, user_id)
+
+	if err != nil {
+		return models.CommentReactionsDTO{}, err
+	}
+
+	return reactions[comment_id], nil
+}
+
+func (s *Service) GetCommentRevisions(comment_id, requester_id string) (revisions []models.CommentRevisionDTO, err error) {
+	if !slices.Contains(s.config.Moderators, requester_id) {
+		return nil, errors.New("unauthorized")
+	}
+
+	revisions, err = s.repo.GetCommentRevisions(comment_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving comment")
+	}
+
+	return revisions, nil
+}
+
+func (s *Service) RestoreComment(comment_id, author_id string) (err error) {
+	comment, err := s.repo.GetCommentByID(comment_id)
+
+	if err != nil {
+		return errors.New("error retrieving comment")
+	}
+
+	// Only whoever deleted the comment can restore it, so a commenter can't
+	// bring back a comment the post author removed
+	if comment.DeletedBy != author_id && (comment.DeletedBy != "" || comment.Creator != author_id) {
+		return errors.New("unauthorized")
+	}
+
+	if err := s.checkRestorable(comment.DeletedAt); err != nil {
+		return err
+	}
+
+	if err := s.repo.RestoreCommentByID(comment_id); err != nil {
+		return err
+	}
+
+	s.publish(Event{Type: EventCommentRestored, UserId: author_id, PostId: comment.PostId, CommentId: comment_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) ListPostComments(post_id, viewer_id string, query models.CommentQueryDTO) (page models.CommentPageDTO, err error) {
+	if _, err := s.getViewablePost(post_id, viewer_id); err != nil {
+		return models.CommentPageDTO{}, err
+	}
+
+	var desc bool
+	key := commentCursor
+	switch query.Order {
+	case "", "oldest":
+	case "newest":
+		desc = true
+	case "top":
+		desc = true
+		key = topCommentCursor
+	default:
+		return models.CommentPageDTO{}, errors.New("invalid order")
+	}
+
+	max_depth := query.MaxDepth
+	if max_depth <= 0 || max_depth > s.config.CommentThreadMaxDepth {
+		max_depth = s.config.CommentThreadMaxDepth
+	}
+
+	comments, err := s.repo.GetPostComments(post_id)
+
+	if err != nil {
+		return models.CommentPageDTO{}, errors.New("error retrieving comments")
+	}
+
+	hiddenList, err := hiddenUsers(s.repo, viewer_id)
+
+	if err != nil {
+		return models.CommentPageDTO{}, err
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, user_id := range hiddenList {
+		hidden[user_id] = true
+	}
+
+	replies := make(map[string][]models.CommentDTO)
+	var roots []models.CommentDTO
+
+	for _, c := range comments {
+		if c.ParentId == "" {
+			roots = append(roots, c)
+		} else {
+			replies[c.ParentId] = append(replies[c.ParentId], c)
+		}
+	}
+
+	threads := []models.CommentResponseDTO{}
+
+	for _, root := range roots {
+		if thread, visible := buildCommentThread(root, replies, hidden, 1, max_depth); visible {
+			threads = append(threads, thread)
+		}
+	}
+
+	reactions, err := s.GetCommentReactions(collectCommentIds(nil, threads), viewer_id)
+
+	if err != nil {
+		return models.CommentPageDTO{}, err
+	}
+
+	setCommentReactions(threads, reactions)
+
+	// Top-level threads are paginated; replies always read oldest first
+	pagination.Sort(threads, desc, key)
+
+	threads, next, err := pagination.Paginate(threads, query.Cursor, query.Limit, desc, key)
+
+	if err != nil {
+		return models.CommentPageDTO{}, err
+	}
+
+	return models.CommentPageDTO{Comments: threads, NextCursor: next}, nil
+}
+
+func commentCursor(c models.CommentResponseDTO) pagination.Cursor {
+	return pagination.Cursor{Time: c.CreatedAt, Id: c.Id}
+}
+
+// topCommentCursor ranks comments by how many reactions they got
+func topCommentCursor(c models.CommentResponseDTO) pagination.Cursor {
+	rank := 0
+	for _, count := range c.Reactions {
+		rank += count
+	}
+	return pagination.Cursor{Rank: rank, Time: c.CreatedAt, Id: c.Id}
+}
+
+// collectCommentIds lists the comments of threads that are not placeholders
+func collectCommentIds(ids []string, comments []models.CommentResponseDTO) []string {
+	for _, comment := range comments {
+		if !comment.Deleted && !comment.Hidden {
+			ids = append(ids, comment.Id)
+		}
+		ids = collectCommentIds(ids, comment.Replies)
+	}
+	return ids
+}
+
+func setCommentReactions(comments []models.CommentResponseDTO, reactions map[string]models.CommentReactionsDTO) {
+	for i := range comments {
+		if comment_reactions, found := reactions[comments[i].Id]; found {
+			comments[i].Reactions = comment_reactions.Reactions
+			comments[i].MyReaction = comment_reactions.MyReaction
+		}
+		setCommentReactions(comments[i].Replies, reactions)
+	}
+}
+
+// buildCommentThread turns a comment and its replies into a nested response.
+// A trashed or hidden comment is only visible as a "[deleted]" or "[hidden]"
+// placeholder, and only while some of its replies are still visible.
+func buildCommentThread(comment models.CommentDTO, replies map[string][]models.CommentDTO, hidden map[string]bool, depth, max_depth int) (models.CommentResponseDTO, bool) {
+	thread := models.CommentResponseDTO{
+		Id:        comment.Id,
+		ParentId:  comment.ParentId,
+		Comment:   comment.Content,
+		Mentions:  comment.Mentions,
+		AuthorId:  comment.Creator,
+		CreatedAt: comment.CreatedAt,
+		EditedAt:  comment.EditedAt,
+	}
+
+	for _, reply := range replies[comment.Id] {
+		replyThread, visible := buildCommentThread(reply, replies, hidden, depth+1, max_depth)
+		if !visible {
+			continue
+		}
+
+		thread.ReplyCount++
+		if depth < max_depth {
+			thread.Replies = append(thread.Replies, replyThread)
+		}
+	}
+
+	// Comments by users hidden from the viewer read like comments the post
+	// author hid, so muting stays invisible to the muted user
+	if comment.DeletedAt != nil || comment.HiddenAt != nil || hidden[comment.Creator] {
+		if thread.ReplyCount == 0 {
+			return models.CommentResponseDTO{}, false
+		}
+
+		if comment.DeletedAt != nil {
+			thread.Comment = "[deleted]"
+			thread.Deleted = true
+		} else {
+			thread.Comment = "[hidden]"
+			thread.Hidden = true
+		}
+		thread.Mentions = nil
+		thread.AuthorId = ""
+		thread.EditedAt = nil
+	}
+
+	return thread, true
+}
+
+/*------------------------------------------------------------------------
+*                             Social graph
+------------------------------------------------------------------------*/
+
+func (s *Service) FollowUser(follower_id, followee_id string) (status string, err error) {
+	if follower_id == followee_id {
+		return "", errors.New("cannot follow yourself")
+	}
+
+	following, err := s.repo.IsFollowing(follower_id, followee_id)
+
+	if err != nil {
+		return "", errors.New("error following user")
+	}
+
+	if following {
+		return models.FollowStatusFollowing, nil
+	}
+
+	blocked, err := s.eitherBlocks(follower_id, followee_id)
+
+	if err != nil {
+		return "", errors.New("error following user")
+	}
+
+	if blocked {
+		return "", errors.New("blocked")
+	}
+
+	// Following a private account takes the approval of its owner
+	if s.isPrivate(followee_id) {
+		return s.requestFollow(follower_id, followee_id)
+	}
+
+	if err := s.follow(follower_id, followee_id); err != nil {
+		return "", err
+	}
+
+	return models.FollowStatusFollowing, nil
+}
+
+func (s *Service) UnfollowUser(follower_id, followee_id string) (err error) {
+	// Unfollowing also withdraws a pending follow request
+	if err := s.repo.DeleteFollowRequest(follower_id, followee_id); err != nil {
+		return errors.New("error unfollowing user")
+	}
+
+	if err := s.repo.UnfollowUser(follower_id, followee_id); err != nil {
+		return errors.New("error unfollowing user")
+	}
+
+	s.publish(Event{Type: EventUserUnfollowed, UserId: follower_id, TargetId: followee_id, At: s.now()})
+
+	return nil
+}
+
+func (s *Service) ListFollowRequests(user_id string, query models.FollowQueryDTO) (page models.FollowRequestPageDTO, err error) {
+	requests, next, err := s.repo.GetPendingFollowRequests(user_id, query.Cursor, query.Limit)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return models.FollowRequestPageDTO{}, err
+		}
+		return models.FollowRequestPageDTO{}, errors.New("error retrieving follow requests")
+	}
+
+	return models.FollowRequestPageDTO{Requests: requests, NextCursor: next}, nil
+}
+
+func (s *Service) ApproveFollowRequest(user_id, requester_id string) (err error) {
+	request, err := s.getPendingFollowRequest(requester_id, user_id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.follow(requester_id, user_id); err != nil {
+		return err
+	}
+
+	return s.decideFollowRequest(request, models.FollowRequestApproved)
+}
+
+func (s *Service) DenyFollowRequest(user_id, requester_id string) (err error) {
+	request, err := s.getPendingFollowRequest(requester_id, user_id)
+
+	if err != nil {
+		return err
+	}
+
+	return s.decideFollowRequest(request, models.FollowRequestDenied)
+}
+
+func (s *Service) BlockUser(blocker_id, blocked_id string) (err error) {
+	if blocker_id == blocked_id {
+		return errors.New("cannot block yourself")
+	}
+
+	if err := s.repo.BlockUser(blocker_id, blocked_id); err != nil {
+		return errors.New("error blocking user")
+	}
+
+	// Blocking cuts the follows and follow requests in both directions
+	if err := s.UnfollowUser(blocker_id, blocked_id); err != nil {
+		return err
+	}
+
+	return s.UnfollowUser(blocked_id, blocker_id)
+}
+
+func (s *Service) UnblockUser(blocker_id, blocked_id string) (err error) {
+	if err := s.repo.UnblockUser(blocker_id, blocked_id); err != nil {
+		return errors.New("error unblocking user")
+	}
+
+	return nil
+}
+
+func (s *Service) ListBlockedUsers(user_id string) (user_ids []string, err error) {
+	blocked, _, err := s.repo.GetBlocks(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving blocks")
+	}
+
+	return blocked, nil
+}
+
+func (s *Service) MuteUser(muter_id, muted_id string) (err error) {
+	if muter_id == muted_id {
+		return errors.New("cannot mute yourself")
+	}
+
+	if err := s.repo.MuteUser(muter_id, muted_id); err != nil {
+		return errors.New("error muting user")
+	}
+
+	return nil
+}
+
+func (s *Service) UnmuteUser(muter_id, muted_id string) (err error) {
+	if err := s.repo.UnmuteUser(muter_id, muted_id); err != nil {
+		return errors.New("error unmuting user")
+	}
+
+	return nil
+}
+
+func (s *Service) ListMutedUsers(user_id string) (user_ids []string, err error) {
+	muted, err := s.repo.GetMutedUsers(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving mutes")
+	}
+
+	return muted, nil
+}
+
+func (s *Service) AddCloseFriend(user_id, friend_id string) (err error) {
+	if user_id == friend_id {
+		return errors.New("cannot add yourself to close friends")
+	}
+
+	if err := s.repo.AddCloseFriend(user_id, friend_id); err != nil {
+		return errors.New("error updating close friends")
+	}
+
+	return nil
+}
+
+func (s *Service) RemoveCloseFriend(user_id, friend_id string) (err error) {
+	if err := s.repo.RemoveCloseFriend(user_id, friend_id); err != nil {
+		return errors.New("error updating close friends")
+	}
+
+	return nil
+}
+
+func (s *Service) ListCloseFriends(user_id string) (user_ids []string, err error) {
+	friends, err := s.repo.GetCloseFriends(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving close friends")
+	}
+
+	return friends, nil
+}
+
+func (s *Service) follow(follower_id, followee_id string) error {
+	if err := s.repo.FollowUser(follower_id, followee_id); err != nil {
+		return errors.New("error following user")
+	}
+
+	s.publish(Event{Type: EventUserFollowed, UserId: follower_id, TargetId: followee_id, At: s.now()})
+
+	return nil
+}
+
+// requestFollow asks a private account for permission to follow it
+func (s *Service) requestFollow(follower_id, followee_id string) (string, error) {
+	// Asking again while a request is pending keeps its place in the queue;
+	// a denied request can be made again
+	if existing, err := s.repo.GetFollowRequest(follower_id, followee_id); err == nil && existing.Status == models.FollowRequestPending {
+		return models.FollowStatusRequested, nil
+	}
+
+	request := models.FollowRequestDTO{
+		RequesterId: follower_id,
+		TargetId:    followee_id,
+		Status:      models.FollowRequestPending,
+		CreatedAt:   s.now(),
+	}
+
+	if err := s.repo.SaveFollowRequest(request); err != nil {
+		return "", errors.New("error following user")
+	}
+
+	return models.FollowStatusRequested, nil
+}
+
+func (s *Service) getPendingFollowRequest(requester_id, target_id string) (models.FollowRequestDTO, error) {
+	request, err := s.repo.GetFollowRequest(requester_id, target_id)
+
+	if err != nil || request.Status != models.FollowRequestPending {
+		return models.FollowRequestDTO{}, errors.New("follow request not found")
+	}
+
+	return request, nil
+}
+
+func (s *Service) decideFollowRequest(request models.FollowRequestDTO, status string) error {
+	decidedAt := s.now()
+	request.Status = status
+	request.DecidedAt = &decidedAt
+
+	if err := s.repo.SaveFollowRequest(request); err != nil {
+		return errors.New("error updating follow request")
+	}
+
+	return nil
+}
+
+func (s *Service) ListFollowers(user_id, viewer_id string, query models.FollowQueryDTO) (page models.FollowPageDTO, err error) {
+	if err := s.checkFollowsVisible(user_id, viewer_id); err != nil {
+		return models.FollowPageDTO{}, err
+	}
+
+	return followPage(s.repo.GetFollowers(user_id, query.Cursor, query.Limit))
+}
+
+func (s *Service) ListFollowing(user_id, viewer_id string, query models.FollowQueryDTO) (page models.FollowPageDTO, err error) {
+	if err := s.checkFollowsVisible(user_id, viewer_id); err != nil {
+		return models.FollowPageDTO{}, err
+	}
+
+	return followPage(s.repo.GetFollowing(user_id, query.Cursor, query.Limit))
+}
+
+// checkFollowsVisible verifies that a viewer, "" when anonymous, can see the
+// follower and following lists of a user. They are shown to whoever can see
+// the posts of the user.
+func (s *Service) checkFollowsVisible(user_id, viewer_id string) error {
+	canView, err := s.canViewPosts(viewer_id, user_id)
+
+	if err != nil {
+		return errors.New("error retrieving follows")
+	}
+
+	if !canView {
+		return errors.New("private account")
+	}
+
+	return nil
+}
+
+func followPage(users []models.FollowDTO, next string, err error) (models.FollowPageDTO, error) {
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return models.FollowPageDTO{}, err
+		}
+		return models.FollowPageDTO{}, errors.New("error retrieving follows")
+	}
+
+	return models.FollowPageDTO{Users: users, NextCursor: next}, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Trash
+------------------------------------------------------------------------*/
+
+// PurgeExpiredTrash permanently removes the items whose restore window is
+// over, along with their likes and reactions
+func (s *Service) PurgeExpiredTrash() (purged int, err error) {
+	post_ids, comment_ids, err := s.repo.PurgeDeletedBefore(s.now().Add(-s.config.TrashRetention))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.likes.PurgePostLikes(post_ids); err != nil {
+		return 0, err
+	}
+	if err := s.likes.PurgeCommentReactions(comment_ids); err != nil {
+		return 0, err
+	}
+
+	return len(post_ids) + len(comment_ids), nil
+}
+
+// RunTrashPurger purges the expired trash periodically until ctx is cancelled
+func (s *Service) RunTrashPurger(ctx context.Context) {
+	ticker := time.NewTicker(s.config.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if purged, err := s.PurgeExpiredTrash(); err != nil {
+				log.Printf("trash purger: %v", err)
+			} else if purged > 0 {
+				log.Printf("trash purger: purged %d items", purged)
+			}
+		}
+	}
+}
+
+// getOwnedComment retrieves a comment that is not in the trash and checks
+// that it was written by author_id
+func (s *Service) getOwnedComment(comment_id, author_id string) (models.CommentDTO, error) {
+	comment, err := s.repo.GetCommentByID(comment_id)
+
+	if err != nil || comment.DeletedAt != nil {
+		return models.CommentDTO{}, errors.New("error retrieving comment")
+	}
+
+	if comment.Creator != author_id {
+		return models.CommentDTO{}, errors.New("unauthorized")
+	}
+
+	return comment, nil
+}
+
+// getViewableComment retrieves a comment that is neither trashed nor hidden,
+// on a post the viewer can see, by an author they don't block or get blocked by
+func (s *Service) getViewableComment(comment_id, viewer_id string) (models.CommentDTO, error) {
+	comment, err := s.repo.GetCommentByID(comment_id)
+
+	if err != nil || comment.DeletedAt != nil || comment.HiddenAt != nil {
+		return models.CommentDTO{}, errors.New("error retrieving comment")
+	}
+
+	if _, err := s.getViewablePost(comment.PostId, viewer_id); err != nil {
+		return models.CommentDTO{}, errors.New("error retrieving comment")
+	}
+
+	if viewer_id != comment.Creator {
+		blocked, err := s.eitherBlocks(viewer_id, comment.Creator)
+
+		if err != nil || blocked {
+			return models.CommentDTO{}, errors.New("error retrieving comment")
+		}
+	}
+
+	return comment, nil
+}
+
+// getModeratedComment retrieves a comment that is not in the trash and checks
+// that it was written on a post authored by post_author_id
+func (s *Service) getModeratedComment(comment_id, post_author_id string) (models.CommentDTO, error) {
+	comment, err := s.repo.GetCommentByID(comment_id)
+
+	if err != nil || comment.DeletedAt != nil {
+		return models.CommentDTO{}, errors.New("error retrieving comment")
+	}
+
+	post_meta, err := s.repo.GetPostMetaByID(comment.PostId)
+
+	if err != nil {
+		return models.CommentDTO{}, errors.New("error retrieving post")
+	}
+
+	if post_meta.Creator != post_author_id {
+		return models.CommentDTO{}, errors.New("unauthorized")
+	}
+
+	return comment, nil
+}
+
+// getViewablePost retrieves a live post the viewer is allowed to see. Posts
+// the viewer cannot see are reported as missing, so as not to reveal them.
+func (s *Service) getViewablePost(post_id, viewer_id string) (models.PostMetaDTO, error) {
+	post_meta, err := s.repo.GetPostMetaByID(post_id)
+
+	if err != nil || post_meta.DeletedAt != nil || !post_meta.Published() {
+		return models.PostMetaDTO{}, errors.New("error retrieving post")
+	}
+
+	canView, err := s.canViewPosts(viewer_id, post_meta.Creator)
+
+	if err != nil || !canView {
+		return models.PostMetaDTO{}, errors.New("error retrieving post")
+	}
+
+	canView, err = postVisibleTo(s.repo, viewer_id, post_meta)
+
+	if err != nil || !canView {
+		return models.PostMetaDTO{}, errors.New("error retrieving post")
+	}
+
+	return post_meta, nil
+}
+
+// canViewPosts tells whether a viewer, "" when anonymous, can see the posts
+// of an author. Private accounts only show them to their approved followers,
+// and blocking hides them both ways.
+func (s *Service) canViewPosts(viewer_id, author_id string) (bool, error) {
+	if viewer_id == author_id {
+		return true, nil
+	}
+
+	if viewer_id != "" {
+		blocked, err := s.eitherBlocks(viewer_id, author_id)
+
+		if err != nil || blocked {
+			return false, err
+		}
+	}
+
+	if !s.isPrivate(author_id) {
+		return true, nil
+	}
+
+	if viewer_id == "" {
+		return false, nil
+	}
+
+	following, err := s.repo.IsFollowing(viewer_id, author_id)
+
+	if err != nil {
+		return false, errors.New("error retrieving followers")
+	}
+
+	return following, nil
+}
+
+// postVisibleTo tells whether the visibility an author chose for a post lets
+// a viewer see it. Account privacy and blocks are checked separately.
+func postVisibleTo(repo repository.IRepository, viewer_id string, post_meta models.PostMetaDTO) (bool, error) {
+	if viewer_id == post_meta.Creator {
+		return true, nil
+	}
+
+	switch post_meta.Visibility {
+	case models.VisibilityPublic, "":
+		return true, nil
+	case models.VisibilityFollowers:
+		if viewer_id == "" {
+			return false, nil
+		}
+
+		following, err := repo.IsFollowing(viewer_id, post_meta.Creator)
+
+		if err != nil {
+			return false, errors.New("error retrieving followers")
+		}
+
+		return following, nil
+	case models.VisibilityCloseFriends:
+		if viewer_id == "" {
+			return false, nil
+		}
+
+		closeFriend, err := repo.IsCloseFriend(post_meta.Creator, viewer_id)
+
+		if err != nil {
+			return false, errors.New("error retrieving close friends")
+		}
+
+		return closeFriend, nil
+	default:
+		return false, nil
+	}
+}
+
+func isVisibility(visibility string) bool {
+	switch visibility {
+	case models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityCloseFriends, models.VisibilityOnlyMe:
+		return true
+	}
+	return false
+}
+
+// eitherBlocks tells whether one of two users blocks the other
+func (s *Service) eitherBlocks(user_id, other_id string) (bool, error) {
+	for _, pair := range [][2]string{{user_id, other_id}, {other_id, user_id}} {
+		blocked, err := s.repo.IsBlocked(pair[0], pair[1])
+
+		if err != nil {
+			return false, errors.New("error retrieving blocks")
+		}
+
+		if blocked {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// hiddenUsers lists the users whose posts and comments are kept from a
+// viewer: the users they mute or block, and the users blocking them
+func hiddenUsers(repo repository.IRepository, viewer_id string) ([]string, error) {
+	if viewer_id == "" {
+		return nil, nil
+	}
+
+	blocked, blocked_by, err := repo.GetBlocks(viewer_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving blocks")
+	}
+
+	muted, err := repo.GetMutedUsers(viewer_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving mutes")
+	}
+
+	return slices.Concat(blocked, blocked_by, muted), nil
+}
+
+// isPrivate tells whether a user has made their account private
+func (s *Service) isPrivate(user_id string) bool {
+	if s.users == nil {
+		return false
+	}
+
+	user, err := s.users.GetUserByID(user_id)
+
+	return err == nil && user.Private
+}
+
+// resolveMentions links the @usernames in a caption or comment to the users
+// they name. Unknown usernames, and users who block the author, are left as
+// plain text.
+func (s *Service) resolveMentions(author_id, content string) []models.MentionDTO {
+	if s.users == nil {
+		return nil
+	}
+
+	var mentions []models.MentionDTO
+
+	for _, mention := range text.Mentions(content) {
+		user, err := s.users.GetUserByUsername(mention.Username)
+
+		if err != nil {
+			continue
+		}
+
+		if user.Id != author_id {
+			blocked, err := s.repo.IsBlocked(user.Id, author_id)
+
+			if err != nil || blocked {
+				continue
+			}
+		}
+
+		mentions = append(mentions, models.MentionDTO{
+			UserId:   user.Id,
+			Username: user.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		})
+	}
+
+	return mentions
+}
+
+// notifyMentions publishes a mention for every user newly mentioned in a post,
+// or in one of its comments when comment_id is set. Users mentioned before an
+// edit, the author themselves, and users who cannot see what they were
+// mentioned in are not notified.
+func (s *Service) notifyMentions(author_id, post_id, comment_id string, mentions, previous []models.MentionDTO) {
+	notified := map[string]bool{author_id: true}
+	for _, mention := range previous {
+		notified[mention.UserId] = true
+	}
+
+	for _, mention := range mentions {
+		if notified[mention.UserId] {
+			continue
+		}
+		notified[mention.UserId] = true
+
+		var err error
+		if comment_id != "" {
+			_, err = s.getViewableComment(comment_id, mention.UserId)
+		} else {
+			_, err = s.getViewablePost(post_id, mention.UserId)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		s.publish(Event{Type: EventUserMentioned, UserId: author_id, PostId: post_id, CommentId: comment_id, TargetId: mention.UserId, At: s.now()})
+	}
+}
+
+// checkCommentPolicy verifies that the post accepts comments from author_id
+func (s *Service) checkCommentPolicy(post_meta models.PostMetaDTO, author_id string) error {
+	switch post_meta.CommentPolicy {
+	case models.CommentPolicyOff:
+		return errors.New("comments disabled")
+	case models.CommentPolicyFollowers:
+		if author_id == post_meta.Creator {
+			return nil
+		}
+
+		following, err := s.repo.IsFollowing(author_id, post_meta.Creator)
+		if err != nil {
+			return errors.New("error retrieving followers")
+		}
+		if !following {
+			return errors.New("comments limited to followers")
+		}
+	}
+
+	return nil
+}
+
+// checkRestorable verifies that an item is in the trash and still within
+// its restore window
+func (s *Service) checkRestorable(deletedAt *time.Time) error {
+	if deletedAt == nil {
+		return errors.New("not deleted")
+	}
+
+	if s.now().Sub(*deletedAt) > s.config.TrashRetention {
+		return errors.New("restore window expired")
+	}
+
+	return nil
 }
@@ -3,8 +3,11 @@ package service
 import (
 	"errors"
 	"image"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/anandh86/instagram/config"
 	"github.com/anandh86/instagram/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,13 +38,78 @@ func (m *MockRepository) GetImageByID(imgID string) (image.Image, error) {
 	return args.Get(0).(image.Image), args.Error(1)
 }
 
+func (m *MockRepository) DeleteImageByID(imgID string) error {
+	args := m.Called(imgID)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetAllPostMetas() ([]models.PostMetaDTO, error) {
 	args := m.Called()
 	return args.Get(0).([]models.PostMetaDTO), args.Error(1)
 }
 
-func (m *MockRepository) SaveComment(comment models.CommentRequestDTO) (string, error) {
-	args := m.Called(comment)
+func (m *MockRepository) CountPostsByCreator(creatorID string) (int, error) {
+	args := m.Called(creatorID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetPostMetasByCreator(creatorID string, cursor string, limit int) ([]models.PostMetaDTO, string, error) {
+	args := m.Called(creatorID, cursor, limit)
+	return args.Get(0).([]models.PostMetaDTO), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) GetUnpublishedPostMetas(creatorID string) ([]models.PostMetaDTO, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).([]models.PostMetaDTO), args.Error(1)
+}
+
+func (m *MockRepository) GetDueScheduledPosts(at time.Time) ([]models.PostMetaDTO, error) {
+	args := m.Called(at)
+	return args.Get(0).([]models.PostMetaDTO), args.Error(1)
+}
+
+func (m *MockRepository) SetPostCaption(postID, caption string, mentions []models.MentionDTO) error {
+	args := m.Called(postID, caption, mentions)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetPostSettings(postID, commentPolicy, visibility string) error {
+	args := m.Called(postID, commentPolicy, visibility)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SchedulePost(postID string, publishAt *time.Time) error {
+	args := m.Called(postID, publishAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PublishPost(postID string, at time.Time) (bool, error) {
+	args := m.Called(postID, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetUnannouncedPosts(before time.Time) ([]models.PostMetaDTO, error) {
+	args := m.Called(before)
+	return args.Get(0).([]models.PostMetaDTO), args.Error(1)
+}
+
+func (m *MockRepository) MarkPostAnnounced(postID string) error {
+	args := m.Called(postID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeletePostByID(postID string, deletedAt time.Time) error {
+	args := m.Called(postID, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RestorePostByID(postID string) error {
+	args := m.Called(postID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveComment(comment models.CommentRequestDTO, createdAt time.Time) (string, error) {
+	args := m.Called(comment, createdAt)
 	return args.String(0), args.Error(1)
 }
 
@@ -50,16 +118,159 @@ func (m *MockRepository) GetCommentByID(commentID string) (models.CommentDTO, er
 	return args.Get(0).(models.CommentDTO), args.Error(1)
 }
 
-func (m *MockRepository) DeleteCommentByID(commentID string) error {
+func (m *MockRepository) DeleteCommentByID(commentID string, deletedBy string, deletedAt time.Time) error {
+	args := m.Called(commentID, deletedBy, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPostComments(postID string) ([]models.CommentDTO, error) {
+	args := m.Called(postID)
+	return args.Get(0).([]models.CommentDTO), args.Error(1)
+}
+
+func (m *MockRepository) UpdateComment(commentID string, content string, mentions []models.MentionDTO, editedAt time.Time) (models.CommentDTO, error) {
+	args := m.Called(commentID, content, mentions, editedAt)
+	return args.Get(0).(models.CommentDTO), args.Error(1)
+}
+
+func (m *MockRepository) SetCommentHidden(commentID string, hiddenAt *time.Time) error {
+	args := m.Called(commentID, hiddenAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCommentRevisions(commentID string) ([]models.CommentRevisionDTO, error) {
 	args := m.Called(commentID)
+	return args.Get(0).([]models.CommentRevisionDTO), args.Error(1)
+}
+
+func (m *MockRepository) RestoreCommentByID(commentID string) error {
+	args := m.Called(commentID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FollowUser(followerID string, followeeID string) error {
+	args := m.Called(followerID, followeeID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UnfollowUser(followerID string, followeeID string) error {
+	args := m.Called(followerID, followeeID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IsFollowing(followerID string, followeeID string) (bool, error) {
+	args := m.Called(followerID, followeeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetFollowers(userID string, cursor string, limit int) ([]models.FollowDTO, string, error) {
+	args := m.Called(userID, cursor, limit)
+	return args.Get(0).([]models.FollowDTO), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) GetFollowing(userID string, cursor string, limit int) ([]models.FollowDTO, string, error) {
+	args := m.Called(userID, cursor, limit)
+	return args.Get(0).([]models.FollowDTO), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) SaveFollowRequest(request models.FollowRequestDTO) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetFollowRequest(requesterID string, targetID string) (models.FollowRequestDTO, error) {
+	args := m.Called(requesterID, targetID)
+	return args.Get(0).(models.FollowRequestDTO), args.Error(1)
+}
+
+func (m *MockRepository) DeleteFollowRequest(requesterID string, targetID string) error {
+	args := m.Called(requesterID, targetID)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetPostLatestComments(postID string, limit int) ([]models.CommentDTO, error) {
-	args := m.Called(postID, limit)
+func (m *MockRepository) GetPendingFollowRequests(targetID string, cursor string, limit int) ([]models.FollowRequestDTO, string, error) {
+	args := m.Called(targetID, cursor, limit)
+	return args.Get(0).([]models.FollowRequestDTO), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) CountFollows(userID string) (int, int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) PurgeDeletedBefore(cutoff time.Time) ([]string, []string, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).([]string), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockRepository) GetPostLatestComments(postID string, limit int, excludedAuthors []string) ([]models.CommentDTO, error) {
+	args := m.Called(postID, limit, excludedAuthors)
 	return args.Get(0).([]models.CommentDTO), args.Error(1)
 }
 
+func (m *MockRepository) BlockUser(blockerID, blockedID string) error {
+	args := m.Called(blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UnblockUser(blockerID, blockedID string) error {
+	args := m.Called(blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IsBlocked(blockerID, blockedID string) (bool, error) {
+	args := m.Called(blockerID, blockedID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetBlocks(userID string) ([]string, []string, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]string), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockRepository) MuteUser(muterID, mutedID string) error {
+	args := m.Called(muterID, mutedID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UnmuteUser(muterID, mutedID string) error {
+	args := m.Called(muterID, mutedID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetMutedUsers(muterID string) ([]string, error) {
+	args := m.Called(muterID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRepository) AddCloseFriend(userID, friendID string) error {
+	args := m.Called(userID, friendID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RemoveCloseFriend(userID, friendID string) error {
+	args := m.Called(userID, friendID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IsCloseFriend(userID, friendID string) (bool, error) {
+	args := m.Called(userID, friendID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetCloseFriends(userID string) ([]string, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// withoutBlocks stubs the block and mute lookups for tests where nobody
+// blocks or mutes anyone
+func withoutBlocks(m *MockRepository) {
+	m.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	m.On("GetBlocks", mock.Anything).Return([]string{}, []string{}, nil).Maybe()
+	m.On("GetMutedUsers", mock.Anything).Return([]string{}, nil).Maybe()
+}
+
 func TestCreatePost_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
 	svc := NewService(mockRepo)
@@ -71,7 +282,10 @@ func TestCreatePost_Success(t *testing.T) {
 	}
 
 	mockRepo.On("SaveImage", testImg).Return("img123", nil)
-	mockRepo.On("SavePostMeta", mock.Anything).Return("post123", nil)
+	mockRepo.On("SavePostMeta", mock.MatchedBy(func(p models.PostMetaDTO) bool {
+		return p.Unannounced
+	})).Return("post123", nil)
+	mockRepo.On("MarkPostAnnounced", "post123").Return(nil)
 
 	postID, err := svc.CreatePost(testImg, postInfo)
 
@@ -115,7 +329,7 @@ func TestGetPostById_Success(t *testing.T) {
 	mockRepo.On("GetPostMetaByID", postID).Return(postMeta, nil)
 	mockRepo.On("GetImageByID", "img123").Return(testImg, nil)
 
-	img, info, err := svc.GetPostById(postID)
+	img, info, err := svc.GetPostById(postID, "")
 
 	assert.NoError(t, err)
 	assert.Equal(t, testImg, img)
@@ -132,7 +346,7 @@ func TestGetPostById_GetPostMetaError(t *testing.T) {
 
 	mockRepo.On("GetPostMetaByID", postID).Return(models.PostMetaDTO{}, errors.New("error retrieving post"))
 
-	img, info, err := svc.GetPostById(postID)
+	img, info, err := svc.GetPostById(postID, "")
 
 	assert.Error(t, err)
 	assert.Nil(t, img)
@@ -160,10 +374,10 @@ func TestGetAllPosts_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetAllPostMetas").Return(postsMeta, nil)
-	mockRepo.On("GetPostLatestComments", "post1", 2).Return([]models.CommentDTO{}, nil)
-	mockRepo.On("GetPostLatestComments", "post2", 2).Return([]models.CommentDTO{}, nil)
+	mockRepo.On("GetPostLatestComments", "post1", 2, []string(nil)).Return([]models.CommentDTO{}, nil)
+	mockRepo.On("GetPostLatestComments", "post2", 2, []string(nil)).Return([]models.CommentDTO{}, nil)
 
-	posts, err := svc.GetAllPosts()
+	posts, err := svc.GetAllPosts("")
 
 	assert.NoError(t, err)
 	assert.Len(t, posts, 2)
@@ -174,6 +388,7 @@ func TestGetAllPosts_Success(t *testing.T) {
 
 func TestCommentOnPost_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
 	svc := NewService(mockRepo)
 
 	comment := models.CommentRequestDTO{
@@ -183,7 +398,7 @@ func TestCommentOnPost_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{}, nil)
-	mockRepo.On("SaveComment", comment).Return("comment123", nil)
+	mockRepo.On("SaveComment", comment, mock.Anything).Return("comment123", nil)
 
 	commentID, err := svc.CommentOnPost(comment)
 
@@ -203,7 +418,7 @@ func TestDeleteComment_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
-	mockRepo.On("DeleteCommentByID", "comment123").Return(nil)
+	mockRepo.On("DeleteCommentByID", "comment123", "user456", mock.Anything).Return(nil)
 
 	err := svc.DeleteComment("comment123", "user456")
 
@@ -217,11 +432,14 @@ func TestDeleteComment_Unauthorized(t *testing.T) {
 
 	comment := models.CommentDTO{
 		Id:      "comment123",
+		PostId:  "post123",
 		Content: "Nice post!",
 		Creator: "user789",
 	}
 
+	// Neither the comment's nor the post's author
 	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "user123"}, nil)
 
 	err := svc.DeleteComment("comment123", "user456")
 
@@ -229,3 +447,953 @@ func TestDeleteComment_Unauthorized(t *testing.T) {
 	assert.EqualError(t, err, "unauthorized")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestDeletePost_Unauthorized(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	postMeta := models.PostMetaDTO{Id: "post123", Creator: "user123"}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(postMeta, nil)
+
+	err := svc.DeletePost("post123", "user456")
+
+	assert.EqualError(t, err, "unauthorized")
+	mockRepo.AssertNotCalled(t, "DeletePostByID", "post123", mock.Anything)
+}
+
+func TestRestoreComment_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	deletedAt := time.Now().Add(-time.Hour)
+	comment := models.CommentDTO{
+		Id:        "comment123",
+		Creator:   "user456",
+		DeletedAt: &deletedAt,
+	}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+	mockRepo.On("RestoreCommentByID", "comment123").Return(nil)
+
+	err := svc.RestoreComment("comment123", "user456")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreComment_WindowExpired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	cfg := config.Default()
+	cfg.TrashRetention = 24 * time.Hour
+	svc := NewService(mockRepo, WithConfig(cfg))
+
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	comment := models.CommentDTO{
+		Id:        "comment123",
+		Creator:   "user456",
+		DeletedAt: &deletedAt,
+	}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+
+	err := svc.RestoreComment("comment123", "user456")
+
+	assert.EqualError(t, err, "restore window expired")
+	mockRepo.AssertNotCalled(t, "RestoreCommentByID", "comment123")
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	mockRepo := new(MockRepository)
+	cfg := config.Default()
+	cfg.TrashRetention = 24 * time.Hour
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewService(mockRepo, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	mockRepo.On("PurgeDeletedBefore", now.Add(-24*time.Hour)).Return([]string{"post123"}, []string{"comment123", "comment456"}, nil)
+
+	purged, err := svc.PurgeExpiredTrash()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTrashAndEdits_FollowTheServiceClock(t *testing.T) {
+	cfg := config.Default()
+	cfg.TrashRetention = 24 * time.Hour
+	cfg.CommentEditWindow = 15 * time.Minute
+	svc, now := newTestService(0, WithConfig(cfg))
+
+	kept := createTestPost(t, svc, "alice", "kept")
+	purged := createTestPost(t, svc, "alice", "purged")
+	comment_id, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: kept, AuthorId: "bob", Comment: "Nice psot"})
+	assert.NoError(t, err)
+
+	*now = now.Add(10 * time.Minute)
+	edited, err := svc.EditComment(comment_id, "bob", "Nice post")
+	assert.NoError(t, err)
+	assert.Equal(t, *now, *edited.EditedAt)
+
+	*now = now.Add(10 * time.Minute)
+	_, err = svc.EditComment(comment_id, "bob", "Nice post!")
+	assert.EqualError(t, err, "edit window expired")
+
+	assert.NoError(t, svc.DeletePost(purged, "alice"))
+	assert.NoError(t, svc.DeleteComment(comment_id, "bob"))
+
+	// Everything deleted can be restored until the retention is over, by the
+	// service's clock
+	*now = now.Add(24 * time.Hour)
+	assert.NoError(t, svc.RestoreComment(comment_id, "bob"))
+
+	*now = now.Add(time.Minute)
+	assert.EqualError(t, svc.RestorePost(purged, "alice"), "restore window expired")
+
+	count, err := svc.PurgeExpiredTrash()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = svc.repo.GetPostMetaByID(purged)
+	assert.Error(t, err)
+	_, err = svc.repo.GetPostMetaByID(kept)
+	assert.NoError(t, err)
+}
+
+func TestPurgeExpiredTrash_DropsLikesAndReactions(t *testing.T) {
+	cfg := config.Default()
+	cfg.TrashRetention = 24 * time.Hour
+	svc, now := newTestService(0, WithConfig(cfg))
+
+	post_id := createTestPost(t, svc, "alice", "gone")
+	kept := createTestPost(t, svc, "alice", "kept")
+	on_post, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, AuthorId: "bob", Comment: "Nice"})
+	assert.NoError(t, err)
+	on_kept, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: kept, AuthorId: "bob", Comment: "Nice"})
+	assert.NoError(t, err)
+
+	_, err = svc.LikePost(post_id, "bob")
+	assert.NoError(t, err)
+	_, err = svc.ReactToComment(on_post, "carol", "👍")
+	assert.NoError(t, err)
+	_, err = svc.ReactToComment(on_kept, "carol", "👍")
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.DeletePost(post_id, "alice"))
+	assert.NoError(t, svc.DeleteComment(on_kept, "bob"))
+
+	*now = now.Add(25 * time.Hour)
+	count, err := svc.PurgeExpiredTrash()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	likes, _ := svc.likes.CountLikes([]string{post_id})
+	assert.Equal(t, 0, likes[post_id])
+
+	reactions, _ := svc.likes.CountCommentReactions([]string{on_post, on_kept})
+	assert.Empty(t, reactions)
+}
+
+func TestCommentOnPost_ParentOnOtherPost(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	comment := models.CommentRequestDTO{
+		PostId:   "post123",
+		ParentId: "comment1",
+		Comment:  "Reply",
+		AuthorId: "user456",
+	}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{}, nil)
+	mockRepo.On("GetCommentByID", "comment1").Return(models.CommentDTO{Id: "comment1", PostId: "post999"}, nil)
+
+	_, err := svc.CommentOnPost(comment)
+
+	assert.EqualError(t, err, "error retrieving parent comment")
+	mockRepo.AssertNotCalled(t, "SaveComment", comment, mock.Anything)
+}
+
+func TestListPostComments_Threads(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	deletedAt := time.Now()
+	comments := []models.CommentDTO{
+		{Id: "c1", PostId: "post123", Content: "Root", Creator: "user1", DeletedAt: &deletedAt},
+		{Id: "c2", PostId: "post123", ParentId: "c1", Content: "Reply", Creator: "user2"},
+		{Id: "c3", PostId: "post123", ParentId: "c2", Content: "Nested reply", Creator: "user1"},
+		{Id: "c4", PostId: "post123", Content: "Trashed without replies", Creator: "user3", DeletedAt: &deletedAt},
+		{Id: "c5", PostId: "post123", Content: "Second root", Creator: "user3"},
+	}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123"}, nil)
+	mockRepo.On("GetPostComments", "post123").Return(comments, nil)
+
+	page, err := svc.ListPostComments("post123", "", models.CommentQueryDTO{MaxDepth: 2})
+
+	assert.NoError(t, err)
+	assert.Empty(t, page.NextCursor)
+
+	threads := page.Comments
+	assert.Len(t, threads, 2)
+
+	// The trashed parent stays as a placeholder for its replies
+	assert.Equal(t, "c1", threads[0].Id)
+	assert.True(t, threads[0].Deleted)
+	assert.Equal(t, "[deleted]", threads[0].Comment)
+	assert.Empty(t, threads[0].AuthorId)
+	assert.Equal(t, 1, threads[0].ReplyCount)
+
+	// Replies below the depth limit are only counted
+	reply := threads[0].Replies[0]
+	assert.Equal(t, "c2", reply.Id)
+	assert.Equal(t, 1, reply.ReplyCount)
+	assert.Empty(t, reply.Replies)
+
+	assert.Equal(t, "c5", threads[1].Id)
+	assert.Equal(t, 0, threads[1].ReplyCount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListPostComments_NewestFirstPagination(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	base := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	comments := []models.CommentDTO{
+		{Id: "c1", PostId: "post123", Content: "First", CreatedAt: base},
+		{Id: "c2", PostId: "post123", Content: "Second", CreatedAt: base.Add(time.Minute)},
+		{Id: "c3", PostId: "post123", Content: "Third", CreatedAt: base.Add(2 * time.Minute)},
+	}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123"}, nil)
+	mockRepo.On("GetPostComments", "post123").Return(comments, nil)
+
+	page, err := svc.ListPostComments("post123", "", models.CommentQueryDTO{Order: "newest", Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Comments, 2)
+	assert.Equal(t, "c3", page.Comments[0].Id)
+	assert.Equal(t, "c2", page.Comments[1].Id)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = svc.ListPostComments("post123", "", models.CommentQueryDTO{Order: "newest", Limit: 2, Cursor: page.NextCursor})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Comments, 1)
+	assert.Equal(t, "c1", page.Comments[0].Id)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestGetAllPosts_UsesConfiguredPreviewCount(t *testing.T) {
+	cfg := config.Default()
+	cfg.PreviewComments = 1
+	svc, _ := newTestService(time.Minute, WithConfig(cfg))
+
+	post_id := createTestPost(t, svc, "alice", "hello")
+	for _, content := range []string{"first", "second"} {
+		_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post_id, AuthorId: "bob", Comment: content})
+		assert.NoError(t, err)
+	}
+
+	posts, err := svc.GetAllPosts("")
+
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Len(t, posts[0].Comments, 1)
+}
+
+func TestEditComment_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	comment := models.CommentDTO{
+		Id:        "comment123",
+		Content:   "Nice psot!",
+		Creator:   "user456",
+		CreatedAt: time.Now().Add(-time.Minute),
+	}
+	edited := comment
+	edited.Content = "Nice post!"
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+	mockRepo.On("UpdateComment", "comment123", "Nice post!", []models.MentionDTO(nil), mock.Anything).Return(edited, nil)
+
+	result, err := svc.EditComment("comment123", "user456", "Nice post!")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Nice post!", result.Content)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEditComment_Unauthorized(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	comment := models.CommentDTO{Id: "comment123", Creator: "user789", CreatedAt: time.Now()}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+
+	_, err := svc.EditComment("comment123", "user456", "Edited")
+
+	assert.EqualError(t, err, "unauthorized")
+	mockRepo.AssertNotCalled(t, "UpdateComment", "comment123", "Edited", mock.Anything, mock.Anything)
+}
+
+func TestEditComment_WindowExpired(t *testing.T) {
+	mockRepo := new(MockRepository)
+	cfg := config.Default()
+	cfg.CommentEditWindow = 15 * time.Minute
+	svc := NewService(mockRepo, WithConfig(cfg))
+
+	comment := models.CommentDTO{Id: "comment123", Creator: "user456", CreatedAt: time.Now().Add(-time.Hour)}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+
+	_, err := svc.EditComment("comment123", "user456", "Edited")
+
+	assert.EqualError(t, err, "edit window expired")
+}
+
+func TestGetCommentRevisions_OnlyModerators(t *testing.T) {
+	t.Setenv("MODERATORS", "mod2, mod1")
+
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo, WithConfig(config.FromEnv()))
+
+	revisions := []models.CommentRevisionDTO{{CommentId: "comment123", Content: "Nice psot!"}}
+	mockRepo.On("GetCommentRevisions", "comment123").Return(revisions, nil)
+
+	_, err := svc.GetCommentRevisions("comment123", "user456")
+	assert.EqualError(t, err, "unauthorized")
+
+	result, err := svc.GetCommentRevisions("comment123", "mod1")
+	assert.NoError(t, err)
+	assert.Equal(t, revisions, result)
+}
+
+func TestDeleteComment_ByPostAuthor(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	comment := models.CommentDTO{Id: "comment123", PostId: "post123", Creator: "user789"}
+	postMeta := models.PostMetaDTO{Id: "post123", Creator: "user456"}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+	mockRepo.On("GetPostMetaByID", "post123").Return(postMeta, nil)
+	mockRepo.On("DeleteCommentByID", "comment123", "user456", mock.Anything).Return(nil)
+
+	err := svc.DeleteComment("comment123", "user456")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreComment_ModeratedByPostAuthor(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	deletedAt := time.Now()
+	comment := models.CommentDTO{
+		Id:        "comment123",
+		Creator:   "user789",
+		DeletedAt: &deletedAt,
+		DeletedBy: "user456",
+	}
+
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+
+	err := svc.RestoreComment("comment123", "user789")
+
+	assert.EqualError(t, err, "unauthorized")
+	mockRepo.AssertNotCalled(t, "RestoreCommentByID", "comment123")
+}
+
+func TestHideComment_OnlyPostAuthor(t *testing.T) {
+	mockRepo := new(MockRepository)
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewService(mockRepo, WithClock(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}))
+
+	var events []Event
+	svc.Subscribe(func(event Event) { events = append(events, event) })
+
+	comment := models.CommentDTO{Id: "comment123", PostId: "post123", Creator: "user789"}
+	postMeta := models.PostMetaDTO{Id: "post123", Creator: "user456"}
+
+	var hiddenAt time.Time
+	mockRepo.On("GetCommentByID", "comment123").Return(comment, nil)
+	mockRepo.On("GetPostMetaByID", "post123").Return(postMeta, nil)
+	mockRepo.On("SetCommentHidden", "comment123", mock.Anything).Run(func(args mock.Arguments) {
+		hiddenAt = *args.Get(1).(*time.Time)
+	}).Return(nil)
+
+	assert.EqualError(t, svc.HideComment("comment123", "user789"), "unauthorized")
+	assert.NoError(t, svc.HideComment("comment123", "user456"))
+	mockRepo.AssertNumberOfCalls(t, "SetCommentHidden", 1)
+
+	// The event carries the time the comment was hidden at
+	assert.Len(t, events, 1)
+	assert.Equal(t, hiddenAt, events[0].At)
+}
+
+func TestCommentOnPost_CommentPolicy(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	offPost := models.PostMetaDTO{Id: "post1", Creator: "user123", CommentPolicy: models.CommentPolicyOff}
+	followersPost := models.PostMetaDTO{Id: "post2", Creator: "user123", CommentPolicy: models.CommentPolicyFollowers}
+
+	mockRepo.On("GetPostMetaByID", "post1").Return(offPost, nil)
+	mockRepo.On("GetPostMetaByID", "post2").Return(followersPost, nil)
+	mockRepo.On("IsFollowing", "stranger", "user123").Return(false, nil)
+	mockRepo.On("IsFollowing", "follower", "user123").Return(true, nil)
+	mockRepo.On("SaveComment", mock.Anything, mock.Anything).Return("comment123", nil)
+
+	_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: "post1", Comment: "Hi", AuthorId: "user123"})
+	assert.EqualError(t, err, "comments disabled")
+
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: "post2", Comment: "Hi", AuthorId: "stranger"})
+	assert.EqualError(t, err, "comments limited to followers")
+
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: "post2", Comment: "Hi", AuthorId: "follower"})
+	assert.NoError(t, err)
+
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: "post2", Comment: "Hi", AuthorId: "user123"})
+	assert.NoError(t, err)
+}
+
+func TestUpdatePostSettings(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	postMeta := models.PostMetaDTO{Id: "post123", Creator: "user123", CommentPolicy: models.CommentPolicyEveryone}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(postMeta, nil)
+	mockRepo.On("SetPostSettings", "post123", models.CommentPolicyFollowers, "").Return(nil)
+
+	err := svc.UpdatePostSettings("post123", "user123", models.PostSettingsDTO{CommentPolicy: "nobody"})
+	assert.EqualError(t, err, "invalid comment policy")
+
+	err = svc.UpdatePostSettings("post123", "user456", models.PostSettingsDTO{CommentPolicy: models.CommentPolicyFollowers})
+	assert.EqualError(t, err, "unauthorized")
+
+	err = svc.UpdatePostSettings("post123", "user123", models.PostSettingsDTO{CommentPolicy: models.CommentPolicyFollowers})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFollowUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("IsFollowing", "user123", "user456").Return(false, nil)
+	mockRepo.On("FollowUser", "user123", "user456").Return(nil)
+
+	_, err := svc.FollowUser("user123", "user123")
+	assert.EqualError(t, err, "cannot follow yourself")
+
+	status, err := svc.FollowUser("user123", "user456")
+	assert.NoError(t, err)
+	assert.Equal(t, models.FollowStatusFollowing, status)
+	mockRepo.AssertNumberOfCalls(t, "FollowUser", 1)
+}
+
+func TestListFollowers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	followers := []models.FollowDTO{{UserId: "user456"}}
+	mockRepo.On("GetFollowers", "user123", "", 10).Return(followers, "next", nil)
+	mockRepo.On("GetFollowers", "user123", "bad", 10).Return([]models.FollowDTO(nil), "", errors.New("invalid cursor"))
+
+	page, err := svc.ListFollowers("user123", "", models.FollowQueryDTO{Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.FollowPageDTO{Users: followers, NextCursor: "next"}, page)
+
+	_, err = svc.ListFollowers("user123", "", models.FollowQueryDTO{Cursor: "bad", Limit: 10})
+	assert.EqualError(t, err, "invalid cursor")
+}
+
+func TestListFollows_PrivateAccount(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	mockRepo.On("GetFollowers", "private", "", 10).Return([]models.FollowDTO{{UserId: "follower"}}, "", nil)
+	mockRepo.On("GetFollowing", "private", "", 10).Return([]models.FollowDTO{}, "", nil)
+
+	for _, viewer := range []string{"private", "follower"} {
+		_, err := svc.ListFollowers("private", viewer, models.FollowQueryDTO{Limit: 10})
+		assert.NoError(t, err)
+		_, err = svc.ListFollowing("private", viewer, models.FollowQueryDTO{Limit: 10})
+		assert.NoError(t, err)
+	}
+
+	for _, viewer := range []string{"stranger", ""} {
+		_, err := svc.ListFollowers("private", viewer, models.FollowQueryDTO{Limit: 10})
+		assert.EqualError(t, err, "private account")
+		_, err = svc.ListFollowing("private", viewer, models.FollowQueryDTO{Limit: 10})
+		assert.EqualError(t, err, "private account")
+	}
+}
+
+func newPrivateAccountTest() (*Service, *MockRepository, *MockUserRepository) {
+	mockRepo := new(MockRepository)
+	mockUsers := new(MockUserRepository)
+	svc := NewService(mockRepo, WithUserRepository(mockUsers))
+
+	mockUsers.On("GetUserByID", "private").Return(models.User{Id: "private", Private: true}, nil)
+	mockUsers.On("GetUserByID", "public").Return(models.User{Id: "public"}, nil)
+	mockRepo.On("IsFollowing", "follower", "private").Return(true, nil)
+	mockRepo.On("IsFollowing", "stranger", "private").Return(false, nil)
+	withoutBlocks(mockRepo)
+
+	return svc, mockRepo, mockUsers
+}
+
+func TestGetPostById_PrivateAccount(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	testImg := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "private", ImageId: "img123"}, nil)
+	mockRepo.On("GetImageByID", "img123").Return(testImg, nil)
+
+	for _, viewer := range []string{"private", "follower"} {
+		img, _, err := svc.GetPostById("post123", viewer)
+		assert.NoError(t, err)
+		assert.Equal(t, testImg, img)
+	}
+
+	for _, viewer := range []string{"stranger", ""} {
+		img, _, err := svc.GetPostById("post123", viewer)
+		assert.EqualError(t, err, "error retrieving post")
+		assert.Nil(t, img)
+	}
+}
+
+func TestGetAllPosts_HidesPrivateAccounts(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	mockRepo.On("GetAllPostMetas").Return([]models.PostMetaDTO{
+		{Id: "post1", Creator: "private"},
+		{Id: "post2", Creator: "public"},
+		{Id: "post3", Creator: "private"},
+	}, nil)
+	mockRepo.On("GetPostLatestComments", mock.Anything, mock.Anything, mock.Anything).Return([]models.CommentDTO{}, nil)
+
+	posts, err := svc.GetAllPosts("stranger")
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "post2", posts[0].Id)
+
+	posts, err = svc.GetAllPosts("follower")
+	assert.NoError(t, err)
+	assert.Len(t, posts, 3)
+}
+
+func TestCommentOnPost_PrivateAccount(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "private", CommentPolicy: models.CommentPolicyEveryone}, nil)
+	mockRepo.On("GetPostComments", "post123").Return([]models.CommentDTO{}, nil)
+
+	_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: "post123", Comment: "Hi", AuthorId: "stranger"})
+	assert.EqualError(t, err, "error retrieving post")
+
+	_, err = svc.ListPostComments("post123", "stranger", models.CommentQueryDTO{})
+	assert.EqualError(t, err, "error retrieving post")
+
+	_, err = svc.ListPostComments("post123", "follower", models.CommentQueryDTO{})
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "SaveComment", mock.Anything, mock.Anything)
+}
+
+func TestFollowUser_PrivateAccountRequests(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	mockRepo.On("GetFollowRequest", "stranger", "private").Return(models.FollowRequestDTO{}, errors.New("follow request not found"))
+	mockRepo.On("SaveFollowRequest", mock.MatchedBy(func(r models.FollowRequestDTO) bool {
+		return r.RequesterId == "stranger" && r.TargetId == "private" && r.Status == models.FollowRequestPending
+	})).Return(nil)
+
+	status, err := svc.FollowUser("stranger", "private")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.FollowStatusRequested, status)
+	mockRepo.AssertNotCalled(t, "FollowUser", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "SaveFollowRequest", mock.Anything)
+}
+
+func TestApproveFollowRequest(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	pending := models.FollowRequestDTO{RequesterId: "stranger", TargetId: "private", Status: models.FollowRequestPending}
+	mockRepo.On("GetFollowRequest", "stranger", "private").Return(pending, nil)
+	mockRepo.On("GetFollowRequest", "nobody", "private").Return(models.FollowRequestDTO{}, errors.New("follow request not found"))
+	mockRepo.On("FollowUser", "stranger", "private").Return(nil)
+	mockRepo.On("SaveFollowRequest", mock.MatchedBy(func(r models.FollowRequestDTO) bool {
+		return r.Status == models.FollowRequestApproved && r.DecidedAt != nil
+	})).Return(nil)
+
+	var events []Event
+	svc.Subscribe(func(event Event) { events = append(events, event) })
+
+	err := svc.ApproveFollowRequest("private", "nobody")
+	assert.EqualError(t, err, "follow request not found")
+
+	err = svc.ApproveFollowRequest("private", "stranger")
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "FollowUser", "stranger", "private")
+
+	// Followers approved late get the same treatment as immediate ones
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventUserFollowed, events[0].Type)
+}
+
+func TestDenyFollowRequest(t *testing.T) {
+	svc, mockRepo, _ := newPrivateAccountTest()
+
+	pending := models.FollowRequestDTO{RequesterId: "stranger", TargetId: "private", Status: models.FollowRequestPending}
+	mockRepo.On("GetFollowRequest", "stranger", "private").Return(pending, nil)
+	mockRepo.On("SaveFollowRequest", mock.MatchedBy(func(r models.FollowRequestDTO) bool {
+		return r.Status == models.FollowRequestDenied
+	})).Return(nil)
+
+	err := svc.DenyFollowRequest("private", "stranger")
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "FollowUser", mock.Anything, mock.Anything)
+}
+
+func TestBlockUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("BlockUser", "user1", "user2").Return(nil)
+	mockRepo.On("DeleteFollowRequest", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UnfollowUser", mock.Anything, mock.Anything).Return(nil)
+
+	err := svc.BlockUser("user1", "user1")
+	assert.EqualError(t, err, "cannot block yourself")
+
+	err = svc.BlockUser("user1", "user2")
+	assert.NoError(t, err)
+
+	// Blocking cuts the follows both ways
+	mockRepo.AssertCalled(t, "UnfollowUser", "user1", "user2")
+	mockRepo.AssertCalled(t, "UnfollowUser", "user2", "user1")
+	mockRepo.AssertCalled(t, "DeleteFollowRequest", "user2", "user1")
+}
+
+func TestFollowUser_Blocked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("IsFollowing", "user2", "user1").Return(false, nil)
+	mockRepo.On("IsBlocked", "user2", "user1").Return(false, nil)
+	mockRepo.On("IsBlocked", "user1", "user2").Return(true, nil)
+
+	_, err := svc.FollowUser("user2", "user1")
+
+	assert.EqualError(t, err, "blocked")
+	mockRepo.AssertNotCalled(t, "FollowUser", mock.Anything, mock.Anything)
+}
+
+func TestCommentOnPost_Blocked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "user1"}, nil)
+	mockRepo.On("IsBlocked", "user2", "user1").Return(false, nil)
+	mockRepo.On("IsBlocked", "user1", "user2").Return(true, nil)
+
+	_, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: "post123", Comment: "Hi", AuthorId: "user2"})
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "SaveComment", mock.Anything, mock.Anything)
+}
+
+func TestGetAllPosts_HidesBlockedAndMutedUsers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("GetAllPostMetas").Return([]models.PostMetaDTO{
+		{Id: "post1", Creator: "blocker"},
+		{Id: "post2", Creator: "muted"},
+		{Id: "post3", Creator: "friend"},
+	}, nil)
+	mockRepo.On("GetBlocks", "viewer").Return([]string{}, []string{"blocker"}, nil)
+	mockRepo.On("GetMutedUsers", "viewer").Return([]string{"muted"}, nil)
+	mockRepo.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("GetPostLatestComments", "post3", 2, []string{"blocker", "muted"}).Return([]models.CommentDTO{}, nil)
+
+	posts, err := svc.GetAllPosts("viewer")
+
+	assert.NoError(t, err)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "post3", posts[0].Id)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListPostComments_HidesMutedUsers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	comments := []models.CommentDTO{
+		{Id: "c1", PostId: "post123", Content: "Root", Creator: "muted"},
+		{Id: "c2", PostId: "post123", ParentId: "c1", Content: "Reply", Creator: "user1"},
+		{Id: "c3", PostId: "post123", Content: "Muted without replies", Creator: "muted"},
+	}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "user1"}, nil)
+	mockRepo.On("GetPostComments", "post123").Return(comments, nil)
+	mockRepo.On("IsBlocked", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("GetBlocks", "viewer").Return([]string{}, []string{}, nil)
+	mockRepo.On("GetMutedUsers", "viewer").Return([]string{"muted"}, nil)
+
+	page, err := svc.ListPostComments("post123", "viewer", models.CommentQueryDTO{})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Comments, 1)
+	assert.Equal(t, "[hidden]", page.Comments[0].Comment)
+	assert.Empty(t, page.Comments[0].AuthorId)
+	assert.Equal(t, "c2", page.Comments[0].Replies[0].Id)
+}
+
+func TestMuteUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("MuteUser", "user1", "user2").Return(nil)
+
+	err := svc.MuteUser("user1", "user1")
+	assert.EqualError(t, err, "cannot mute yourself")
+
+	err = svc.MuteUser("user1", "user2")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePost_Visibility(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	testImg := image.NewRGBA(image.Rect(0, 0, 100, 100))
+
+	_, err := svc.CreatePost(testImg, models.PostRequestDTO{AuthorId: "user123", Visibility: "friends"})
+	assert.EqualError(t, err, "invalid visibility")
+	mockRepo.AssertNotCalled(t, "SaveImage", mock.Anything)
+
+	mockRepo.On("SaveImage", testImg).Return("img123", nil)
+	mockRepo.On("SavePostMeta", mock.MatchedBy(func(p models.PostMetaDTO) bool {
+		return p.Visibility == models.VisibilityPublic
+	})).Return("post1", nil).Once()
+	mockRepo.On("SavePostMeta", mock.MatchedBy(func(p models.PostMetaDTO) bool {
+		return p.Visibility == models.VisibilityCloseFriends
+	})).Return("post2", nil).Once()
+	mockRepo.On("MarkPostAnnounced", mock.Anything).Return(nil)
+
+	// Posts are public unless the author says otherwise
+	postID, err := svc.CreatePost(testImg, models.PostRequestDTO{AuthorId: "user123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "post1", postID)
+
+	postID, err = svc.CreatePost(testImg, models.PostRequestDTO{AuthorId: "user123", Visibility: models.VisibilityCloseFriends})
+	assert.NoError(t, err)
+	assert.Equal(t, "post2", postID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdatePostSettings_Visibility(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	postMeta := models.PostMetaDTO{Id: "post123", Creator: "user123", CommentPolicy: models.CommentPolicyOff, Visibility: models.VisibilityPublic}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(postMeta, nil)
+	mockRepo.On("SetPostSettings", "post123", "", models.VisibilityOnlyMe).Return(nil)
+
+	err := svc.UpdatePostSettings("post123", "user123", models.PostSettingsDTO{Visibility: "nobody"})
+	assert.EqualError(t, err, "invalid visibility")
+
+	// Settings left out keep their value
+	err = svc.UpdatePostSettings("post123", "user123", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPostById_Visibility(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	testImg := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	posts := map[string]string{
+		"public":        models.VisibilityPublic,
+		"followers":     models.VisibilityFollowers,
+		"close_friends": models.VisibilityCloseFriends,
+		"only_me":       models.VisibilityOnlyMe,
+	}
+	for post_id, visibility := range posts {
+		mockRepo.On("GetPostMetaByID", post_id).Return(models.PostMetaDTO{Id: post_id, Creator: "author", ImageId: "img123", Visibility: visibility}, nil)
+	}
+	mockRepo.On("GetImageByID", "img123").Return(testImg, nil)
+	mockRepo.On("IsFollowing", "follower", "author").Return(true, nil)
+	mockRepo.On("IsFollowing", "friend", "author").Return(false, nil)
+	mockRepo.On("IsCloseFriend", "author", "follower").Return(false, nil)
+	mockRepo.On("IsCloseFriend", "author", "friend").Return(true, nil)
+
+	visible := map[string][]string{
+		"":         {"public"},
+		"follower": {"public", "followers"},
+		"friend":   {"public", "close_friends"},
+		"author":   {"public", "followers", "close_friends", "only_me"},
+	}
+	for viewer, allowed := range visible {
+		for post_id := range posts {
+			_, _, err := svc.GetPostById(post_id, viewer)
+			if slices.Contains(allowed, post_id) {
+				assert.NoError(t, err, "%s viewing %s", viewer, post_id)
+			} else {
+				assert.EqualError(t, err, "error retrieving post", "%s viewing %s", viewer, post_id)
+			}
+		}
+	}
+}
+
+func TestLikePost(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "author"}, nil)
+	mockRepo.On("GetPostMetaByID", "hidden").Return(models.PostMetaDTO{Id: "hidden", Creator: "author", Visibility: models.VisibilityOnlyMe}, nil)
+
+	// Liking twice counts once
+	for i := 0; i < 2; i++ {
+		summary, err := svc.LikePost("post123", "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, models.LikeSummaryDTO{LikeCount: 1, LikedByMe: true}, summary)
+	}
+
+	_, err := svc.LikePost("post123", "user2")
+	assert.NoError(t, err)
+
+	_, err = svc.LikePost("hidden", "user1")
+	assert.EqualError(t, err, "error retrieving post")
+
+	summaries, err := svc.GetLikeSummaries([]string{"post123", "other"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LikeSummaryDTO{LikeCount: 2}, summaries["post123"])
+	assert.Equal(t, models.LikeSummaryDTO{}, summaries["other"])
+
+	summary, err := svc.UnlikePost("post123", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LikeSummaryDTO{LikeCount: 1}, summary)
+
+	page, err := svc.ListPostLikes("post123", "", models.LikeQueryDTO{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Likes, 1)
+	assert.Equal(t, "user2", page.Likes[0].UserId)
+}
+
+func TestReactToComment(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	hiddenAt := time.Now()
+	mockRepo.On("GetCommentByID", "comment1").Return(models.CommentDTO{Id: "comment1", PostId: "post123", Creator: "author"}, nil)
+	mockRepo.On("GetCommentByID", "hidden").Return(models.CommentDTO{Id: "hidden", PostId: "post123", Creator: "author", HiddenAt: &hiddenAt}, nil)
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "author"}, nil)
+
+	_, err := svc.ReactToComment("comment1", "user1", "🍕")
+	assert.EqualError(t, err, "invalid reaction")
+
+	_, err = svc.ReactToComment("hidden", "user1", "👍")
+	assert.EqualError(t, err, "error retrieving comment")
+
+	_, err = svc.ReactToComment("comment1", "user2", "👍")
+	assert.NoError(t, err)
+
+	reactions, err := svc.ReactToComment("comment1", "user1", "😂")
+	assert.NoError(t, err)
+	assert.Equal(t, models.CommentReactionsDTO{Reactions: map[string]int{"👍": 1, "😂": 1}, MyReaction: "😂"}, reactions)
+
+	// Reacting again replaces the previous reaction
+	reactions, err = svc.ReactToComment("comment1", "user1", "👍")
+	assert.NoError(t, err)
+	assert.Equal(t, models.CommentReactionsDTO{Reactions: map[string]int{"👍": 2}, MyReaction: "👍"}, reactions)
+
+	reactions, err = svc.RemoveCommentReaction("comment1", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.CommentReactionsDTO{Reactions: map[string]int{"👍": 1}}, reactions)
+}
+
+func TestListPostComments_TopOrder(t *testing.T) {
+	mockRepo := new(MockRepository)
+	withoutBlocks(mockRepo)
+	svc := NewService(mockRepo)
+
+	base := time.Now()
+	comments := []models.CommentDTO{
+		{Id: "c1", PostId: "post123", Content: "Quiet", Creator: "user1", CreatedAt: base},
+		{Id: "c2", PostId: "post123", Content: "Popular", Creator: "user2", CreatedAt: base.Add(time.Second)},
+		{Id: "c3", PostId: "post123", Content: "Liked once", Creator: "user3", CreatedAt: base.Add(2 * time.Second)},
+		{Id: "c4", PostId: "post123", ParentId: "c1", Content: "Reply", Creator: "user2", CreatedAt: base.Add(3 * time.Second)},
+	}
+
+	mockRepo.On("GetPostMetaByID", "post123").Return(models.PostMetaDTO{Id: "post123", Creator: "author"}, nil)
+	mockRepo.On("GetPostComments", "post123").Return(comments, nil)
+	for _, comment := range comments {
+		mockRepo.On("GetCommentByID", comment.Id).Return(comment, nil)
+	}
+
+	for _, reaction := range []struct{ comment, user, emoji string }{
+		{"c2", "user1", "❤️"}, {"c2", "user3", "😂"}, {"c3", "viewer", "👍"}, {"c4", "user3", "👍"},
+	} {
+		_, err := svc.ReactToComment(reaction.comment, reaction.user, reaction.emoji)
+		assert.NoError(t, err)
+	}
+
+	page, err := svc.ListPostComments("post123", "viewer", models.CommentQueryDTO{Order: "top", Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Comments, 2)
+	assert.Equal(t, "c2", page.Comments[0].Id)
+	assert.Equal(t, map[string]int{"❤️": 1, "😂": 1}, page.Comments[0].Reactions)
+	assert.Equal(t, "c3", page.Comments[1].Id)
+	assert.Equal(t, "👍", page.Comments[1].MyReaction)
+
+	// Reactions on replies don't lift their thread
+	page, err = svc.ListPostComments("post123", "viewer", models.CommentQueryDTO{Order: "top", Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, page.Comments, 1)
+	assert.Equal(t, "c1", page.Comments[0].Id)
+	assert.Equal(t, map[string]int{"👍": 1}, page.Comments[0].Replies[0].Reactions)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestPublishDuePosts_ContinuesPastFailures(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+
+	due := []models.PostMetaDTO{{Id: "post1"}, {Id: "post2"}}
+	mockRepo.On("GetDueScheduledPosts", mock.Anything).Return(due, nil)
+	mockRepo.On("PublishPost", "post1", mock.Anything).Return(false, errors.New("post metadata not found"))
+	mockRepo.On("PublishPost", "post2", mock.Anything).Return(true, nil)
+	mockRepo.On("GetPostMetaByID", "post2").Return(models.PostMetaDTO{Id: "post2", Creator: "user123", Unannounced: true}, nil)
+	mockRepo.On("MarkPostAnnounced", "post2").Return(nil)
+	mockRepo.On("GetUnannouncedPosts", mock.Anything).Return([]models.PostMetaDTO{}, nil)
+
+	published, err := svc.PublishDuePosts()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	mockRepo.AssertExpectations(t)
+}
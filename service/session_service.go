@@ -0,0 +1,20 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type ISessionService interface {
+	// Start a session for a user who just logged in on a device
+	CreateSession(user_id, device string) (tokens models.TokenPairDTO, err error)
+
+	// Exchange a refresh token for a new token pair, rotating the refresh token
+	Refresh(refresh_token string) (tokens models.TokenPairDTO, err error)
+
+	// List the active sessions of a user
+	ListSessions(user_id string) (sessions []models.Session, err error)
+
+	// Log a device out; Only the session's user would be able to revoke it
+	RevokeSession(user_id, session_id string) (err error)
+
+	// Check whether a session is still active
+	IsSessionActive(session_id string) bool
+}
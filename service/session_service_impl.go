@@ -0,0 +1,176 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/anandh86/instagram/auth"
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+)
+
+type SessionService struct {
+	sessions repository.ISessionRepository
+	keys     *auth.KeySet
+	config   config.Config
+	now      func() time.Time
+}
+
+func NewSessionService(sessions repository.ISessionRepository, keys *auth.KeySet, cfg config.Config) *SessionService {
+
+	// compile-time check to ensure we implement the interface
+	var _ ISessionService = (*SessionService)(nil)
+
+	return &SessionService{
+		sessions: sessions,
+		keys:     keys,
+		config:   cfg,
+		now:      time.Now,
+	}
+}
+
+func (s *SessionService) CreateSession(user_id, device string) (tokens models.TokenPairDTO, err error) {
+	secret, err := newRefreshSecret()
+
+	if err != nil {
+		return models.TokenPairDTO{}, errors.New("error creating session")
+	}
+
+	now := s.now()
+	session := models.Session{
+		UserId:           user_id,
+		Device:           device,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(s.config.RefreshTokenTTL),
+		RefreshTokenHash: hashRefreshSecret(secret),
+	}
+
+	session.Id, err = s.sessions.SaveSession(session)
+
+	if err != nil {
+		return models.TokenPairDTO{}, errors.New("error creating session")
+	}
+
+	return s.issueTokens(session, secret)
+}
+
+func (s *SessionService) Refresh(refresh_token string) (tokens models.TokenPairDTO, err error) {
+	// Refresh tokens read "<session id>.<secret>"
+	session_id, secret, found := strings.Cut(refresh_token, ".")
+
+	if !found {
+		return models.TokenPairDTO{}, errors.New("invalid token")
+	}
+
+	session, err := s.sessions.GetSessionByID(session_id)
+
+	if err != nil || session.RevokedAt != nil || !s.now().Before(session.ExpiresAt) {
+		return models.TokenPairDTO{}, errors.New("invalid token")
+	}
+
+	new_secret, err := newRefreshSecret()
+
+	if err != nil {
+		return models.TokenPairDTO{}, errors.New("error refreshing session")
+	}
+
+	now := s.now()
+	err = s.sessions.RotateRefreshToken(session_id, hashRefreshSecret(secret), hashRefreshSecret(new_secret), now, now.Add(s.config.RefreshTokenTTL))
+
+	if err != nil {
+		if err.Error() == "token reused" {
+			// A replaced token was presented again: either the client or an
+			// attacker holds a stolen copy, so end the whole token family
+			if revokeErr := s.sessions.RevokeSession(session_id, now); revokeErr != nil {
+				log.Printf("revoking session %s after token reuse: %v", session_id, revokeErr)
+			}
+			return models.TokenPairDTO{}, err
+		}
+		return models.TokenPairDTO{}, errors.New("invalid token")
+	}
+
+	session.ExpiresAt = now.Add(s.config.RefreshTokenTTL)
+
+	return s.issueTokens(session, new_secret)
+}
+
+func (s *SessionService) ListSessions(user_id string) (sessions []models.Session, err error) {
+	all, err := s.sessions.GetUserSessions(user_id)
+
+	if err != nil {
+		return nil, errors.New("error retrieving sessions")
+	}
+
+	sessions = []models.Session{}
+	for _, session := range all {
+		if s.isActive(session) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionService) RevokeSession(user_id, session_id string) (err error) {
+	session, err := s.sessions.GetSessionByID(session_id)
+
+	if err != nil {
+		return errors.New("error retrieving session")
+	}
+
+	if session.UserId != user_id {
+		return errors.New("unauthorized")
+	}
+
+	return s.sessions.RevokeSession(session_id, s.now())
+}
+
+func (s *SessionService) IsSessionActive(session_id string) bool {
+	session, err := s.sessions.GetSessionByID(session_id)
+	return err == nil && s.isActive(session)
+}
+
+func (s *SessionService) isActive(session models.Session) bool {
+	return session.RevokedAt == nil && s.now().Before(session.ExpiresAt)
+}
+
+// issueTokens signs an access token for the session and pairs it with the
+// session's current refresh token
+func (s *SessionService) issueTokens(session models.Session, secret string) (models.TokenPairDTO, error) {
+	accessToken, claims, err := s.keys.Issue(session.UserId, session.Id, s.config.AccessTokenTTL)
+
+	if err != nil {
+		return models.TokenPairDTO{}, errors.New("error issuing token")
+	}
+
+	return models.TokenPairDTO{
+		SessionId:    session.Id,
+		AccessToken:  accessToken,
+		RefreshToken: session.Id + "." + secret,
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Unix(claims.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+func newRefreshSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Only hashes of refresh tokens are stored, so a leaked store can't be used
+// to refresh sessions
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
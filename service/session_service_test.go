@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/auth"
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock repository implementing ISessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) SaveSession(session models.Session) (string, error) {
+	args := m.Called(session)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetSessionByID(sessionID string) (models.Session, error) {
+	args := m.Called(sessionID)
+	return args.Get(0).(models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetUserSessions(userID string) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) RotateRefreshToken(sessionID, oldHash, newHash string, usedAt, expiresAt time.Time) error {
+	args := m.Called(sessionID, oldHash, newHash, usedAt, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeSession(sessionID string, revokedAt time.Time) error {
+	args := m.Called(sessionID, revokedAt)
+	return args.Error(0)
+}
+
+func testKeySet(t *testing.T) *auth.KeySet {
+	keys := auth.NewKeySet()
+	assert.NoError(t, keys.AddHMACKey("test", []byte("0123456789abcdef0123456789abcdef")))
+	assert.NoError(t, keys.SetSigningKey("test"))
+	return keys
+}
+
+func TestCreateSession(t *testing.T) {
+	mockSessions := new(MockSessionRepository)
+	keys := testKeySet(t)
+	svc := NewSessionService(mockSessions, keys, config.Default())
+
+	mockSessions.On("SaveSession", mock.MatchedBy(func(s models.Session) bool {
+		return s.UserId == "user123" && s.Device == "phone" && s.RefreshTokenHash != ""
+	})).Return("session1", nil)
+
+	tokens, err := svc.CreateSession("user123", "phone")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "session1", tokens.SessionId)
+	assert.True(t, strings.HasPrefix(tokens.RefreshToken, "session1."))
+
+	claims, err := keys.Verify(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", claims.Subject)
+	assert.Equal(t, "session1", claims.SessionId)
+}
+
+func TestRefresh_ReuseRevokesSession(t *testing.T) {
+	mockSessions := new(MockSessionRepository)
+	svc := NewSessionService(mockSessions, testKeySet(t), config.Default())
+
+	session := models.Session{Id: "session1", UserId: "user123", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockSessions.On("GetSessionByID", "session1").Return(session, nil)
+	mockSessions.On("RotateRefreshToken", "session1", hashRefreshSecret("stolen"), mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("token reused"))
+	mockSessions.On("RevokeSession", "session1", mock.Anything).Return(nil)
+
+	_, err := svc.Refresh("session1.stolen")
+
+	assert.EqualError(t, err, "token reused")
+	mockSessions.AssertExpectations(t)
+}
+
+func TestRefresh_Rotates(t *testing.T) {
+	mockSessions := new(MockSessionRepository)
+	svc := NewSessionService(mockSessions, testKeySet(t), config.Default())
+
+	session := models.Session{Id: "session1", UserId: "user123", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockSessions.On("GetSessionByID", "session1").Return(session, nil)
+	mockSessions.On("RotateRefreshToken", "session1", hashRefreshSecret("current"), mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	tokens, err := svc.Refresh("session1.current")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "session1.current", tokens.RefreshToken)
+	assert.True(t, strings.HasPrefix(tokens.RefreshToken, "session1."))
+	mockSessions.AssertNotCalled(t, "RevokeSession", "session1", mock.Anything)
+}
+
+func TestRefresh_ExpiredSession(t *testing.T) {
+	mockSessions := new(MockSessionRepository)
+	svc := NewSessionService(mockSessions, testKeySet(t), config.Default())
+
+	session := models.Session{Id: "session1", UserId: "user123", ExpiresAt: time.Now().Add(-time.Minute)}
+	mockSessions.On("GetSessionByID", "session1").Return(session, nil)
+
+	_, err := svc.Refresh("session1.current")
+
+	assert.EqualError(t, err, "invalid token")
+}
+
+func TestRevokeSession_OtherUser(t *testing.T) {
+	mockSessions := new(MockSessionRepository)
+	svc := NewSessionService(mockSessions, testKeySet(t), config.Default())
+
+	mockSessions.On("GetSessionByID", "session1").Return(models.Session{Id: "session1", UserId: "user123"}, nil)
+
+	err := svc.RevokeSession("user456", "session1")
+
+	assert.EqualError(t, err, "unauthorized")
+	mockSessions.AssertNotCalled(t, "RevokeSession", "session1", mock.Anything)
+}
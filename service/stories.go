@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"image"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+)
+
+func (s *Service) CreateStory(story_img image.Image, author_id string) (story_id string, err error) {
+	img_id, err := s.repo.SaveImage(story_img)
+
+	if err != nil {
+		return "", errors.New("error saving image")
+	}
+
+	now := s.now()
+
+	story_id, err = s.stories.SaveStory(models.StoryDTO{
+		AuthorId:  author_id,
+		ImageId:   img_id,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.StoryLifetime),
+	})
+
+	if err != nil {
+		return "", errors.New("error saving story")
+	}
+
+	return story_id, nil
+}
+
+func (s *Service) ViewStory(story_id, viewer_id string) (story_img image.Image, story models.StoryDTO, err error) {
+	story, err = s.getViewableStory(story_id, viewer_id)
+
+	if err != nil {
+		return nil, models.StoryDTO{}, err
+	}
+
+	story_img, err = s.repo.GetImageByID(story.ImageId)
+
+	if err != nil {
+		return nil, models.StoryDTO{}, errors.New("error retrieving image")
+	}
+
+	// Authors looking at their own stories don't count as viewers
+	if viewer_id != story.AuthorId {
+		if _, err := s.stories.AddStoryView(story.Id, viewer_id, s.now()); err != nil {
+			return nil, models.StoryDTO{}, errors.New("error recording view")
+		}
+	}
+
+	return story_img, story, nil
+}
+
+func (s *Service) ListStoryViewers(story_id, author_id string, query models.StoryViewQueryDTO) (page models.StoryViewPageDTO, err error) {
+	story, err := s.getOwnedStory(story_id, author_id)
+
+	if err != nil {
+		return models.StoryViewPageDTO{}, err
+	}
+
+	views, next, err := s.stories.GetStoryViews(story.Id, query.Cursor, query.Limit)
+
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return models.StoryViewPageDTO{}, err
+		}
+		return models.StoryViewPageDTO{}, errors.New("error retrieving views")
+	}
+
+	counts, err := s.stories.CountStoryViews([]string{story.Id})
+
+	if err != nil {
+		return models.StoryViewPageDTO{}, errors.New("error retrieving views")
+	}
+
+	return models.StoryViewPageDTO{Views: views, ViewCount: counts[story.Id], NextCursor: next}, nil
+}
+
+func (s *Service) DeleteStory(story_id, author_id string) (err error) {
+	story, err := s.getOwnedStory(story_id, author_id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.stories.DeleteStory(story.Id); err != nil {
+		return errors.New("error deleting story")
+	}
+
+	// The image only served the story
+	s.repo.DeleteImageByID(story.ImageId)
+
+	return nil
+}
+
+func (s *Service) GetStoriesTray(viewer_id string) (tray models.StoryTrayDTO, err error) {
+	hiddenList, err := hiddenUsers(s.repo, viewer_id)
+
+	if err != nil {
+		return models.StoryTrayDTO{}, errors.New("error retrieving stories")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	authors := []string{viewer_id}
+
+	err = eachFollow(s.repo.GetFollowing, viewer_id, func(followee_id string) error {
+		if !hidden[followee_id] {
+			authors = append(authors, followee_id)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return models.StoryTrayDTO{}, errors.New("error retrieving stories")
+	}
+
+	now := s.now()
+	tray.Tray = []models.StoryTrayEntryDTO{}
+
+	for _, author_id := range authors {
+		entry, err := s.trayEntry(author_id, viewer_id, now)
+
+		if err != nil {
+			return models.StoryTrayDTO{}, errors.New("error retrieving stories")
+		}
+
+		if len(entry.Stories) > 0 {
+			tray.Tray = append(tray.Tray, entry)
+		}
+	}
+
+	// The viewer's own stories come first, then the authors with stories
+	// the viewer has not seen yet, most recently posted first
+	sort.SliceStable(tray.Tray, func(i, j int) bool {
+		a, b := tray.Tray[i], tray.Tray[j]
+		if (a.AuthorId == viewer_id) != (b.AuthorId == viewer_id) {
+			return a.AuthorId == viewer_id
+		}
+		if a.HasUnseen != b.HasUnseen {
+			return a.HasUnseen
+		}
+		return a.LatestAt.After(b.LatestAt)
+	})
+
+	return tray, nil
+}
+
+// trayEntry gathers the live stories of an author for the stories tray
+func (s *Service) trayEntry(author_id, viewer_id string, now time.Time) (models.StoryTrayEntryDTO, error) {
+	stories, err := s.stories.GetLiveStories(author_id, now)
+
+	if err != nil || len(stories) == 0 {
+		return models.StoryTrayEntryDTO{}, err
+	}
+
+	story_ids := make([]string, 0, len(stories))
+	for _, story := range stories {
+		story_ids = append(story_ids, story.Id)
+	}
+
+	seen, err := s.stories.GetViewedStories(viewer_id, story_ids)
+
+	if err != nil {
+		return models.StoryTrayEntryDTO{}, err
+	}
+
+	var counts map[string]int
+	if author_id == viewer_id {
+		if counts, err = s.stories.CountStoryViews(story_ids); err != nil {
+			return models.StoryTrayEntryDTO{}, err
+		}
+	}
+
+	entry := models.StoryTrayEntryDTO{AuthorId: author_id}
+
+	for _, story := range stories {
+		response := models.StoryResponseDTO{
+			Id:        story.Id,
+			ImageId:   story.ImageId,
+			CreatedAt: story.CreatedAt,
+			ExpiresAt: story.ExpiresAt,
+			Seen:      author_id == viewer_id || seen[story.Id],
+		}
+
+		if counts != nil {
+			count := counts[story.Id]
+			response.ViewCount = &count
+		}
+
+		entry.HasUnseen = entry.HasUnseen || !response.Seen
+		entry.LatestAt = story.CreatedAt
+		entry.Stories = append(entry.Stories, response)
+	}
+
+	return entry, nil
+}
+
+// ExpireStories deletes the stories whose lifetime is over, along with their
+// images and views
+func (s *Service) ExpireStories() (expired int, err error) {
+	stories, err := s.stories.DeleteExpiredStories(s.now())
+
+	if err != nil {
+		return 0, errors.New("error deleting stories")
+	}
+
+	for _, story := range stories {
+		s.repo.DeleteImageByID(story.ImageId)
+	}
+
+	return len(stories), nil
+}
+
+// RunStorySweeper deletes the expired stories periodically until ctx is
+// cancelled
+func (s *Service) RunStorySweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.config.StorySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if expired, err := s.ExpireStories(); err != nil {
+				log.Printf("story sweeper: %v", err)
+			} else if expired > 0 {
+				log.Printf("story sweeper: deleted %d stories", expired)
+			}
+		}
+	}
+}
+
+// getViewableStory retrieves a live story and checks that the viewer can see
+// it: stories are shown to their author and the author's followers, unless
+// either of them blocks the other
+func (s *Service) getViewableStory(story_id, viewer_id string) (models.StoryDTO, error) {
+	story, err := s.stories.GetStory(story_id)
+
+	if err != nil || !story.ExpiresAt.After(s.now()) {
+		return models.StoryDTO{}, errors.New("error retrieving story")
+	}
+
+	if viewer_id == story.AuthorId {
+		return story, nil
+	}
+
+	if viewer_id == "" {
+		return models.StoryDTO{}, errors.New("error retrieving story")
+	}
+
+	following, err := s.repo.IsFollowing(viewer_id, story.AuthorId)
+
+	if err != nil || !following {
+		return models.StoryDTO{}, errors.New("error retrieving story")
+	}
+
+	blocked, err := s.eitherBlocks(viewer_id, story.AuthorId)
+
+	if err != nil || blocked {
+		return models.StoryDTO{}, errors.New("error retrieving story")
+	}
+
+	return story, nil
+}
+
+// getOwnedStory retrieves a live story and checks that it was posted by
+// author_id
+func (s *Service) getOwnedStory(story_id, author_id string) (models.StoryDTO, error) {
+	story, err := s.stories.GetStory(story_id)
+
+	if err != nil || !story.ExpiresAt.After(s.now()) {
+		return models.StoryDTO{}, errors.New("error retrieving story")
+	}
+
+	if story.AuthorId != author_id {
+		return models.StoryDTO{}, errors.New("unauthorized")
+	}
+
+	return story, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestStory(t *testing.T, svc *Service, author_id string) string {
+	story_id, err := svc.CreateStory(image.NewRGBA(image.Rect(0, 0, 1, 1)), author_id)
+	assert.NoError(t, err)
+	return story_id
+}
+
+func trayAuthors(t *testing.T, svc *Service, viewer_id string) []string {
+	tray, err := svc.GetStoriesTray(viewer_id)
+	assert.NoError(t, err)
+
+	authors := []string{}
+	for _, entry := range tray.Tray {
+		authors = append(authors, entry.AuthorId)
+	}
+	return authors
+}
+
+func TestStories_VisibleToFollowers(t *testing.T) {
+	svc, _ := newTestService(0)
+
+	story_id := createTestStory(t, svc, "alice")
+
+	_, story, err := svc.ViewStory(story_id, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", story.AuthorId)
+
+	for _, viewer_id := range []string{"bob", ""} {
+		_, _, err = svc.ViewStory(story_id, viewer_id)
+		assert.EqualError(t, err, "error retrieving story")
+	}
+
+	_, err = svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	_, _, err = svc.ViewStory(story_id, "bob")
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.BlockUser("alice", "bob"))
+	_, _, err = svc.ViewStory(story_id, "bob")
+	assert.EqualError(t, err, "error retrieving story")
+}
+
+func TestStories_Viewers(t *testing.T) {
+	svc, now := newTestService(0)
+
+	story_id := createTestStory(t, svc, "alice")
+
+	for _, viewer_id := range []string{"bob", "carol", "bob", "alice"} {
+		_, err := svc.FollowUser(viewer_id, "alice")
+		if viewer_id != "alice" {
+			assert.NoError(t, err)
+		}
+		*now = now.Add(time.Minute)
+		_, _, err = svc.ViewStory(story_id, viewer_id)
+		assert.NoError(t, err)
+	}
+
+	// Views are counted once per viewer, and the author's own aren't counted
+	page, err := svc.ListStoryViewers(story_id, "alice", models.StoryViewQueryDTO{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.ViewCount)
+	assert.Equal(t, "carol", page.Views[0].UserId)
+	assert.Equal(t, "bob", page.Views[1].UserId)
+
+	_, err = svc.ListStoryViewers(story_id, "bob", models.StoryViewQueryDTO{})
+	assert.EqualError(t, err, "unauthorized")
+
+	_, err = svc.ListStoryViewers(story_id, "alice", models.StoryViewQueryDTO{Cursor: "garbage"})
+	assert.EqualError(t, err, "invalid cursor")
+
+	assert.EqualError(t, svc.DeleteStory(story_id, "bob"), "unauthorized")
+	assert.NoError(t, svc.DeleteStory(story_id, "alice"))
+	_, _, err = svc.ViewStory(story_id, "alice")
+	assert.EqualError(t, err, "error retrieving story")
+}
+
+func TestStories_TrayOrdersUnseenFirst(t *testing.T) {
+	svc, now := newTestService(0)
+
+	for _, followee_id := range []string{"alice", "carol", "dave", "erin"} {
+		_, err := svc.FollowUser("bob", followee_id)
+		assert.NoError(t, err)
+	}
+
+	alice := createTestStory(t, svc, "alice")
+	*now = now.Add(time.Minute)
+	createTestStory(t, svc, "carol")
+	*now = now.Add(time.Minute)
+	dave := createTestStory(t, svc, "dave")
+	*now = now.Add(time.Minute)
+	createTestStory(t, svc, "bob")
+	createTestStory(t, svc, "frank")
+
+	// Own stories first, then the most recent
+	assert.Equal(t, []string{"bob", "dave", "carol", "alice"}, trayAuthors(t, svc, "bob"))
+
+	// Authors whose stories were all seen move to the back
+	_, _, err := svc.ViewStory(dave, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "carol", "alice", "dave"}, trayAuthors(t, svc, "bob"))
+
+	// A new story makes an author unseen again
+	_, _, err = svc.ViewStory(alice, "bob")
+	assert.NoError(t, err)
+	*now = now.Add(time.Minute)
+	createTestStory(t, svc, "dave")
+	assert.Equal(t, []string{"bob", "dave", "carol", "alice"}, trayAuthors(t, svc, "bob"))
+
+	tray, _ := svc.GetStoriesTray("bob")
+	assert.True(t, tray.Tray[1].HasUnseen)
+	assert.Len(t, tray.Tray[1].Stories, 2)
+	assert.True(t, tray.Tray[1].Stories[0].Seen)
+	assert.False(t, tray.Tray[1].Stories[1].Seen)
+	assert.Nil(t, tray.Tray[1].Stories[0].ViewCount)
+	assert.Equal(t, 0, *tray.Tray[0].Stories[0].ViewCount)
+
+	// Muted accounts are left out
+	assert.NoError(t, svc.MuteUser("bob", "carol"))
+	assert.Equal(t, []string{"bob", "dave", "alice"}, trayAuthors(t, svc, "bob"))
+}
+
+func TestStories_Expire(t *testing.T) {
+	svc, now := newTestService(0)
+
+	_, err := svc.FollowUser("bob", "alice")
+	assert.NoError(t, err)
+
+	old := createTestStory(t, svc, "alice")
+	old_story, _ := svc.stories.GetStory(old)
+	*now = now.Add(12 * time.Hour)
+	recent := createTestStory(t, svc, "alice")
+
+	// Stories stop showing the moment they expire, before they are swept
+	*now = now.Add(12 * time.Hour)
+	_, _, err = svc.ViewStory(old, "bob")
+	assert.EqualError(t, err, "error retrieving story")
+
+	tray, _ := svc.GetStoriesTray("bob")
+	assert.Len(t, tray.Tray[0].Stories, 1)
+	assert.Equal(t, recent, tray.Tray[0].Stories[0].Id)
+
+	expired, err := svc.ExpireStories()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	_, err = svc.repo.GetImageByID(old_story.ImageId)
+	assert.EqualError(t, err, "image not found")
+
+	*now = now.Add(12 * time.Hour)
+	expired, _ = svc.ExpireStories()
+	assert.Equal(t, 1, expired)
+	assert.Empty(t, trayAuthors(t, svc, "bob"))
+}
@@ -0,0 +1,12 @@
+package service
+
+import "github.com/anandh86/instagram/models"
+
+type ITagService interface {
+	// Get a page of the posts with a hashtag the viewer can see, newest
+	// first, along with how many posts carry the hashtag
+	GetTaggedPosts(tag, viewer_id string, query models.TagQueryDTO) (page models.TagPageDTO, err error)
+
+	// Keep the hashtag index up to date with a change made through the Service
+	HandleEvent(event Event)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/pagination"
+	"github.com/anandh86/instagram/repository"
+	"github.com/anandh86/instagram/text"
+)
+
+// TagService indexes posts by the hashtags in their caption and in the
+// comments their author wrote on them. Comments by other users are left out
+// so nobody can file someone else's post under a hashtag.
+type TagService struct {
+	posts *Service
+	tags  repository.ITagRepository
+}
+
+func NewTagService(posts *Service, tags repository.ITagRepository) *TagService {
+
+	// compile-time check to ensure we implement the interface
+	var _ ITagService = (*TagService)(nil)
+
+	return &TagService{
+		posts: posts,
+		tags:  tags,
+	}
+}
+
+func (s *TagService) GetTaggedPosts(tag, viewer_id string, query models.TagQueryDTO) (page models.TagPageDTO, err error) {
+	tag = text.NormalizeHashtag(strings.TrimPrefix(tag, "#"))
+
+	if !text.IsHashtag(tag) {
+		return models.TagPageDTO{}, errors.New("invalid tag")
+	}
+
+	limit := pagination.ClampLimit(query.Limit)
+	cursor := query.Cursor
+
+	if cursor != "" {
+		if _, err := pagination.Decode(cursor); err != nil {
+			return models.TagPageDTO{}, err
+		}
+	}
+
+	count, err := s.tags.CountTaggedPosts(tag)
+
+	if err != nil {
+		return models.TagPageDTO{}, errors.New("error retrieving posts")
+	}
+
+	hiddenList, err := hiddenUsers(s.posts.repo, viewer_id)
+
+	if err != nil {
+		return models.TagPageDTO{}, errors.New("error retrieving posts")
+	}
+
+	hidden := make(map[string]bool, len(hiddenList))
+	for _, hidden_id := range hiddenList {
+		hidden[hidden_id] = true
+	}
+
+	posts := []models.PostResponseDTO{}
+
+	// Posts the viewer cannot see are skipped, so keep reading until the
+	// page is full or the hashtag runs out
+	for len(posts) < limit {
+		entries, next, err := s.tags.GetTaggedPosts(tag, cursor, limit-len(posts))
+
+		if err != nil {
+			return models.TagPageDTO{}, errors.New("error retrieving posts")
+		}
+
+		for _, entry := range entries {
+			post_meta, err := s.posts.getViewablePost(entry.PostId, viewer_id)
+
+			if err != nil || hidden[post_meta.Creator] {
+				continue
+			}
+
+			comments, _ := s.posts.repo.GetPostLatestComments(post_meta.Id, s.posts.config.PreviewComments, hiddenList)
+
+			posts = append(posts, postPreview(post_meta, comments))
+		}
+
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+
+	return models.TagPageDTO{Tag: tag, PostCount: count, Posts: posts, NextCursor: cursor}, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Indexing
+------------------------------------------------------------------------*/
+
+func (s *TagService) HandleEvent(event Event) {
+	var err error
+
+	switch event.Type {
+	case EventPostCreated, EventPostEdited, EventPostRestored:
+		err = s.indexPost(event.PostId)
+	case EventPostDeleted:
+		err = s.tags.SetPostTags(models.TaggedPostDTO{PostId: event.PostId}, nil)
+	case EventCommentCreated, EventCommentEdited, EventCommentDeleted, EventCommentRestored:
+		err = s.indexComment(event.PostId, event.CommentId)
+	}
+
+	if err != nil {
+		log.Printf("updating hashtags for %s event: %v", event.Type, err)
+	}
+}
+
+// indexComment reindexes a post after one of its comments changed, if the
+// comment was written by the author of the post
+func (s *TagService) indexComment(post_id, comment_id string) error {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.posts.repo.GetCommentByID(comment_id)
+
+	if err != nil {
+		return err
+	}
+
+	if comment.Creator != post_meta.Creator {
+		return nil
+	}
+
+	return s.indexPost(post_id)
+}
+
+// indexPost files a post under the hashtags of its caption and of the live
+// comments of its author, or takes it out of the index while it is trashed
+func (s *TagService) indexPost(post_id string) error {
+	post_meta, err := s.posts.repo.GetPostMetaByID(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	entry := models.TaggedPostDTO{PostId: post_meta.Id, CreatedAt: post_meta.CreatedAt}
+
+	if post_meta.DeletedAt != nil {
+		return s.tags.SetPostTags(entry, nil)
+	}
+
+	comments, err := s.posts.repo.GetPostComments(post_id)
+
+	if err != nil {
+		return err
+	}
+
+	texts := []string{post_meta.Caption}
+	for _, comment := range comments {
+		if comment.Creator == post_meta.Creator && comment.DeletedAt == nil {
+			texts = append(texts, comment.Content)
+		}
+	}
+
+	return s.tags.SetPostTags(entry, text.Hashtags(strings.Join(texts, "\n")))
+}
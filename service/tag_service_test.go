@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTagTest() (*Service, *TagService) {
+	svc, _ := newTestService(time.Minute)
+	tags := NewTagService(svc, repository.NewInMemoryTagRepo())
+	svc.Subscribe(tags.HandleEvent)
+	return svc, tags
+}
+
+func taggedCaptions(t *testing.T, tags *TagService, tag, viewer_id string) []string {
+	page, err := tags.GetTaggedPosts(tag, viewer_id, models.TagQueryDTO{})
+	assert.NoError(t, err)
+	return captions(page.Posts)
+}
+
+func TestTags_CaptionEditsAndTrash(t *testing.T) {
+	svc, tags := newTagTest()
+
+	beach := createTestPost(t, svc, "alice", "Sunset at the #Beach")
+	createTestPost(t, svc, "bob", "#beach day #surf")
+
+	assert.Equal(t, []string{"#beach day #surf", "Sunset at the #Beach"}, taggedCaptions(t, tags, "beach", ""))
+
+	// Tags are matched however they are typed
+	page, err := tags.GetTaggedPosts("#BEACH", "", models.TagQueryDTO{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "beach", page.Tag)
+	assert.Equal(t, 2, page.PostCount)
+	assert.Len(t, page.Posts, 1)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = tags.GetTaggedPosts("beach", "", models.TagQueryDTO{Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sunset at the #Beach", page.Posts[0].Caption)
+	assert.Empty(t, page.NextCursor)
+
+	// Editing the caption moves the post to its new hashtags
+	assert.NoError(t, svc.EditPost(beach, "alice", "Sunset at the #sea"))
+	assert.Equal(t, []string{"#beach day #surf"}, taggedCaptions(t, tags, "beach", ""))
+	assert.Equal(t, []string{"Sunset at the #sea"}, taggedCaptions(t, tags, "sea", ""))
+	assert.EqualError(t, svc.EditPost(beach, "bob", "#hijacked"), "unauthorized")
+
+	// Trashed posts leave the index until they are restored
+	assert.NoError(t, svc.DeletePost(beach, "alice"))
+	page, _ = tags.GetTaggedPosts("sea", "", models.TagQueryDTO{})
+	assert.Equal(t, 0, page.PostCount)
+
+	assert.NoError(t, svc.RestorePost(beach, "alice"))
+	assert.Equal(t, []string{"Sunset at the #sea"}, taggedCaptions(t, tags, "sea", ""))
+}
+
+func TestTags_AuthorComments(t *testing.T) {
+	svc, tags := newTagTest()
+
+	post := createTestPost(t, svc, "alice", "no tags yet")
+
+	comment_id, err := svc.CommentOnPost(models.CommentRequestDTO{PostId: post, AuthorId: "alice", Comment: "#東京 #travel"})
+	assert.NoError(t, err)
+	_, err = svc.CommentOnPost(models.CommentRequestDTO{PostId: post, AuthorId: "bob", Comment: "#spam"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"no tags yet"}, taggedCaptions(t, tags, "東京", ""))
+	assert.Empty(t, taggedCaptions(t, tags, "spam", ""))
+
+	_, err = svc.EditComment(comment_id, "alice", "#travel only")
+	assert.NoError(t, err)
+	assert.Empty(t, taggedCaptions(t, tags, "東京", ""))
+
+	assert.NoError(t, svc.DeleteComment(comment_id, "alice"))
+	assert.Empty(t, taggedCaptions(t, tags, "travel", ""))
+
+	assert.NoError(t, svc.RestoreComment(comment_id, "alice"))
+	assert.Equal(t, []string{"no tags yet"}, taggedCaptions(t, tags, "travel", ""))
+}
+
+func TestTags_Visibility(t *testing.T) {
+	svc, tags := newTagTest()
+
+	createTestPost(t, svc, "alice", "public #cats")
+	private := createTestPost(t, svc, "alice", "only me #cats")
+	assert.NoError(t, svc.UpdatePostSettings(private, "alice", models.PostSettingsDTO{Visibility: models.VisibilityOnlyMe}))
+	createTestPost(t, svc, "carol", "muted #cats")
+	assert.NoError(t, svc.MuteUser("bob", "carol"))
+
+	assert.Equal(t, []string{"public #cats"}, taggedCaptions(t, tags, "cats", "bob"))
+	assert.Equal(t, []string{"muted #cats", "only me #cats", "public #cats"}, taggedCaptions(t, tags, "cats", "alice"))
+
+	// The count covers every post with the hashtag
+	page, _ := tags.GetTaggedPosts("cats", "bob", models.TagQueryDTO{})
+	assert.Equal(t, 3, page.PostCount)
+
+	_, err := tags.GetTaggedPosts("123", "", models.TagQueryDTO{})
+	assert.EqualError(t, err, "invalid tag")
+	_, err = tags.GetTaggedPosts("cats", "", models.TagQueryDTO{Cursor: "garbage"})
+	assert.EqualError(t, err, "invalid cursor")
+}
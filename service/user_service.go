@@ -0,0 +1,39 @@
+package service
+
+import (
+	"image"
+
+	"github.com/anandh86/instagram/models"
+)
+
+type IUserService interface {
+	// Register a new user with a username and password
+	Register(req models.UserRequestDTO) (user models.User, err error)
+
+	// Check a username and password, returning the matching user
+	Authenticate(username, password string) (user models.User, err error)
+
+	// Get a user by its id
+	GetUserByID(user_id string) (user models.User, err error)
+
+	// Find a user by its id or its username
+	LookupUser(id_or_username string) (user models.User, err error)
+
+	// Get the public profile of a user, looked up by id or username, along
+	// with how the user relates to the viewer when one is given
+	GetProfile(id_or_username, viewer_id string) (profile models.ProfileDTO, err error)
+
+	// Change the display name, bio or website of a user
+	UpdateProfile(user_id string, req models.ProfileRequestDTO) (user models.User, err error)
+
+	// Replace the avatar of a user with a set of square renditions
+	SetAvatar(user_id string, renditions map[int]image.Image) (user models.User, err error)
+
+	// Get the smallest avatar rendition at least size pixels wide, or the
+	// largest one when none is that big
+	GetAvatar(id_or_username string, size int) (avatar image.Image, err error)
+
+	// Get the author summaries of the given users, by user id. Unknown users
+	// are left out.
+	GetAuthorSummaries(user_ids []string) map[string]models.AuthorSummaryDTO
+}
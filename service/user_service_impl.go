@@ -0,0 +1,373 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/anandh86/instagram/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._]{3,30}$`)
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes
+	maxPasswordLength = 72
+
+	maxDisplayNameLength = 50
+	maxBioLength         = 150
+	maxWebsiteLength     = 200
+)
+
+type UserService struct {
+	users repository.IUserRepository
+	// Holds avatar images and the posts counted on profiles
+	content repository.IRepository
+	config  config.Config
+
+	// Compared against when a username does not exist, so that a failed
+	// login takes the same time whether or not the user exists
+	dummyHash []byte
+
+	now func() time.Time
+
+	eventBus
+}
+
+func NewUserService(users repository.IUserRepository, content repository.IRepository, cfg config.Config) *UserService {
+
+	// compile-time check to ensure we implement the interface
+	var _ IUserService = (*UserService)(nil)
+
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), cfg.BcryptCost)
+
+	return &UserService{
+		users:     users,
+		content:   content,
+		config:    cfg,
+		dummyHash: dummyHash,
+		now:       time.Now,
+	}
+}
+
+func (s *UserService) Register(req models.UserRequestDTO) (user models.User, err error) {
+	username := strings.ToLower(strings.TrimSpace(req.Username))
+
+	if !usernamePattern.MatchString(username) {
+		return models.User{}, errors.New("invalid username")
+	}
+
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return models.User{}, errors.New("invalid password")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.config.BcryptCost)
+
+	if err != nil {
+		return models.User{}, errors.New("error hashing password")
+	}
+
+	user = models.User{
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    s.now(),
+	}
+
+	user.Id, err = s.users.SaveUser(user)
+
+	if err != nil {
+		if err.Error() == "username taken" {
+			return models.User{}, err
+		}
+		return models.User{}, errors.New("error saving user")
+	}
+
+	s.publish(Event{Type: EventUserRegistered, UserId: user.Id, At: user.CreatedAt})
+
+	return user, nil
+}
+
+func (s *UserService) Authenticate(username, password string) (user models.User, err error) {
+	user, err = s.users.GetUserByUsername(strings.ToLower(strings.TrimSpace(username)))
+
+	if err != nil {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return models.User{}, errors.New("invalid credentials")
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return models.User{}, errors.New("invalid credentials")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetUserByID(user_id string) (user models.User, err error) {
+	user, err = s.users.GetUserByID(user_id)
+
+	if err != nil {
+		return models.User{}, errors.New("error retrieving user")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) LookupUser(id_or_username string) (user models.User, err error) {
+	user, err = s.users.GetUserByUsername(strings.ToLower(id_or_username))
+
+	if err != nil {
+		user, err = s.users.GetUserByID(id_or_username)
+	}
+
+	if err != nil {
+		return models.User{}, errors.New("error retrieving user")
+	}
+
+	return user, nil
+}
+
+/*------------------------------------------------------------------------
+*                             Profile
+------------------------------------------------------------------------*/
+
+func (s *UserService) GetProfile(id_or_username, viewer_id string) (profile models.ProfileDTO, err error) {
+	user, err := s.LookupUser(id_or_username)
+
+	if err != nil {
+		return models.ProfileDTO{}, err
+	}
+
+	postCount, err := s.content.CountPostsByCreator(user.Id)
+
+	if err != nil {
+		return models.ProfileDTO{}, errors.New("error counting posts")
+	}
+
+	followers, following, err := s.content.CountFollows(user.Id)
+
+	if err != nil {
+		return models.ProfileDTO{}, errors.New("error counting follows")
+	}
+
+	profile = models.ProfileDTO{
+		Id:          user.Id,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Website:     user.Website,
+		AvatarURL:   avatarURL(user),
+		Private:     user.Private,
+		PostCount:   postCount,
+		Followers:   followers,
+		Following:   following,
+		CreatedAt:   user.CreatedAt,
+	}
+
+	if viewer_id != "" && viewer_id != user.Id {
+		profile.Relationship, err = s.relationship(viewer_id, user.Id)
+
+		if err != nil {
+			return models.ProfileDTO{}, err
+		}
+	}
+
+	return profile, nil
+}
+
+// relationship tells whether the viewer and a user follow each other, and
+// whether the viewer awaits approval to follow them
+func (s *UserService) relationship(viewer_id, user_id string) (*models.RelationshipDTO, error) {
+	following, err := s.content.IsFollowing(viewer_id, user_id)
+
+	if err != nil {
+		return nil, errors.New("error checking follows")
+	}
+
+	followedBy, err := s.content.IsFollowing(user_id, viewer_id)
+
+	if err != nil {
+		return nil, errors.New("error checking follows")
+	}
+
+	relationship := &models.RelationshipDTO{Following: following, FollowedBy: followedBy}
+
+	if request, err := s.content.GetFollowRequest(viewer_id, user_id); err == nil {
+		relationship.Requested = request.Status == models.FollowRequestPending
+	}
+
+	return relationship, nil
+}
+
+func (s *UserService) UpdateProfile(user_id string, req models.ProfileRequestDTO) (user models.User, err error) {
+	// Only the fields in the request are written, so concurrent updates of
+	// other fields are kept
+	profile := models.ProfileRequestDTO{Private: req.Private}
+
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return models.User{}, errors.New("invalid display name")
+		}
+		profile.DisplayName = &displayName
+	}
+
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return models.User{}, errors.New("invalid bio")
+		}
+		profile.Bio = &bio
+	}
+
+	if req.Website != nil {
+		website := strings.TrimSpace(*req.Website)
+		if website != "" && !isWebsite(website) {
+			return models.User{}, errors.New("invalid website")
+		}
+		profile.Website = &website
+	}
+
+	user, err = s.users.SetUserProfile(user_id, profile)
+
+	if err != nil {
+		if err.Error() == "user not found" {
+			return models.User{}, errors.New("error retrieving user")
+		}
+		return models.User{}, errors.New("error saving user")
+	}
+
+	s.publish(Event{Type: EventProfileUpdated, UserId: user.Id, At: s.now()})
+
+	return user, nil
+}
+
+func (s *UserService) SetAvatar(user_id string, renditions map[int]image.Image) (user models.User, err error) {
+	user, err = s.GetUserByID(user_id)
+
+	if err != nil {
+		return models.User{}, err
+	}
+
+	avatar := make(map[int]string, len(renditions))
+
+	for size, img := range renditions {
+		img_id, img_err := s.content.SaveImage(img)
+
+		if img_err != nil {
+			s.deleteAvatar(avatar)
+			return models.User{}, errors.New("error saving image")
+		}
+
+		avatar[size] = img_id
+	}
+
+	previous, err := s.users.SetUserAvatar(user_id, avatar)
+
+	if err != nil {
+		s.deleteAvatar(avatar)
+		return models.User{}, errors.New("error saving user")
+	}
+
+	s.deleteAvatar(previous)
+
+	user.Avatar = avatar
+
+	return user, nil
+}
+
+// deleteAvatar removes the stored renditions of an avatar
+func (s *UserService) deleteAvatar(avatar map[int]string) {
+	for _, img_id := range avatar {
+		if err := s.content.DeleteImageByID(img_id); err != nil {
+			log.Printf("deleting avatar image %s: %v", img_id, err)
+		}
+	}
+}
+
+func (s *UserService) GetAvatar(id_or_username string, size int) (avatar image.Image, err error) {
+	user, err := s.LookupUser(id_or_username)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.Avatar) == 0 {
+		return nil, errors.New("no avatar")
+	}
+
+	sizes := make([]int, 0, len(user.Avatar))
+	for rendition := range user.Avatar {
+		sizes = append(sizes, rendition)
+	}
+	slices.Sort(sizes)
+
+	// Fall back to the largest rendition
+	chosen := sizes[len(sizes)-1]
+	for _, rendition := range sizes {
+		if rendition >= size {
+			chosen = rendition
+			break
+		}
+	}
+
+	avatar, err = s.content.GetImageByID(user.Avatar[chosen])
+
+	if err != nil {
+		return nil, errors.New("error retrieving image")
+	}
+
+	return avatar, nil
+}
+
+func (s *UserService) GetAuthorSummaries(user_ids []string) map[string]models.AuthorSummaryDTO {
+	authors := make(map[string]models.AuthorSummaryDTO, len(user_ids))
+
+	for _, user_id := range user_ids {
+		if _, seen := authors[user_id]; seen {
+			continue
+		}
+
+		user, err := s.users.GetUserByID(user_id)
+		if err != nil {
+			continue
+		}
+
+		authors[user_id] = models.AuthorSummaryDTO{
+			Id:          user.Id,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			AvatarURL:   avatarURL(user),
+		}
+	}
+
+	return authors
+}
+
+// avatarURL is where the avatar of a user is served, if they have one
+func avatarURL(user models.User) string {
+	if len(user.Avatar) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/api/users/%s/avatar", user.Id)
+}
+
+// isWebsite tells whether a profile link is an absolute http(s) URL
+func isWebsite(website string) bool {
+	if len(website) > maxWebsiteLength {
+		return false
+	}
+
+	u, err := url.Parse(website)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/anandh86/instagram/config"
+	"github.com/anandh86/instagram/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Mock repository implementing IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) SaveUser(user models.User) (string, error) {
+	args := m.Called(user)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByID(userID string) (models.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByUsername(username string) (models.User, error) {
+	args := m.Called(username)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetUserProfile(userID string, profile models.ProfileRequestDTO) (models.User, error) {
+	args := m.Called(userID, profile)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetUserAvatar(userID string, avatar map[int]string) (map[int]string, error) {
+	args := m.Called(userID, avatar)
+	previous, _ := args.Get(0).(map[int]string)
+	return previous, args.Error(1)
+}
+
+func (m *MockUserRepository) GetAllUsers() ([]models.User, error) {
+	args := m.Called()
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func testUserConfig() config.Config {
+	cfg := config.Default()
+	cfg.BcryptCost = bcrypt.MinCost
+	return cfg
+}
+
+func TestRegister_Success(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	mockUsers.On("SaveUser", mock.MatchedBy(func(u models.User) bool {
+		return u.Username == "alice" &&
+			bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("correct horse")) == nil
+	})).Return("user123", nil)
+
+	user, err := svc.Register(models.UserRequestDTO{Username: " Alice ", Password: "correct horse"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", user.Id)
+	assert.Equal(t, "alice", user.Username)
+	mockUsers.AssertExpectations(t)
+}
+
+func TestRegister_InvalidInput(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	_, err := svc.Register(models.UserRequestDTO{Username: "a!", Password: "correct horse"})
+	assert.EqualError(t, err, "invalid username")
+
+	_, err = svc.Register(models.UserRequestDTO{Username: "alice", Password: "short"})
+	assert.EqualError(t, err, "invalid password")
+
+	mockUsers.AssertNotCalled(t, "SaveUser", mock.Anything)
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	stored := models.User{Id: "user123", Username: "alice", PasswordHash: string(hash)}
+
+	mockUsers.On("GetUserByUsername", "alice").Return(stored, nil)
+	mockUsers.On("GetUserByUsername", "bob").Return(models.User{}, errors.New("user not found"))
+
+	user, err := svc.Authenticate("Alice", "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", user.Id)
+
+	_, err = svc.Authenticate("alice", "wrong password")
+	assert.EqualError(t, err, "invalid credentials")
+
+	_, err = svc.Authenticate("bob", "correct horse")
+	assert.EqualError(t, err, "invalid credentials")
+}
+
+func TestGetProfile_ByUsername(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	stored := models.User{
+		Id:          "user123",
+		Username:    "alice",
+		DisplayName: "Alice",
+		Bio:         "Photographer",
+		Avatar:      map[int]string{64: "img64"},
+	}
+
+	mockUsers.On("GetUserByUsername", "alice").Return(stored, nil)
+	mockRepo.On("CountPostsByCreator", "user123").Return(3, nil)
+	mockRepo.On("CountFollows", "user123").Return(10, 4, nil)
+
+	profile, err := svc.GetProfile("Alice", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", profile.DisplayName)
+	assert.Equal(t, "Photographer", profile.Bio)
+	assert.Equal(t, 3, profile.PostCount)
+	assert.Equal(t, 10, profile.Followers)
+	assert.Equal(t, 4, profile.Following)
+	assert.Equal(t, "/api/users/user123/avatar", profile.AvatarURL)
+	assert.Nil(t, profile.Relationship)
+}
+
+func TestGetProfile_ByID(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	mockUsers.On("GetUserByUsername", "user123").Return(models.User{}, errors.New("user not found"))
+	mockUsers.On("GetUserByID", "user123").Return(models.User{Id: "user123", Username: "alice"}, nil)
+	mockRepo.On("CountPostsByCreator", "user123").Return(0, nil)
+	mockRepo.On("CountFollows", "user123").Return(0, 0, nil)
+
+	profile, err := svc.GetProfile("user123", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", profile.Username)
+	assert.Empty(t, profile.AvatarURL)
+}
+
+func TestGetProfile_Relationship(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	mockUsers.On("GetUserByUsername", "alice").Return(models.User{Id: "user123", Username: "alice"}, nil)
+	mockRepo.On("CountPostsByCreator", "user123").Return(0, nil)
+	mockRepo.On("CountFollows", "user123").Return(1, 0, nil)
+	mockRepo.On("IsFollowing", "viewer", "user123").Return(true, nil)
+	mockRepo.On("IsFollowing", "user123", "viewer").Return(false, nil)
+	mockRepo.On("GetFollowRequest", "viewer", "user123").Return(models.FollowRequestDTO{}, errors.New("follow request not found"))
+
+	profile, err := svc.GetProfile("alice", "viewer")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RelationshipDTO{Following: true, FollowedBy: false}, profile.Relationship)
+
+	// No relationship with oneself
+	profile, err = svc.GetProfile("alice", "user123")
+
+	assert.NoError(t, err)
+	assert.Nil(t, profile.Relationship)
+}
+
+func TestUpdateProfile(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	displayName := "Alice"
+	website := "https://alice.example.com"
+	updated := models.User{Id: "user123", Username: "alice", DisplayName: displayName, Bio: "Old bio", Website: website}
+
+	// Only the fields in the request are written, trimmed
+	mockUsers.On("SetUserProfile", "user123", models.ProfileRequestDTO{DisplayName: &displayName, Website: &website}).Return(updated, nil)
+
+	padded := " Alice "
+	user, err := svc.UpdateProfile("user123", models.ProfileRequestDTO{DisplayName: &padded, Website: &website})
+
+	assert.NoError(t, err)
+	assert.Equal(t, updated, user)
+}
+
+func TestUpdateProfile_InvalidInput(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	website := "javascript:alert(1)"
+	_, err := svc.UpdateProfile("user123", models.ProfileRequestDTO{Website: &website})
+	assert.EqualError(t, err, "invalid website")
+
+	bio := strings.Repeat("a", maxBioLength+1)
+	_, err = svc.UpdateProfile("user123", models.ProfileRequestDTO{Bio: &bio})
+	assert.EqualError(t, err, "invalid bio")
+
+	mockUsers.AssertNotCalled(t, "SetUserProfile", mock.Anything, mock.Anything)
+}
+
+func TestSetAvatar(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	small := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	large := image.NewRGBA(image.Rect(0, 0, 320, 320))
+
+	mockUsers.On("GetUserByID", "user123").Return(models.User{Id: "user123"}, nil)
+	mockRepo.On("SaveImage", small).Return("img64", nil)
+	mockRepo.On("SaveImage", large).Return("img320", nil)
+	mockUsers.On("SetUserAvatar", "user123", map[int]string{64: "img64", 320: "img320"}).Return(map[int]string{64: "old64"}, nil)
+	mockRepo.On("DeleteImageByID", "old64").Return(nil)
+
+	user, err := svc.SetAvatar("user123", map[int]image.Image{64: small, 320: large})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{64: "img64", 320: "img320"}, user.Avatar)
+	mockRepo.AssertCalled(t, "DeleteImageByID", "old64")
+}
+
+func TestSetAvatar_FailedUpdateDeletesNewImages(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	small := image.NewRGBA(image.Rect(0, 0, 64, 64))
+
+	mockUsers.On("GetUserByID", "user123").Return(models.User{Id: "user123"}, nil)
+	mockRepo.On("SaveImage", small).Return("img64", nil)
+	mockUsers.On("SetUserAvatar", "user123", mock.Anything).Return(nil, errors.New("disk full"))
+	mockRepo.On("DeleteImageByID", "img64").Return(nil)
+
+	_, err := svc.SetAvatar("user123", map[int]image.Image{64: small})
+
+	assert.EqualError(t, err, "error saving user")
+	mockRepo.AssertCalled(t, "DeleteImageByID", "img64")
+}
+
+func TestGetAvatar_PicksRendition(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	mockRepo := new(MockRepository)
+	svc := NewUserService(mockUsers, mockRepo, testUserConfig())
+
+	stored := models.User{Id: "user123", Username: "alice", Avatar: map[int]string{64: "img64", 150: "img150", 320: "img320"}}
+	mockUsers.On("GetUserByUsername", "alice").Return(stored, nil)
+
+	medium := image.NewRGBA(image.Rect(0, 0, 150, 150))
+	large := image.NewRGBA(image.Rect(0, 0, 320, 320))
+	mockRepo.On("GetImageByID", "img150").Return(medium, nil)
+	mockRepo.On("GetImageByID", "img320").Return(large, nil)
+
+	avatar, err := svc.GetAvatar("alice", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, medium, avatar)
+
+	avatar, err = svc.GetAvatar("alice", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, large, avatar)
+}
+
+func TestGetAuthorSummaries(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	mockUsers.On("GetUserByID", "user123").Return(models.User{Id: "user123", Username: "alice", DisplayName: "Alice"}, nil).Once()
+	mockUsers.On("GetUserByID", "ghost").Return(models.User{}, errors.New("user not found"))
+
+	authors := svc.GetAuthorSummaries([]string{"user123", "ghost", "user123"})
+
+	assert.Equal(t, map[string]models.AuthorSummaryDTO{
+		"user123": {Id: "user123", Username: "alice", DisplayName: "Alice"},
+	}, authors)
+	mockUsers.AssertExpectations(t)
+}
+
+func TestUpdateProfile_Private(t *testing.T) {
+	mockUsers := new(MockUserRepository)
+	svc := NewUserService(mockUsers, new(MockRepository), testUserConfig())
+
+	private := true
+	mockUsers.On("SetUserProfile", "user123", models.ProfileRequestDTO{Private: &private}).Return(models.User{Id: "user123", Private: true}, nil)
+
+	user, err := svc.UpdateProfile("user123", models.ProfileRequestDTO{Private: &private})
+
+	assert.NoError(t, err)
+	assert.True(t, user.Private)
+}
@@ -0,0 +1,88 @@
+// Package text picks out the parts of user written text the app acts on,
+// such as the hashtags and mentions in captions and comments
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Longest hashtag recognized, in characters
+const MaxHashtagLength = 100
+
+// Hashtags lists the distinct hashtags in s, in the order they first appear,
+// without the leading '#'. A hashtag starts with '#' (or the full width '＃')
+// at the start of s or after a character that can't be part of a tag, and
+// runs over letters, digits, combining marks and underscores of any script.
+// Tags made only of digits and underscores, or longer than MaxHashtagLength,
+// are ignored. Tags are normalized so that differently typed spellings of a
+// word match: composed (NFC) and lowercased.
+func Hashtags(s string) []string {
+	s = norm.NFC.String(s)
+
+	var tags []string
+	seen := make(map[string]bool)
+
+	prev := ' '
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		if !isHashMark(r) || isTagRune(prev) || isHashMark(prev) {
+			prev = r
+			i += size
+			continue
+		}
+
+		// Read the tag body after the mark
+		start := i + size
+		end := start
+		length, letters := 0, 0
+		for end < len(s) {
+			r, size := utf8.DecodeRuneInString(s[end:])
+			if !isTagRune(r) {
+				break
+			}
+			if unicode.IsLetter(r) {
+				letters++
+			}
+			length++
+			end += size
+		}
+
+		if letters > 0 && length <= MaxHashtagLength {
+			tag := strings.ToLower(s[start:end])
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+
+		prev, _ = utf8.DecodeLastRuneInString(s[:end])
+		i = end
+	}
+
+	return tags
+}
+
+// IsHashtag tells whether tag, without its leading '#', is a hashtag as
+// Hashtags would extract it
+func IsHashtag(tag string) bool {
+	tags := Hashtags("#" + tag)
+	return len(tags) == 1 && tags[0] == NormalizeHashtag(tag)
+}
+
+// NormalizeHashtag brings a tag to the form Hashtags returns it in
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(norm.NFC.String(tag))
+}
+
+func isHashMark(r rune) bool {
+	return r == '#' || r == '＃'
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
+}
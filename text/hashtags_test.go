@@ -0,0 +1,48 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashtags(t *testing.T) {
+	cases := map[string][]string{
+		"Sunset at the #Beach with #friends #beach":  {"beach", "friends"},
+		"#first word and trailing punctuation #end.": {"first", "end"},
+		"no tags, an email@x.com, a#b and &#39;":     nil,
+		"#123 is a number but #2024goals is a tag":   {"2024goals"},
+		"##double and #under_score_":                 {"under_score_"},
+		"(#paren) \"#quoted\" #comma,#next":          {"paren", "quoted", "comma", "next"},
+		"日本語 #東京 and #ÉTÉ":                           {"東京", "été"},
+		"full width ＃ハッシュタグ":                         {"ハッシュタグ"},
+		"Thai marks #สวัสดี done":                    {"สวัสดี"},
+		"Hindi #नमस्ते":                              {"नमस्ते"},
+		"emoji 🌅#sunset":                             {"sunset"},
+	}
+
+	for caption, want := range cases {
+		assert.Equal(t, want, Hashtags(caption), caption)
+	}
+}
+
+func TestHashtags_NormalizesComposedForms(t *testing.T) {
+	// "café" typed with a combining accent matches the precomposed spelling
+	assert.Equal(t, []string{"café"}, Hashtags("#cafe\u0301 #caf\u00e9"))
+}
+
+func TestHashtags_IgnoresOverlongTags(t *testing.T) {
+	tag := strings.Repeat("a", MaxHashtagLength)
+
+	assert.Equal(t, []string{tag}, Hashtags("#"+tag))
+	assert.Nil(t, Hashtags("#"+tag+"a"))
+}
+
+func TestIsHashtag(t *testing.T) {
+	assert.True(t, IsHashtag("Beach"))
+	assert.True(t, IsHashtag("東京"))
+	assert.False(t, IsHashtag(""))
+	assert.False(t, IsHashtag("123"))
+	assert.False(t, IsHashtag("two words"))
+}
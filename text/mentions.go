@@ -0,0 +1,74 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Shortest and longest usernames, as accepted on sign up
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 30
+)
+
+// Mention is an @username in a text. Start and End are the offsets of the
+// mention in characters (Unicode code points), with the '@' included:
+// the mention spans [Start, End).
+type Mention struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// Mentions lists the @usernames in s, in the order they appear. A mention
+// starts with '@' (or the full width '＠') at the start of s or after a
+// character that can't be part of a username, so email addresses are not
+// taken for mentions. Usernames are lowercased; trailing dots are left out,
+// as they usually end a sentence.
+func Mentions(s string) []Mention {
+	var mentions []Mention
+
+	prev := ' '
+	offset := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		if !isAtMark(r) || isUsernameRune(prev) || isAtMark(prev) {
+			prev = r
+			i += size
+			offset++
+			continue
+		}
+
+		// Usernames are ASCII, so bytes and characters line up from here
+		start := i + size
+		end := start
+		for end < len(s) && end-start < maxUsernameLength+1 && isUsernameRune(rune(s[end])) {
+			end++
+		}
+
+		username := strings.TrimRight(s[start:end], ".")
+
+		if n := len(username); n >= minUsernameLength && n <= maxUsernameLength && (end == len(s) || !isUsernameRune(rune(s[end]))) {
+			mentions = append(mentions, Mention{
+				Username: strings.ToLower(username),
+				Start:    offset,
+				End:      offset + 1 + n,
+			})
+		}
+
+		prev, _ = utf8.DecodeLastRuneInString(s[:end])
+		offset += 1 + end - start
+		i = end
+	}
+
+	return mentions
+}
+
+func isAtMark(r rune) bool {
+	return r == '@' || r == '＠'
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '.' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
@@ -0,0 +1,33 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentions(t *testing.T) {
+	mentions := Mentions("Thanks @Bob_1 and @carol.smith. Mail me at dave@example.com or ＠erin")
+
+	assert.Equal(t, []Mention{
+		{Username: "bob_1", Start: 7, End: 13},
+		{Username: "carol.smith", Start: 18, End: 30},
+		{Username: "erin", Start: 63, End: 68},
+	}, mentions)
+}
+
+func TestMentions_CharacterOffsets(t *testing.T) {
+	caption := "東京で @alice と"
+
+	mentions := Mentions(caption)
+
+	assert.Equal(t, []Mention{{Username: "alice", Start: 4, End: 10}}, mentions)
+	assert.Equal(t, "@alice", string([]rune(caption)[4:10]))
+}
+
+func TestMentions_IgnoresInvalidUsernames(t *testing.T) {
+	assert.Nil(t, Mentions("@ab is too short, @@double and a lone @"))
+	assert.Nil(t, Mentions("@"+strings.Repeat("a", maxUsernameLength+1)))
+	assert.Len(t, Mentions("@"+strings.Repeat("a", maxUsernameLength)), 1)
+}
@@ -0,0 +1,254 @@
+package trending
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// How many half lives the epoch of a board can lag behind before it is
+// moved forward. Scores grow by 2^maxEpochLag over that time at most.
+const maxEpochLag = 16
+
+// Entry is an item of a board ranking
+type Entry struct {
+	Id        string
+	CreatedAt time.Time
+
+	// Score of the item now
+	Score float64
+
+	// Rank orders the entries, highest first. Unlike the score, it does not
+	// change as time passes, only when the item is engaged with, or now and
+	// then when the board moves its epoch forward.
+	Rank int
+}
+
+// Board keeps the scores of recently created items up to date as they are
+// engaged with. It is safe for concurrent use.
+//
+// Scores are stored as of the epoch of the board, a point in time: all
+// scores decay at the same rate from there, so the order of the items only
+// changes when engagements come and go. The sorted ranking is kept between
+// those changes, so reading it again only restates the scores.
+type Board struct {
+	mu sync.Mutex
+
+	formula Formula
+
+	// How long after being created items stay on the board
+	window time.Duration
+
+	now   func() time.Time
+	epoch time.Time
+	items map[string]*item
+
+	// Ids of the items in ranking order, nil when they must be sorted again
+	ranked []string
+}
+
+type item struct {
+	createdAt time.Time
+
+	// Score as of the epoch
+	score float64
+
+	// Engagements by a key identifying them, such as "like/<user id>"
+	engagements map[string]Engagement
+}
+
+// NewBoard creates a board ranking the items created within window before
+// the time given by now
+func NewBoard(formula Formula, window time.Duration, now func() time.Time) *Board {
+	return &Board{
+		formula: formula,
+		window:  window,
+		now:     now,
+		epoch:   now(),
+		items:   make(map[string]*item),
+	}
+}
+
+// Add puts an item on the board, unless it is already there or was created
+// too long ago
+func (b *Board) Add(id string, createdAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.items[id]; exists || b.expired(createdAt, b.now()) {
+		return
+	}
+
+	b.items[id] = &item{createdAt: createdAt, engagements: make(map[string]Engagement)}
+	b.ranked = nil
+}
+
+// Remove takes an item off the board
+func (b *Board) Remove(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Taking an item out leaves the others in order
+	if _, exists := b.items[id]; !exists {
+		return
+	}
+	delete(b.items, id)
+	b.ranked = slices.DeleteFunc(b.ranked, func(ranked string) bool { return ranked == id })
+}
+
+// Engage adds an engagement to the score of an item on the board. An
+// engagement is only counted once per key, so that liking a post twice does
+// not count twice. Engagements dated after now count as made now.
+func (b *Board) Engage(id, key string, engagement Engagement) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if engagement.At.After(now) {
+		engagement.At = now
+	}
+
+	it, exists := b.items[id]
+	if !exists {
+		return
+	}
+	if _, counted := it.engagements[key]; counted {
+		return
+	}
+
+	b.advance(now)
+
+	it.engagements[key] = engagement
+	it.score += b.worth(engagement)
+	b.ranked = nil
+}
+
+// Disengage takes an engagement back out of the score of an item
+func (b *Board) Disengage(id, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	it, exists := b.items[id]
+	if !exists {
+		return
+	}
+	engagement, counted := it.engagements[key]
+	if !counted {
+		return
+	}
+
+	b.advance(b.now())
+
+	delete(it.engagements, key)
+	if len(it.engagements) == 0 {
+		// Start over from zero rather than keep rounding errors
+		it.score = 0
+	} else {
+		it.score = math.Max(0, it.score-b.worth(engagement))
+	}
+	b.ranked = nil
+}
+
+// Score is the current score of an item, zero when it is not on the board
+func (b *Board) Score(id string) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	it, exists := b.items[id]
+	if !exists {
+		return 0
+	}
+	return it.score * b.formula.Decay(b.epoch, b.now())
+}
+
+// Ranking lists the items on the board, highest ranked first, with ties
+// broken by the newest item first, then by id. Items created too long ago
+// are dropped from the board.
+func (b *Board) Ranking() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.advance(now)
+
+	if b.ranked == nil {
+		b.rerank()
+	}
+
+	decay := b.formula.Decay(b.epoch, now)
+
+	entries := make([]Entry, 0, len(b.ranked))
+	kept := b.ranked[:0]
+	for _, id := range b.ranked {
+		it := b.items[id]
+		if b.expired(it.createdAt, now) {
+			delete(b.items, id)
+			continue
+		}
+		kept = append(kept, id)
+
+		entries = append(entries, Entry{
+			Id:        id,
+			CreatedAt: it.createdAt,
+			Score:     it.score * decay,
+			Rank:      rank(it.score),
+		})
+	}
+	b.ranked = kept
+
+	return entries
+}
+
+// rerank puts the ids of the items on the board in ranking order
+func (b *Board) rerank() {
+	ranked := make([]string, 0, len(b.items))
+	for id := range b.items {
+		ranked = append(ranked, id)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		x, y := b.items[ranked[i]], b.items[ranked[j]]
+		if rx, ry := rank(x.score), rank(y.score); rx != ry {
+			return rx > ry
+		}
+		if !x.createdAt.Equal(y.createdAt) {
+			return x.createdAt.After(y.createdAt)
+		}
+		return ranked[i] > ranked[j]
+	})
+
+	b.ranked = ranked
+}
+
+// worth is what an engagement adds to a score stated as of the epoch
+func (b *Board) worth(engagement Engagement) float64 {
+	return b.formula.Weight(engagement.Kind) * b.formula.Decay(engagement.At, b.epoch)
+}
+
+// advance moves the epoch to now once it lags too far behind, restating the
+// scores as of the new epoch
+func (b *Board) advance(now time.Time) {
+	if now.Sub(b.epoch) <= maxEpochLag*b.formula.HalfLife {
+		return
+	}
+
+	decay := b.formula.Decay(b.epoch, now)
+	for _, it := range b.items {
+		it.score *= decay
+	}
+	b.epoch = now
+
+	// Rounding the restated scores can tie items that were apart
+	b.ranked = nil
+}
+
+func (b *Board) expired(createdAt, now time.Time) bool {
+	return now.Sub(createdAt) > b.window
+}
+
+// rank turns a score into an integer rank, precise to a thousandth
+func rank(score float64) int {
+	return int(math.Round(score * 1000))
+}
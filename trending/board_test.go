@@ -0,0 +1,167 @@
+package trending
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock tests move by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func rankedIds(board *Board) []string {
+	ids := []string{}
+	for _, entry := range board.Ranking() {
+		ids = append(ids, entry.Id)
+	}
+	return ids
+}
+
+func TestBoard_RanksByDecayedScore(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 24*time.Hour, clock.Now)
+
+	board.Add("old", clock.now)
+	board.Engage("old", "save/a", Engagement{Kind: Save, At: clock.now})
+	board.Engage("old", "save/b", Engagement{Kind: Save, At: clock.now})
+
+	clock.Advance(2 * time.Hour)
+	board.Add("new", clock.now)
+	board.Engage("new", "comment/c", Engagement{Kind: Comment, At: clock.now})
+
+	// Six points two hours ago are worth 1.5 now, less than a fresh comment
+	assert.Equal(t, []string{"new", "old"}, rankedIds(board))
+	assert.InDelta(t, 1.5, board.Score("old"), 1e-9)
+	assert.InDelta(t, 2, board.Score("new"), 1e-9)
+
+	// The order holds as time passes
+	clock.Advance(5 * time.Hour)
+	assert.Equal(t, []string{"new", "old"}, rankedIds(board))
+	assert.InDelta(t, 2.0/32, board.Score("new"), 1e-9)
+}
+
+func TestBoard_FollowsFormula(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 24*time.Hour, clock.Now)
+	board.Add("a", clock.now)
+
+	var engagements []Engagement
+	for i, kind := range []Kind{Like, Comment, Save, Like, Like} {
+		clock.Advance(30 * time.Minute)
+		engagement := Engagement{Kind: kind, At: clock.now}
+		board.Engage("a", string(rune('a'+i)), engagement)
+		engagements = append(engagements, engagement)
+	}
+
+	// Counting an engagement again has no effect
+	board.Engage("a", "a", Engagement{Kind: Save, At: clock.now})
+
+	clock.Advance(90 * time.Minute)
+	assert.InDelta(t, testFormula.Score(engagements, clock.now), board.Score("a"), 1e-9)
+
+	// Taking an engagement back removes what it was worth
+	board.Disengage("a", "c")
+	assert.InDelta(t, testFormula.Score(append(engagements[:2:2], engagements[3:]...), clock.now), board.Score("a"), 1e-9)
+
+	board.Disengage("a", "unknown")
+	for _, key := range []string{"a", "b", "d", "e"} {
+		board.Disengage("a", key)
+	}
+	assert.Equal(t, 0.0, board.Score("a"))
+}
+
+func TestBoard_TiesAndWindow(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 24*time.Hour, clock.Now)
+
+	board.Add("first", clock.now)
+	clock.Advance(time.Hour)
+	board.Add("second", clock.now)
+
+	// Engagements on items off the board are ignored
+	board.Engage("missing", "like/a", Engagement{Kind: Like, At: clock.now})
+	assert.Equal(t, 0.0, board.Score("missing"))
+
+	// Items without engagement come newest first
+	assert.Equal(t, []string{"second", "first"}, rankedIds(board))
+
+	// Items leave the board once created too long ago
+	clock.Advance(23*time.Hour + time.Minute)
+	assert.Equal(t, []string{"second"}, rankedIds(board))
+
+	board.Add("ancient", start)
+	board.Remove("second")
+	assert.Empty(t, rankedIds(board))
+}
+
+func TestBoard_AdvancesEpoch(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 365*24*time.Hour, clock.Now)
+
+	board.Add("a", clock.now)
+	board.Add("b", clock.now)
+	board.Engage("a", "like/x", Engagement{Kind: Like, At: clock.now})
+
+	// Far past the epoch, scores are restated before they grow out of range
+	for i := 0; i < 100; i++ {
+		clock.Advance(24 * time.Hour)
+		board.Engage("b", string(rune('A'+i)), Engagement{Kind: Like, At: clock.now})
+		board.Engage("a", string(rune('A'+i)), Engagement{Kind: Save, At: clock.now})
+	}
+
+	ranking := board.Ranking()
+	assert.Equal(t, "a", ranking[0].Id)
+	assert.InDelta(t, 3, ranking[0].Score, 1e-6)
+	assert.InDelta(t, 1, ranking[1].Score, 1e-6)
+	assert.Less(t, ranking[0].Rank, 1<<20*1000)
+}
+
+func TestBoard_FutureEngagementsCountAsNow(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 24*time.Hour, clock.Now)
+
+	board.Add("a", clock.now)
+	board.Engage("a", "like/x", Engagement{Kind: Like, At: clock.now.Add(1000 * time.Hour)})
+
+	assert.Equal(t, 1.0, board.Score("a"))
+}
+
+func TestBoard_KeepsRankingBetweenChanges(t *testing.T) {
+	clock := &fakeClock{now: start}
+	board := NewBoard(testFormula, 24*time.Hour, clock.Now)
+
+	board.Add("a", clock.now)
+	board.Add("b", clock.now)
+	board.Add("c", clock.now)
+	board.Engage("a", "like/x", Engagement{Kind: Like, At: clock.now})
+	assert.Equal(t, []string{"a", "c", "b"}, rankedIds(board))
+
+	// Reading the ranking again reuses the sorted order
+	ranked := board.ranked
+	clock.Advance(time.Hour)
+	assert.Equal(t, []string{"a", "c", "b"}, rankedIds(board))
+	assert.Same(t, &ranked[0], &board.ranked[0])
+
+	// Removing an item keeps the order of the others
+	board.Remove("c")
+	assert.Equal(t, []string{"a", "b"}, rankedIds(board))
+
+	// Engagements reorder the ranking
+	board.Engage("b", "save/x", Engagement{Kind: Save, At: clock.now})
+	assert.Nil(t, board.ranked)
+	assert.Equal(t, []string{"b", "a"}, rankedIds(board))
+
+	board.Disengage("b", "save/x")
+	assert.Equal(t, []string{"a", "b"}, rankedIds(board))
+}
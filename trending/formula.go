@@ -0,0 +1,66 @@
+// Package trending ranks items, such as posts, by how much engagement they
+// are getting lately: each like, comment or save adds to the score of an
+// item, and its contribution halves every half life after it happened.
+package trending
+
+import (
+	"math"
+	"time"
+)
+
+// Kind is a kind of engagement
+type Kind int
+
+const (
+	Like Kind = iota
+	Comment
+	Save
+)
+
+// Formula sets how much each kind of engagement is worth, and how quickly it
+// wears off
+type Formula struct {
+	LikeWeight    float64
+	CommentWeight float64
+	SaveWeight    float64
+
+	// Time after which an engagement is worth half as much
+	HalfLife time.Duration
+}
+
+// Engagement is something a user did with an item, at a point in time
+type Engagement struct {
+	Kind Kind
+	At   time.Time
+}
+
+// Weight is what an engagement of a kind is worth when it happens
+func (f Formula) Weight(kind Kind) float64 {
+	switch kind {
+	case Like:
+		return f.LikeWeight
+	case Comment:
+		return f.CommentWeight
+	case Save:
+		return f.SaveWeight
+	}
+	return 0
+}
+
+// Decay is the share of its weight an engagement made at a point in time is
+// still worth at now. Engagements from the future are worth more than their
+// weight, which keeps scores stated at different times comparable.
+func (f Formula) Decay(at, now time.Time) float64 {
+	return math.Exp2(-float64(now.Sub(at)) / float64(f.HalfLife))
+}
+
+// Score is the score of an item with the given engagements at now. Boards
+// keep scores up to date as engagements come and go rather than summing them
+// all up again; this is the definition they follow.
+func (f Formula) Score(engagements []Engagement, now time.Time) float64 {
+	score := 0.0
+	for _, engagement := range engagements {
+		score += f.Weight(engagement.Kind) * f.Decay(engagement.At, now)
+	}
+	return score
+}
@@ -0,0 +1,31 @@
+package trending
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFormula = Formula{LikeWeight: 1, CommentWeight: 2, SaveWeight: 3, HalfLife: time.Hour}
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFormula_Decay(t *testing.T) {
+	assert.Equal(t, 1.0, testFormula.Decay(start, start))
+	assert.Equal(t, 0.5, testFormula.Decay(start, start.Add(time.Hour)))
+	assert.Equal(t, 0.25, testFormula.Decay(start, start.Add(2*time.Hour)))
+	assert.Equal(t, 2.0, testFormula.Decay(start.Add(time.Hour), start))
+}
+
+func TestFormula_Score(t *testing.T) {
+	engagements := []Engagement{
+		{Kind: Like, At: start},
+		{Kind: Comment, At: start.Add(time.Hour)},
+		{Kind: Save, At: start.Add(2 * time.Hour)},
+	}
+
+	// 1/4 + 2/2 + 3
+	assert.Equal(t, 4.25, testFormula.Score(engagements, start.Add(2*time.Hour)))
+	assert.Equal(t, 0.0, testFormula.Score(nil, start))
+}